@@ -1038,6 +1038,49 @@ func TestABI_EventById(t *testing.T) {
 	}
 }
 
+// TestABI_ErrorByID checks that errors can be looked up by their selector, and
+// that their revert data is only unpacked by the matching error.
+func TestABI_ErrorByID(t *testing.T) {
+	abi, err := JSON(strings.NewReader(`[
+		{"inputs":[{"internalType":"uint256","name":"available","type":"uint256"},{"internalType":"uint256","name":"required","type":"uint256"}],"name":"InsufficientBalance","type":"error"},
+		{"inputs":[],"name":"Unauthorized","type":"error"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, errABI := range abi.Errors {
+		var id [4]byte
+		copy(id[:], errABI.ID[:4])
+		found, err := abi.ErrorByID(id)
+		if err != nil {
+			t.Fatalf("Failed to look up ABI error: %v", err)
+		}
+		if found.Name != name {
+			t.Errorf("Error %s found for the id of %s", found.Name, name)
+		}
+	}
+	if _, err := abi.ErrorByID([4]byte{0xde, 0xad, 0xbe, 0xef}); err == nil {
+		t.Errorf("ErrorByID should return an error if the id is not found")
+	}
+	// Unpack the arguments of a revert with InsufficientBalance(1, 2)
+	errABI := abi.Errors["InsufficientBalance"]
+	if errABI.Sig != "InsufficientBalance(uint256,uint256)" {
+		t.Fatalf("Error signature mismatch: have %s", errABI.Sig)
+	}
+	data := append(errABI.ID[:4:4], common.LeftPadBytes([]byte{1}, 32)...)
+	data = append(data, common.LeftPadBytes([]byte{2}, 32)...)
+	args, err := errABI.Unpack(data)
+	if err != nil {
+		t.Fatalf("Failed to unpack error: %v", err)
+	}
+	if len(args) != 2 || args[0].(*big.Int).Uint64() != 1 || args[1].(*big.Int).Uint64() != 2 {
+		t.Errorf("Unpacked arguments mismatch: have %v", args)
+	}
+	if _, err := abi.Errors["Unauthorized"].Unpack(data); err == nil {
+		t.Errorf("Unpack should fail on the revert data of another error")
+	}
+}
+
 // TestDoubleDuplicateMethodNames checks that if transfer0 already exists, there won't be a name
 // conflict and that the second transfer method will be renamed transfer1.
 func TestDoubleDuplicateMethodNames(t *testing.T) {
@@ -1124,6 +1167,9 @@ func TestUnpackRevert(t *testing.T) {
 		{"", "", errors.New("invalid data for unpacking")},
 		{"08c379a1", "", errors.New("invalid data for unpacking")},
 		{"08c379a00000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000000d72657665727420726561736f6e00000000000000000000000000000000000000", "revert reason", nil},
+		{"4e487b710000000000000000000000000000000000000000000000000000000000000000", "generic panic", nil},
+		{"4e487b710000000000000000000000000000000000000000000000000000000000000011", "arithmetic underflow or overflow", nil},
+		{"4e487b7100000000000000000000000000000000000000000000000000000000000000ff", "unknown panic code: 0xff", nil},
 	}
 	for index, c := range cases {
 		t.Run(fmt.Sprintf("case %d", index), func(t *testing.T) {
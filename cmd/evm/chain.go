@@ -0,0 +1,129 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/clique"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var ChainDataFlag = cli.StringFlag{
+	Name:  "chaindata",
+	Usage: "chain database directory to replay transactions from",
+}
+
+// openChainDB opens the chain database configured by the flags.
+func openChainDB(ctx *cli.Context) (ethdb.Database, error) {
+	dir := ctx.String(ChainDataFlag.Name)
+	if dir == "" {
+		return nil, errors.New("missing --chaindata directory")
+	}
+	db, err := rawdb.NewLevelDBDatabaseWithFreezer(dir, 256, 16, filepath.Join(dir, "ancient"), "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open chain database: %v", err)
+	}
+	return db, nil
+}
+
+// lookupTransaction returns the block including the given mined transaction,
+// along with the index of the transaction.
+func lookupTransaction(db ethdb.Database, hash common.Hash) (*types.Block, uint64, error) {
+	_, blockHash, number, index := rawdb.ReadTransaction(db, hash)
+	if blockHash == (common.Hash{}) {
+		return nil, 0, fmt.Errorf("transaction %x not found", hash)
+	}
+	block := rawdb.ReadBlock(db, blockHash, number)
+	if block == nil {
+		return nil, 0, fmt.Errorf("block %d [%x] not found", number, blockHash)
+	}
+	return block, index, nil
+}
+
+// replayBlock applies the transactions of the block up to and including the
+// one at index last on top of the parent state. The transactions are traced by
+// the tracer trace returns for them, if any.
+func replayBlock(db ethdb.Database, block *types.Block, last int, trace func(i int, tx *types.Transaction) vm.Tracer) ([]*types.Receipt, error) {
+	number := block.NumberU64()
+	parent := rawdb.ReadHeader(db, block.ParentHash(), number-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent of block %d not found", number)
+	}
+	config := rawdb.ReadChainConfig(db, rawdb.ReadCanonicalHash(db, 0))
+	if config == nil {
+		return nil, errors.New("chain config not found")
+	}
+	statedb, err := state.New(parent.Root, state.NewDatabase(db), nil)
+	if err != nil {
+		return nil, fmt.Errorf("state of block %d unavailable: %v", number-1, err)
+	}
+	// Seals are not verified, the engine is only used for the block author
+	var engine consensus.Engine = ethash.NewFaker()
+	if config.Clique != nil {
+		engine = clique.New(config.Clique, db)
+	}
+	var (
+		chain    = &dbChain{db: db, engine: engine}
+		header   = block.Header()
+		usedGas  = new(uint64)
+		gp       = new(core.GasPool).AddGas(block.GasLimit())
+		receipts []*types.Receipt
+	)
+	if config.DAOForkSupport && config.DAOForkBlock != nil && config.DAOForkBlock.Cmp(block.Number()) == 0 {
+		misc.ApplyDAOHardFork(statedb)
+	}
+	for i, tx := range block.Transactions()[:last+1] {
+		vmConfig := vm.Config{}
+		if tracer := trace(i, tx); tracer != nil {
+			vmConfig = vm.Config{Debug: true, Tracer: tracer}
+		}
+		statedb.Prepare(tx.Hash(), block.Hash(), i)
+		receipt, err := core.ApplyTransaction(config, chain, nil, gp, statedb, header, tx, usedGas, vmConfig)
+		if err != nil {
+			return nil, fmt.Errorf("could not apply tx %d [%v]: %v", i, tx.Hash().Hex(), err)
+		}
+		receipts = append(receipts, receipt)
+	}
+	return receipts, statedb.Error()
+}
+
+// dbChain is a chain context reading headers from the database.
+type dbChain struct {
+	db     ethdb.Reader
+	engine consensus.Engine
+}
+
+// Engine retrieves the consensus engine.
+func (c *dbChain) Engine() consensus.Engine { return c.engine }
+
+// GetHeader retrieves a header from the database by hash and number.
+func (c *dbChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	return rawdb.ReadHeader(c.db, hash, number)
+}
@@ -0,0 +1,88 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/cmd/evm/internal/debugger"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var DebugTxFlag = cli.StringFlag{
+	Name:  "tx",
+	Usage: "hash of a mined transaction to debug",
+}
+
+var debugCommand = cli.Command{
+	Action: debugCmd,
+	Name:   "debug",
+	Usage:  "interactively debugs evm code or a mined transaction",
+	Flags: []cli.Flag{
+		DebugTxFlag,
+		ChainDataFlag,
+	},
+	Description: `
+The debug command executes code like the run command, pausing before the first
+instruction to accept debugger commands. Execution can be stepped into, over
+and out of calls, and resumed until a breakpoint on a pc, opcode, called
+address or storage key is hit. Type 'help' at the prompt for the commands.
+
+With --tx and --chaindata, the given mined transaction is replayed on top of
+its parent state from the chain database instead. The database must not be in
+use by a running node, and must still hold the state of the parent block.`,
+}
+
+func debugCmd(ctx *cli.Context) error {
+	dbg := debugger.New(os.Stdin, os.Stdout)
+	if !ctx.IsSet(DebugTxFlag.Name) {
+		return runCode(ctx, dbg)
+	}
+	db, err := openChainDB(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return debugTransaction(db, common.HexToHash(ctx.String(DebugTxFlag.Name)), dbg)
+}
+
+// debugTransaction replays the given mined transaction with the debugger,
+// after applying the transactions preceding it in its block.
+func debugTransaction(db ethdb.Database, hash common.Hash, dbg vm.Tracer) error {
+	block, index, err := lookupTransaction(db, hash)
+	if err != nil {
+		return err
+	}
+	receipts, err := replayBlock(db, block, int(index), func(i int, tx *types.Transaction) vm.Tracer {
+		if uint64(i) == index {
+			return dbg
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	receipt := receipts[index]
+	fmt.Printf("Transaction %x: status %d, gas used %d\n", hash, receipt.Status, receipt.GasUsed)
+	return nil
+}
@@ -0,0 +1,397 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+// Package debugger implements an interactive EVM debugger on top of vm.Tracer,
+// pausing the execution to let the user inspect it and control its progress.
+package debugger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+const helpText = `Commands:
+  step, s                      execute the next instruction, stepping into calls
+  next, n                      execute the next instruction, stepping over calls
+  out, o                       run until the current call returns
+  continue, c                  run until a breakpoint is hit
+  break, b <kind> <value>      set a breakpoint, kind being one of:
+                                 pc <pc>            instruction at pc
+                                 op <opcode>        instruction with opcode
+                                 addr <address>     call entering address
+                                 storage <key>      SLOAD or SSTORE of key
+  delete, d <id>               delete a breakpoint
+  breakpoints, bl              list the breakpoints
+  where, w                     show the current instruction
+  stack                        show the stack, top first
+  memory [offset [length]]     show the memory
+  storage <key>                show a storage slot of the current contract
+  returndata                   show the return data of the last call
+  quit, q                      abort the execution
+An empty line repeats the last command.`
+
+// stepMode defines when the execution is paused next.
+type stepMode int
+
+const (
+	stepInto stepMode = iota // Pause at the next instruction
+	stepOver                 // Pause at the next instruction of the same or an outer call
+	stepOut                  // Pause at the next instruction of an outer call
+	run                      // Pause at breakpoints only
+)
+
+// breakpoint is a condition pausing the execution.
+type breakpoint struct {
+	id   int
+	kind string
+	pc   uint64
+	op   vm.OpCode
+	addr common.Address
+	key  common.Hash
+}
+
+func (b *breakpoint) String() string {
+	switch b.kind {
+	case "pc":
+		return fmt.Sprintf("pc %d", b.pc)
+	case "op":
+		return fmt.Sprintf("op %v", b.op)
+	case "addr":
+		return fmt.Sprintf("addr %x", b.addr)
+	default:
+		return fmt.Sprintf("storage %x", b.key)
+	}
+}
+
+// hit returns whether the breakpoint matches the instruction about to be
+// executed.
+func (b *breakpoint) hit(s *step) bool {
+	switch b.kind {
+	case "pc":
+		return s.pc == b.pc
+	case "op":
+		return s.op == b.op
+	case "addr":
+		return s.entered && s.contract.Address() == b.addr
+	default:
+		return (s.op == vm.SLOAD || s.op == vm.SSTORE) && len(s.stack.Data()) > 0 &&
+			common.Hash(s.stack.Back(0).Bytes32()) == b.key
+	}
+}
+
+// step is an instruction about to be executed.
+type step struct {
+	env      *vm.EVM
+	pc       uint64
+	op       vm.OpCode
+	gas      uint64
+	cost     uint64
+	memory   *vm.Memory
+	stack    *vm.Stack
+	rData    []byte
+	contract *vm.Contract
+	depth    int
+	entered  bool // Whether the instruction is the first of a call
+}
+
+// Debugger is a vm.Tracer running an interactive debugging session, reading
+// commands from an input and writing the responses to an output.
+type Debugger struct {
+	in  *bufio.Scanner
+	out io.Writer
+
+	mode        stepMode      // Condition for the next pause
+	modeDepth   int           // Call depth when the step mode was set
+	lastDepth   int           // Call depth of the previous instruction
+	lastCommand string        // Command to repeat on an empty line
+	breakpoints []*breakpoint // Active breakpoints
+	nextID      int           // Identifier of the next breakpoint
+	done        bool          // Whether the session ended
+}
+
+// New creates a debugger reading commands from in and writing to out. The
+// execution is paused at its first instruction.
+func New(in io.Reader, out io.Writer) *Debugger {
+	return &Debugger{
+		in:     bufio.NewScanner(in),
+		out:    out,
+		mode:   stepInto,
+		nextID: 1,
+	}
+}
+
+// CaptureStart implements vm.Tracer, reporting the start of the execution.
+func (d *Debugger) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	kind := "call"
+	if create {
+		kind = "create"
+	}
+	fmt.Fprintf(d.out, "Starting %s from %x to %x, gas %d, value %v, input %s\n", kind, from, to, gas, value, hexutil.Encode(input))
+	d.lastDepth = 0
+	return nil
+}
+
+// CaptureState implements vm.Tracer, pausing the execution if needed.
+func (d *Debugger) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, rData []byte, contract *vm.Contract, depth int, err error) error {
+	if d.done {
+		return nil
+	}
+	s := &step{
+		env:      env,
+		pc:       pc,
+		op:       op,
+		gas:      gas,
+		cost:     cost,
+		memory:   memory,
+		stack:    stack,
+		rData:    rData,
+		contract: contract,
+		depth:    depth,
+		entered:  depth > d.lastDepth,
+	}
+	d.lastDepth = depth
+
+	var pause bool
+	switch d.mode {
+	case stepInto:
+		pause = true
+	case stepOver:
+		pause = depth <= d.modeDepth
+	case stepOut:
+		pause = depth < d.modeDepth
+	}
+	for _, b := range d.breakpoints {
+		if b.hit(s) {
+			fmt.Fprintf(d.out, "Breakpoint %d: %v\n", b.id, b)
+			pause = true
+			break
+		}
+	}
+	if pause {
+		d.prompt(s)
+	}
+	return nil
+}
+
+// CaptureFault implements vm.Tracer, reporting failed instructions.
+func (d *Debugger) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, contract *vm.Contract, depth int, err error) error {
+	fmt.Fprintf(d.out, "Fault at depth %d, %x pc %d %v: %v\n", depth, contract.Address(), pc, op, err)
+	return nil
+}
+
+// CaptureEnd implements vm.Tracer, reporting the result of the execution.
+func (d *Debugger) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error {
+	fmt.Fprintf(d.out, "Execution finished, gas used %d, output %s\n", gasUsed, hexutil.Encode(output))
+	if err != nil {
+		fmt.Fprintf(d.out, "Error: %v\n", err)
+	}
+	return nil
+}
+
+// prompt runs commands until one of them resumes the execution.
+func (d *Debugger) prompt(s *step) {
+	d.where(s)
+	for {
+		fmt.Fprint(d.out, "> ")
+		if !d.in.Scan() {
+			// Out of input, let the execution complete
+			fmt.Fprintln(d.out)
+			d.done = true
+			return
+		}
+		line := strings.TrimSpace(d.in.Text())
+		if line == "" {
+			line = d.lastCommand
+		}
+		d.lastCommand = line
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if d.execute(s, fields[0], fields[1:]) {
+			return
+		}
+	}
+}
+
+// execute runs a command, returning whether the execution is to be resumed.
+func (d *Debugger) execute(s *step, command string, args []string) bool {
+	switch command {
+	case "step", "s":
+		return d.resume(s, stepInto)
+	case "next", "n":
+		return d.resume(s, stepOver)
+	case "out", "o":
+		return d.resume(s, stepOut)
+	case "continue", "c":
+		return d.resume(s, run)
+	case "quit", "q":
+		s.env.Cancel()
+		d.done = true
+		return true
+	case "break", "b":
+		d.addBreakpoint(args)
+	case "delete", "d":
+		d.deleteBreakpoint(args)
+	case "breakpoints", "bl":
+		for _, b := range d.breakpoints {
+			fmt.Fprintf(d.out, "%d: %v\n", b.id, b)
+		}
+	case "where", "w":
+		d.where(s)
+	case "stack":
+		data := s.stack.Data()
+		for i := len(data) - 1; i >= 0; i-- {
+			fmt.Fprintf(d.out, "%3d: %#x\n", len(data)-1-i, data[i].Bytes32())
+		}
+	case "memory":
+		d.printMemory(s, args)
+	case "storage":
+		if len(args) != 1 {
+			fmt.Fprintln(d.out, "Usage: storage <key>")
+			break
+		}
+		key, ok := math.ParseBig256(args[0])
+		if !ok {
+			fmt.Fprintf(d.out, "Invalid storage key %q\n", args[0])
+			break
+		}
+		value := s.env.StateDB.GetState(s.contract.Address(), common.BigToHash(key))
+		fmt.Fprintf(d.out, "%#x\n", value)
+	case "returndata":
+		fmt.Fprintln(d.out, hexutil.Encode(s.rData))
+	case "help", "h":
+		fmt.Fprintln(d.out, helpText)
+	default:
+		fmt.Fprintf(d.out, "Unknown command %q, try help\n", command)
+	}
+	return false
+}
+
+// resume sets the condition for the next pause.
+func (d *Debugger) resume(s *step, mode stepMode) bool {
+	d.mode, d.modeDepth = mode, s.depth
+	return true
+}
+
+// where shows the instruction about to be executed.
+func (d *Debugger) where(s *step) {
+	fmt.Fprintf(d.out, "[depth %d] %x pc %d: %v, gas %d, cost %d\n", s.depth, s.contract.Address(), s.pc, s.op, s.gas, s.cost)
+}
+
+func (d *Debugger) addBreakpoint(args []string) {
+	if len(args) != 2 {
+		fmt.Fprintln(d.out, "Usage: break <pc|op|addr|storage> <value>")
+		return
+	}
+	b := &breakpoint{kind: args[0]}
+	switch b.kind {
+	case "pc":
+		pc, err := strconv.ParseUint(args[1], 0, 64)
+		if err != nil {
+			fmt.Fprintf(d.out, "Invalid pc %q\n", args[1])
+			return
+		}
+		b.pc = pc
+	case "op":
+		name := strings.ToUpper(args[1])
+		if b.op = vm.StringToOp(name); b.op.String() != name {
+			fmt.Fprintf(d.out, "Unknown opcode %q\n", args[1])
+			return
+		}
+	case "addr":
+		if !common.IsHexAddress(args[1]) {
+			fmt.Fprintf(d.out, "Invalid address %q\n", args[1])
+			return
+		}
+		b.addr = common.HexToAddress(args[1])
+	case "storage":
+		key, ok := math.ParseBig256(args[1])
+		if !ok {
+			fmt.Fprintf(d.out, "Invalid storage key %q\n", args[1])
+			return
+		}
+		b.key = common.BigToHash(key)
+	default:
+		fmt.Fprintf(d.out, "Unknown breakpoint kind %q\n", args[0])
+		return
+	}
+	b.id = d.nextID
+	d.nextID++
+	d.breakpoints = append(d.breakpoints, b)
+	fmt.Fprintf(d.out, "Breakpoint %d: %v\n", b.id, b)
+}
+
+func (d *Debugger) deleteBreakpoint(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(d.out, "Usage: delete <id>")
+		return
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		fmt.Fprintf(d.out, "Invalid breakpoint %q\n", args[0])
+		return
+	}
+	for i, b := range d.breakpoints {
+		if b.id == id {
+			d.breakpoints = append(d.breakpoints[:i], d.breakpoints[i+1:]...)
+			return
+		}
+	}
+	fmt.Fprintf(d.out, "No breakpoint %d\n", id)
+}
+
+// printMemory shows a range of the memory, 32 bytes per line.
+func (d *Debugger) printMemory(s *step, args []string) {
+	data := s.memory.Data()
+	start, end := uint64(0), uint64(len(data))
+	if len(args) > 0 {
+		offset, err := strconv.ParseUint(args[0], 0, 64)
+		if err != nil {
+			fmt.Fprintf(d.out, "Invalid offset %q\n", args[0])
+			return
+		}
+		start = offset
+	}
+	if len(args) > 1 {
+		length, err := strconv.ParseUint(args[1], 0, 64)
+		if err != nil {
+			fmt.Fprintf(d.out, "Invalid length %q\n", args[1])
+			return
+		}
+		if start+length < end {
+			end = start + length
+		}
+	}
+	for pos := start; pos < end; pos += 32 {
+		lineEnd := pos + 32
+		if lineEnd > end {
+			lineEnd = end
+		}
+		fmt.Fprintf(d.out, "%#06x: %x\n", pos, data[pos:lineEnd])
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package debugger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+)
+
+var (
+	// calleeCode stores 0x2a in slot 7.
+	calleeAddr = common.HexToAddress("0xca11ee")
+	calleeCode = []byte{
+		byte(vm.PUSH1), 0x2a, byte(vm.PUSH1), 0x07, byte(vm.SSTORE), byte(vm.STOP),
+	}
+	// callerCode calls the callee, then stores 1 in slot 0.
+	callerCode = []byte{
+		byte(vm.PUSH1), 0, byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1),
+		byte(vm.PUSH3), 0xca, 0x11, 0xee, byte(vm.GAS), byte(vm.CALL), byte(vm.POP), // pc 0-12
+		byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x00, byte(vm.SSTORE), byte(vm.STOP), // pc 13-18
+	}
+)
+
+// debug runs the caller code with a debugger executing the given commands,
+// returning the debugger output.
+func debug(t *testing.T, commands ...string) string {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetCode(calleeAddr, calleeCode)
+
+	var out bytes.Buffer
+	debugger := New(strings.NewReader(strings.Join(commands, "\n")+"\n"), &out)
+	_, _, err := runtime.Execute(callerCode, nil, &runtime.Config{
+		State:     statedb,
+		EVMConfig: vm.Config{Debug: true, Tracer: debugger},
+	})
+	if err != nil {
+		t.Fatalf("execution failed: %v\n%s", err, out.String())
+	}
+	return out.String()
+}
+
+// pauses returns the instructions the debugger paused at.
+func pauses(output string) []string {
+	var locations []string
+	for _, line := range strings.Split(output, "\n") {
+		if i := strings.Index(line, "[depth"); i >= 0 {
+			line = line[i:]
+			locations = append(locations, line[:strings.Index(line, ",")])
+		}
+	}
+	return locations
+}
+
+func TestStepping(t *testing.T) {
+	tests := []struct {
+		commands []string
+		pauses   []string
+	}{
+		// Stepping into the call
+		{
+			[]string{"b pc 11", "c", "s", "s"},
+			[]string{
+				"[depth 1] " + hexAddr(runtimeAddr) + " pc 0: PUSH1",
+				"[depth 1] " + hexAddr(runtimeAddr) + " pc 11: CALL",
+				"[depth 2] " + hexAddr(calleeAddr) + " pc 0: PUSH1",
+				"[depth 2] " + hexAddr(calleeAddr) + " pc 2: PUSH1",
+			},
+		},
+		// Stepping over the call, repeating with an empty line
+		{
+			[]string{"b pc 11", "c", "n", ""},
+			[]string{
+				"[depth 1] " + hexAddr(runtimeAddr) + " pc 0: PUSH1",
+				"[depth 1] " + hexAddr(runtimeAddr) + " pc 11: CALL",
+				"[depth 1] " + hexAddr(runtimeAddr) + " pc 12: POP",
+				"[depth 1] " + hexAddr(runtimeAddr) + " pc 13: PUSH1",
+			},
+		},
+		// Stepping out of the call
+		{
+			[]string{"b addr " + calleeAddr.Hex(), "c", "o"},
+			[]string{
+				"[depth 1] " + hexAddr(runtimeAddr) + " pc 0: PUSH1",
+				"[depth 2] " + hexAddr(calleeAddr) + " pc 0: PUSH1",
+				"[depth 1] " + hexAddr(runtimeAddr) + " pc 12: POP",
+			},
+		},
+		// Breaking on opcodes and storage keys, deleting breakpoints
+		{
+			[]string{"b op sstore", "b storage 7", "c", "d 1", "c"},
+			[]string{
+				"[depth 1] " + hexAddr(runtimeAddr) + " pc 0: PUSH1",
+				"[depth 2] " + hexAddr(calleeAddr) + " pc 4: SSTORE",
+			},
+		},
+	}
+	for i, tt := range tests {
+		output := debug(t, tt.commands...)
+		if have := pauses(output); strings.Join(have, "\n") != strings.Join(tt.pauses, "\n") {
+			t.Errorf("test %d: pauses mismatch:\nhave %q\nwant %q\noutput:\n%s", i, have, tt.pauses, output)
+		}
+	}
+}
+
+func TestInspection(t *testing.T) {
+	output := debug(t, "b op sstore", "c", "stack", "storage 7", "s", "storage 7", "s", "returndata", "c")
+
+	for _, want := range []string{
+		"  0: 0x0000000000000000000000000000000000000000000000000000000000000007\n" +
+			"  1: 0x000000000000000000000000000000000000000000000000000000000000002a\n",
+		"> 0x0000000000000000000000000000000000000000000000000000000000000000\n",
+		"> 0x000000000000000000000000000000000000000000000000000000000000002a\n",
+		"> 0x\n",
+		"Execution finished, gas used",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestQuit(t *testing.T) {
+	output := debug(t, "q")
+	if len(pauses(output)) != 1 || !strings.Contains(output, "Execution finished") {
+		t.Errorf("unexpected output:\n%s", output)
+	}
+	// Invalid commands must not resume the execution
+	output = debug(t, "b pc x", "b op FOO", "b foo 1", "frobnicate", "q")
+	for _, want := range []string{`Invalid pc "x"`, `Unknown opcode "FOO"`, `Unknown breakpoint kind "foo"`, `Unknown command "frobnicate"`} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+// runtimeAddr is the address runtime.Execute runs the code at.
+var runtimeAddr = common.BytesToAddress([]byte("contract"))
+
+func hexAddr(addr common.Address) string {
+	return common.Bytes2Hex(addr[:])
+}
@@ -0,0 +1,283 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package profiler
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// pbuf encodes protocol buffer messages, enough of them for profile.proto.
+type pbuf []byte
+
+func (b *pbuf) varint(x uint64) {
+	for x >= 0x80 {
+		*b = append(*b, byte(x)|0x80)
+		x >>= 7
+	}
+	*b = append(*b, byte(x))
+}
+
+func (b *pbuf) key(tag int, wireType int) {
+	b.varint(uint64(tag)<<3 | uint64(wireType))
+}
+
+// uint64 encodes a varint field, omitting the default zero.
+func (b *pbuf) uint64(tag int, x uint64) {
+	if x != 0 {
+		b.key(tag, 0)
+		b.varint(x)
+	}
+}
+
+func (b *pbuf) int64(tag int, x int64) {
+	b.uint64(tag, uint64(x))
+}
+
+func (b *pbuf) bool(tag int, x bool) {
+	if x {
+		b.uint64(tag, 1)
+	}
+}
+
+// uint64s encodes a packed repeated varint field.
+func (b *pbuf) uint64s(tag int, xs []uint64) {
+	var packed pbuf
+	for _, x := range xs {
+		packed.varint(x)
+	}
+	b.bytes(tag, packed)
+}
+
+func (b *pbuf) bytes(tag int, data []byte) {
+	b.key(tag, 2)
+	b.varint(uint64(len(data)))
+	*b = append(*b, data...)
+}
+
+// Field numbers of profile.proto.
+const (
+	profileSampleType        = 1
+	profileSample            = 2
+	profileMapping           = 3
+	profileLocation          = 4
+	profileFunction          = 5
+	profileStringTable       = 6
+	profilePeriodType        = 11
+	profilePeriod            = 12
+	profileDefaultSampleType = 14
+
+	valueTypeType = 1
+	valueTypeUnit = 2
+
+	sampleLocation = 1
+	sampleValue    = 2
+
+	mappingID           = 1
+	mappingStart        = 2
+	mappingLimit        = 3
+	mappingFilename     = 5
+	mappingHasFunctions = 7
+
+	locationID      = 1
+	locationMapping = 2
+	locationAddress = 3
+	locationLine    = 4
+
+	lineFunction = 1
+	lineLine     = 2
+
+	functionID         = 1
+	functionName       = 2
+	functionSystemName = 3
+	functionFilename   = 4
+)
+
+// function identifies a function of the profile, the part of a compiled
+// contract in a source file, or an executed code without sources.
+type function struct {
+	name, file string
+}
+
+// location identifies an instruction of an executed code.
+type location struct {
+	contract *Contract
+	pc       uint64
+}
+
+// pprofBuilder accumulates the tables of a profile.
+type pprofBuilder struct {
+	out     pbuf
+	strings map[string]int64
+	table   []string
+
+	mappings  map[*Contract]uint64 // Mapping ids, also the upper address bits
+	functions map[function]uint64
+	locations map[location]uint64
+
+	sources *Sources
+}
+
+func (b *pprofBuilder) string(s string) int64 {
+	if i, ok := b.strings[s]; ok {
+		return i
+	}
+	i := int64(len(b.table))
+	b.strings[s] = i
+	b.table = append(b.table, s)
+	return i
+}
+
+func (b *pprofBuilder) valueType(tag int, typ, unit string) {
+	var vt pbuf
+	vt.int64(valueTypeType, b.string(typ))
+	vt.int64(valueTypeUnit, b.string(unit))
+	b.out.bytes(tag, vt)
+}
+
+// mapping returns the mapping id of the executed code, along with its source
+// map if it was compiled.
+func (b *pprofBuilder) mapping(c *Contract) (uint64, *CodeMap) {
+	m := b.sources.Find(c)
+	if id, ok := b.mappings[c]; ok {
+		return id, m
+	}
+	id := uint64(len(b.mappings) + 1)
+	b.mappings[c] = id
+
+	name := fmt.Sprintf("%x", c.Address)
+	if c.Create {
+		name += " (create)"
+	}
+	var mapping pbuf
+	mapping.uint64(mappingID, id)
+	mapping.uint64(mappingStart, id<<32)
+	mapping.uint64(mappingLimit, id<<32+uint64(len(c.Code)))
+	mapping.int64(mappingFilename, b.string(name))
+	mapping.bool(mappingHasFunctions, true)
+	b.out.bytes(profileMapping, mapping)
+	return id, m
+}
+
+func (b *pprofBuilder) function(fn function) uint64 {
+	if id, ok := b.functions[fn]; ok {
+		return id
+	}
+	id := uint64(len(b.functions) + 1)
+	b.functions[fn] = id
+
+	var f pbuf
+	f.uint64(functionID, id)
+	f.int64(functionName, b.string(fn.name))
+	f.int64(functionSystemName, b.string(fn.name))
+	f.int64(functionFilename, b.string(fn.file))
+	b.out.bytes(profileFunction, f)
+	return id
+}
+
+// location returns the id of the location of an instruction, named after the
+// compiled contract and source line if known, or the code address otherwise.
+func (b *pprofBuilder) location(c *Contract, pc uint64) uint64 {
+	loc := location{c, pc}
+	if id, ok := b.locations[loc]; ok {
+		return id
+	}
+	id := uint64(len(b.locations) + 1)
+	b.locations[loc] = id
+
+	mappingID, m := b.mapping(c)
+	var (
+		fn   = function{name: fmt.Sprintf("%x", c.Address)}
+		line int
+	)
+	if c.Create {
+		fn.name += " (create)"
+	}
+	if m != nil {
+		fn.name = m.Name
+		if src, ok := m.Lookup(pc); ok {
+			fn.file, line = src.File.Path, src.Line
+		}
+	}
+	var l pbuf
+	l.uint64(lineFunction, b.function(fn))
+	l.int64(lineLine, int64(line))
+
+	var location pbuf
+	location.uint64(locationID, id)
+	location.uint64(locationMapping, mappingID)
+	location.uint64(locationAddress, mappingID<<32+pc)
+	location.bytes(locationLine, l)
+	b.out.bytes(profileLocation, location)
+	return id
+}
+
+// sample adds the samples of the instructions executed by a call tree node and
+// its descendants.
+func (b *pprofBuilder) sample(n *CallNode) {
+	pcs := make([]uint64, 0, len(n.Hits))
+	for pc := range n.Hits {
+		pcs = append(pcs, pc)
+	}
+	sort.Slice(pcs, func(i, j int) bool { return pcs[i] < pcs[j] })
+
+	for _, pc := range pcs {
+		stack := []uint64{b.location(n.Contract, pc)}
+		for caller := n; caller.Parent != nil; caller = caller.Parent {
+			stack = append(stack, b.location(caller.Parent.Contract, caller.CallPC))
+		}
+		var sample pbuf
+		sample.uint64s(sampleLocation, stack)
+		sample.uint64s(sampleValue, []uint64{n.Hits[pc], n.Gas[pc]})
+		b.out.bytes(profileSample, sample)
+	}
+	for _, child := range n.order {
+		b.sample(child)
+	}
+}
+
+// WritePprof writes the call tree as a gzipped pprof profile, sampling the
+// executions and the gas of every instruction along with its call stack. The
+// instructions of compiled contracts are located in their sources.
+func WritePprof(w io.Writer, p *Profiler, sources *Sources) error {
+	b := &pprofBuilder{
+		strings:   map[string]int64{"": 0},
+		table:     []string{""},
+		mappings:  make(map[*Contract]uint64),
+		functions: make(map[function]uint64),
+		locations: make(map[location]uint64),
+		sources:   sources,
+	}
+	b.valueType(profileSampleType, "instructions", "count")
+	b.valueType(profileSampleType, "gas", "gas")
+	for _, root := range p.rootOrder {
+		b.sample(root)
+	}
+	b.valueType(profilePeriodType, "gas", "gas")
+	b.out.uint64(profilePeriod, 1)
+	b.out.int64(profileDefaultSampleType, b.string("gas"))
+	for _, s := range b.table {
+		b.out.bytes(profileStringTable, []byte(s))
+	}
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(b.out); err != nil {
+		return err
+	}
+	return zw.Close()
+}
@@ -0,0 +1,350 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+// Package profiler implements a vm.Tracer aggregating the code coverage and
+// gas usage of contracts over executions, and reports on them as LCOV coverage
+// and pprof profiles.
+package profiler
+
+import (
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Contract is the aggregated execution of a contract code.
+type Contract struct {
+	Hash    common.Hash    // Hash of the code
+	Address common.Address // Address the code first executed at
+	Code    []byte         // Executed code, the init code of creations
+	Create  bool           // Whether the code is an init code
+
+	Frames   uint64                // Number of call frames executing the code
+	Hits     map[uint64]uint64     // Number of executions per pc
+	Gas      map[uint64]uint64     // Gas spent per pc, exclusive of calls
+	Branches map[uint64]*[2]uint64 // Number of JUMPIs per pc, not taken and taken
+	Ops      map[vm.OpCode]*OpStat // Executions and gas per opcode
+}
+
+// OpStat is the aggregated execution of an opcode.
+type OpStat struct {
+	Count uint64
+	Gas   uint64
+}
+
+// ExclusiveGas returns the gas spent by the instructions of the code,
+// excluding the gas spent by the code it calls.
+func (c *Contract) ExclusiveGas() uint64 {
+	var gas uint64
+	for _, g := range c.Gas {
+		gas += g
+	}
+	return gas
+}
+
+// callSite identifies a call tree node among the children of its parent.
+type callSite struct {
+	pc   uint64
+	hash common.Hash
+}
+
+// CallNode is a node of the aggregated call tree, standing for the call frames
+// executing the same code from the same call site of the same parent node.
+type CallNode struct {
+	Contract  *Contract
+	Parent    *CallNode         // Calling node, nil for roots
+	CallPC    uint64            // Pc of the call in the parent code
+	Frames    uint64            // Number of call frames
+	Hits      map[uint64]uint64 // Number of executions per pc
+	Gas       map[uint64]uint64 // Gas spent per pc, exclusive of calls
+	Inclusive uint64            // Gas spent including calls
+
+	children map[callSite]*CallNode
+	order    []*CallNode // Children in insertion order, for deterministic reports
+}
+
+// Children returns the nodes called from the node.
+func (n *CallNode) Children() []*CallNode {
+	return n.order
+}
+
+func (n *CallNode) child(pc uint64, contract *Contract) *CallNode {
+	site := callSite{pc, contract.Hash}
+	if child, ok := n.children[site]; ok {
+		return child
+	}
+	child := newCallNode(contract, n, pc)
+	n.children[site] = child
+	n.order = append(n.order, child)
+	return child
+}
+
+func newCallNode(contract *Contract, parent *CallNode, pc uint64) *CallNode {
+	return &CallNode{
+		Contract: contract,
+		Parent:   parent,
+		CallPC:   pc,
+		Hits:     make(map[uint64]uint64),
+		Gas:      make(map[uint64]uint64),
+		children: make(map[callSite]*CallNode),
+	}
+}
+
+// frame is a call frame being executed.
+type frame struct {
+	node     *CallNode
+	startGas uint64 // Gas available to the frame
+	gas      uint64 // Gas spent so far, including calls
+
+	call     bool      // Whether the last instruction was a call
+	callPC   uint64    // Pc of the last call
+	callOp   vm.OpCode // Opcode of the last call
+	callGas  uint64    // Gas available before the last call
+	childGas uint64    // Gas spent by the frame of the last call
+
+	faulted bool      // Whether the frame consumed all its gas on failure
+	faultPC uint64    // Pc of the failing instruction
+	faultOp vm.OpCode // Opcode of the failing instruction
+}
+
+// Profiler is a vm.Tracer aggregating the executions it traces into the
+// executed contracts and a call tree.
+//
+// The gas of an instruction is the gas it consumes, except for calls and
+// creations, which are credited with the gas consumed minus the gas spent by
+// the called frame. The gas left unused by a failing frame is credited to the
+// failing instruction.
+type Profiler struct {
+	contracts map[common.Hash]*Contract
+	order     []*Contract
+	roots     map[common.Hash]*CallNode
+	rootOrder []*CallNode
+
+	create bool     // Whether the traced execution is a creation
+	stack  []*frame // Call frames being executed
+}
+
+// New creates an empty profiler.
+func New() *Profiler {
+	return &Profiler{
+		contracts: make(map[common.Hash]*Contract),
+		roots:     make(map[common.Hash]*CallNode),
+	}
+}
+
+// Contracts returns the executed contracts, in order of first execution.
+func (p *Profiler) Contracts() []*Contract {
+	return p.order
+}
+
+// Roots returns the roots of the call tree, one per code executed by a
+// traced execution.
+func (p *Profiler) Roots() []*CallNode {
+	return p.rootOrder
+}
+
+// InclusiveGas returns the gas spent by the frames executing the code,
+// including the frames they call. Recursive calls are only counted once.
+func (p *Profiler) InclusiveGas(c *Contract) uint64 {
+	var (
+		gas  uint64
+		walk func(n *CallNode)
+	)
+	walk = func(n *CallNode) {
+		if n.Contract == c {
+			gas += n.Inclusive
+			return
+		}
+		for _, child := range n.order {
+			walk(child)
+		}
+	}
+	for _, root := range p.rootOrder {
+		walk(root)
+	}
+	return gas
+}
+
+// CaptureStart implements vm.Tracer, starting the profiling of an execution.
+func (p *Profiler) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	p.create = create
+	p.stack = p.stack[:0]
+	return nil
+}
+
+// CaptureState implements vm.Tracer, accounting the instruction.
+func (p *Profiler) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, rData []byte, contract *vm.Contract, depth int, err error) error {
+	p.leave(depth, gas)
+	if depth > len(p.stack) {
+		p.enter(contract, gas)
+	}
+	f := p.stack[len(p.stack)-1]
+	c := f.node.Contract
+
+	c.Hits[pc]++
+	f.node.Hits[pc]++
+	stat := c.Ops[op]
+	if stat == nil {
+		stat = new(OpStat)
+		c.Ops[op] = stat
+	}
+	stat.Count++
+
+	if err != nil {
+		// The instruction failed before executing, consuming the frame's gas
+		f.faulted, f.faultPC, f.faultOp = true, pc, op
+		return nil
+	}
+	switch op {
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL, vm.CREATE, vm.CREATE2:
+		// Accounted once the call returns
+		f.call, f.callPC, f.callOp, f.callGas, f.childGas = true, pc, op, gas, 0
+	case vm.JUMPI:
+		branch := c.Branches[pc]
+		if branch == nil {
+			branch = new([2]uint64)
+			c.Branches[pc] = branch
+		}
+		if stack.Back(1).Sign() != 0 {
+			branch[1]++
+		} else {
+			branch[0]++
+		}
+		p.charge(f, pc, op, cost)
+	default:
+		p.charge(f, pc, op, cost)
+	}
+	return nil
+}
+
+// CaptureFault implements vm.Tracer, recording the failure of an instruction.
+func (p *Profiler) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, contract *vm.Contract, depth int, err error) error {
+	if depth == 0 || depth > len(p.stack) || errors.Is(err, vm.ErrExecutionReverted) {
+		return nil
+	}
+	f := p.stack[depth-1]
+	f.faulted, f.faultPC, f.faultOp = true, pc, op
+	return nil
+}
+
+// CaptureEnd implements vm.Tracer, completing the profiling of an execution.
+func (p *Profiler) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error {
+	p.leave(0, 0)
+	return nil
+}
+
+// enter pushes a frame executing the contract.
+func (p *Profiler) enter(contract *vm.Contract, gas uint64) {
+	var (
+		parent *frame
+		create = p.create
+	)
+	if len(p.stack) > 0 {
+		parent = p.stack[len(p.stack)-1]
+		create = parent.callOp == vm.CREATE || parent.callOp == vm.CREATE2
+	}
+	c := p.contract(contract, create)
+	c.Frames++
+
+	var node *CallNode
+	if parent == nil {
+		if node = p.roots[c.Hash]; node == nil {
+			node = newCallNode(c, nil, 0)
+			p.roots[c.Hash] = node
+			p.rootOrder = append(p.rootOrder, node)
+		}
+	} else {
+		node = parent.node.child(parent.callPC, c)
+	}
+	node.Frames++
+	p.stack = append(p.stack, &frame{node: node, startGas: gas})
+}
+
+// leave pops the frames deeper than depth, given the gas available to the
+// instruction executed next at depth.
+func (p *Profiler) leave(depth int, gas uint64) {
+	for len(p.stack) > depth {
+		f := p.stack[len(p.stack)-1]
+		p.stack = p.stack[:len(p.stack)-1]
+
+		if f.faulted && f.gas < f.startGas {
+			p.credit(f, f.faultPC, f.faultOp, f.startGas-f.gas)
+			f.gas = f.startGas
+		}
+		f.node.Inclusive += f.gas
+		if len(p.stack) > 0 {
+			p.stack[len(p.stack)-1].childGas += f.gas
+		}
+	}
+	if depth == 0 || depth > len(p.stack) {
+		return
+	}
+	// Account the call returning to the frame, if any
+	if f := p.stack[depth-1]; f.call {
+		f.call = false
+
+		spent := f.callGas - gas
+		f.gas += spent
+		if spent > f.childGas {
+			p.credit(f, f.callPC, f.callOp, spent-f.childGas)
+		}
+	}
+}
+
+// charge accounts the gas spent by an instruction of the frame.
+func (p *Profiler) charge(f *frame, pc uint64, op vm.OpCode, gas uint64) {
+	f.gas += gas
+	p.credit(f, pc, op, gas)
+}
+
+// credit credits gas to an instruction of the frame, exclusive of calls.
+func (p *Profiler) credit(f *frame, pc uint64, op vm.OpCode, gas uint64) {
+	f.node.Gas[pc] += gas
+	f.node.Contract.Gas[pc] += gas
+	f.node.Contract.Ops[op].Gas += gas
+}
+
+// contract returns the aggregate of the code executed by the contract.
+func (p *Profiler) contract(contract *vm.Contract, create bool) *Contract {
+	hash := contract.CodeHash
+	if hash == (common.Hash{}) {
+		hash = crypto.Keccak256Hash(contract.Code)
+	}
+	if c, ok := p.contracts[hash]; ok {
+		return c
+	}
+	addr := contract.Address()
+	if contract.CodeAddr != nil {
+		addr = *contract.CodeAddr
+	}
+	c := &Contract{
+		Hash:     hash,
+		Address:  addr,
+		Code:     common.CopyBytes(contract.Code),
+		Create:   create,
+		Hits:     make(map[uint64]uint64),
+		Gas:      make(map[uint64]uint64),
+		Branches: make(map[uint64]*[2]uint64),
+		Ops:      make(map[vm.OpCode]*OpStat),
+	}
+	p.contracts[hash] = c
+	p.order = append(p.order, c)
+	return c
+}
@@ -0,0 +1,193 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package profiler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+)
+
+var (
+	callerAddr = common.HexToAddress("0xca11e4")
+	calleeAddr = common.HexToAddress("0xca11ee")
+
+	// callerCode calls the callee, jumping to 0x26 if the call succeeds:
+	//
+	//	00 PUSH1 0x20, PUSH1 0, PUSH1 0, PUSH1 0, PUSH1 0, PUSH20 callee
+	//	1f GAS, CALL
+	//	21 PUSH1 0x26, JUMPI
+	//	24 STOP, STOP
+	//	26 JUMPDEST, STOP
+	callerCode = common.FromHex("60206000600060006000" + "73" + fmt.Sprintf("%x", calleeAddr) + "5af1" + "602657" + "0000" + "5b00")
+
+	// callerSrcMap maps the call to line 1, the JUMPI to line 2 and the
+	// branches to lines 3 and 4 of callerSource.
+	callerSrcMap = strings.Join([]string{"0:5:0", "", "", "", "", "", "", "", "6:5", "", "12:5", "", "18:5", ""}, ";")
+	callerSource = "line1\nline2\nline3\nline4\n"
+
+	// Callee codes returning a word, and failing
+	returnCode  = common.FromHex("600160005260206000f3")
+	invalidCode = common.FromHex("6001fe")
+)
+
+// profile runs the caller with the callee code, returning the profiler and the
+// gas used.
+func profile(t *testing.T, callee []byte) (*Profiler, uint64) {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetCode(callerAddr, callerCode)
+	statedb.SetCode(calleeAddr, callee)
+
+	prof := New()
+	cfg := &runtime.Config{
+		State:     statedb,
+		GasLimit:  100000,
+		EVMConfig: vm.Config{Debug: true, Tracer: prof},
+	}
+	_, left, err := runtime.Call(callerAddr, nil, cfg)
+	if err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	return prof, cfg.GasLimit - left
+}
+
+func TestProfiler(t *testing.T) {
+	tests := []struct {
+		name   string
+		callee []byte
+		branch [2]uint64
+		stop   uint64 // Pc of the STOP executed by the caller
+	}{
+		{"return", returnCode, [2]uint64{0, 1}, 0x27},
+		{"invalid", invalidCode, [2]uint64{1, 0}, 0x24},
+	}
+	for _, tt := range tests {
+		prof, used := profile(t, tt.callee)
+
+		contracts := prof.Contracts()
+		if len(contracts) != 2 {
+			t.Fatalf("%s: %d contracts executed, want 2", tt.name, len(contracts))
+		}
+		caller, callee := contracts[0], contracts[1]
+		if caller.Address != callerAddr || callee.Address != calleeAddr {
+			t.Fatalf("%s: contracts %x and %x executed, want %x and %x", tt.name, caller.Address, callee.Address, callerAddr, calleeAddr)
+		}
+		if caller.Hits[0x23] != 1 || caller.Hits[tt.stop] != 1 {
+			t.Errorf("%s: caller hits %v, want JUMPI and STOP at %#x", tt.name, caller.Hits, tt.stop)
+		}
+		if b := caller.Branches[0x23]; b == nil || *b != tt.branch {
+			t.Errorf("%s: JUMPI branches %v, want %v", tt.name, b, tt.branch)
+		}
+		// The gas used is split between the contracts, the caller including the callee
+		if gas := caller.ExclusiveGas() + callee.ExclusiveGas(); gas != used {
+			t.Errorf("%s: exclusive gas %d, want %d", tt.name, gas, used)
+		}
+		if gas := prof.InclusiveGas(caller); gas != used {
+			t.Errorf("%s: caller inclusive gas %d, want %d", tt.name, gas, used)
+		}
+		if gas := prof.InclusiveGas(callee); gas != callee.ExclusiveGas() {
+			t.Errorf("%s: callee inclusive gas %d, want %d", tt.name, gas, callee.ExclusiveGas())
+		}
+		// The call tree nests the callee under the call
+		roots := prof.Roots()
+		if len(roots) != 1 || len(roots[0].Children()) != 1 {
+			t.Fatalf("%s: call tree mismatch", tt.name)
+		}
+		if child := roots[0].Children()[0]; child.Contract != callee || child.CallPC != 0x20 || child.Inclusive != callee.ExclusiveGas() {
+			t.Errorf("%s: callee node mismatch: pc %#x, gas %d", tt.name, child.CallPC, child.Inclusive)
+		}
+	}
+}
+
+// loadSources writes the caller source and returns it along with its
+// compilation.
+func loadSources(t *testing.T, dir string) *Sources {
+	if err := ioutil.WriteFile(filepath.Join(dir, "caller.sol"), []byte(callerSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+	combined := fmt.Sprintf(`{
+		"contracts": {"caller.sol:Caller": {"bin": "", "bin-runtime": "%x", "srcmap": "", "srcmap-runtime": "%s"}},
+		"sourceList": ["caller.sol"]
+	}`, callerCode, callerSrcMap)
+
+	sources, err := LoadSources([]byte(combined), dir)
+	if err != nil {
+		t.Fatalf("failed to load sources: %v", err)
+	}
+	return sources
+}
+
+func TestLCOV(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profiler-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sources := loadSources(t, dir)
+
+	prof, _ := profile(t, returnCode)
+	var out bytes.Buffer
+	if err := WriteLCOV(&out, prof, sources); err != nil {
+		t.Fatalf("failed to write coverage: %v", err)
+	}
+	want := "TN:\nSF:" + filepath.Join(dir, "caller.sol") + "\n" +
+		"BRDA:2,0,0,0\nBRDA:2,0,1,1\nBRF:2\nBRH:1\n" +
+		"DA:1,1\nDA:2,1\nDA:3,0\nDA:4,1\nLF:4\nLH:3\nend_of_record\n"
+	if out.String() != want {
+		t.Errorf("coverage mismatch:\nhave\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestPprof(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profiler-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sources := loadSources(t, dir)
+
+	prof, _ := profile(t, returnCode)
+	var out bytes.Buffer
+	if err := WritePprof(&out, prof, sources); err != nil {
+		t.Fatalf("failed to write profile: %v", err)
+	}
+	r, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatalf("profile not gzipped: %v", err)
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("profile not gzipped: %v", err)
+	}
+	// The callee is named after its address, the caller after its sources
+	for _, s := range []string{"gas", "caller.sol:Caller", filepath.Join(dir, "caller.sol"), fmt.Sprintf("%x", calleeAddr)} {
+		if !bytes.Contains(data, []byte(s)) {
+			t.Errorf("profile lacks string %q", s)
+		}
+	}
+}
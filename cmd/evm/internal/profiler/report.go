@@ -0,0 +1,174 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package profiler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/core/asm"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// maxReportedOps is the number of most expensive opcodes listed per contract.
+const maxReportedOps = 10
+
+// WriteReport writes a summary of the gas usage and coverage of the executed
+// contracts.
+func WriteReport(w io.Writer, p *Profiler, sources *Sources) error {
+	bw := bufio.NewWriter(w)
+	for _, c := range p.Contracts() {
+		kind := "code"
+		if c.Create {
+			kind = "init code"
+		}
+		fmt.Fprintf(bw, "Contract %x, %s %x", c.Address, kind, c.Hash)
+		if m := sources.Find(c); m != nil {
+			fmt.Fprintf(bw, " (%s)", m.Name)
+		}
+		var covered, total int
+		it := asm.NewInstructionIterator(c.Code)
+		for it.Next() {
+			if total++; c.Hits[it.PC()] > 0 {
+				covered++
+			}
+		}
+		fmt.Fprintf(bw, "\n  frames %d, gas %d inclusive, %d exclusive, %d/%d instructions executed\n",
+			c.Frames, p.InclusiveGas(c), c.ExclusiveGas(), covered, total)
+
+		ops := make([]vm.OpCode, 0, len(c.Ops))
+		for op := range c.Ops {
+			ops = append(ops, op)
+		}
+		sort.Slice(ops, func(i, j int) bool {
+			if c.Ops[ops[i]].Gas != c.Ops[ops[j]].Gas {
+				return c.Ops[ops[i]].Gas > c.Ops[ops[j]].Gas
+			}
+			return ops[i] < ops[j]
+		})
+		if len(ops) > maxReportedOps {
+			ops = ops[:maxReportedOps]
+		}
+		for _, op := range ops {
+			fmt.Fprintf(bw, "  %-14v %10d executions %12d gas\n", op, c.Ops[op].Count, c.Ops[op].Gas)
+		}
+	}
+	return bw.Flush()
+}
+
+// lineCoverage is the coverage of a source line.
+type lineCoverage struct {
+	hits     uint64
+	branches [][2]uint64 // Executions of the JUMPIs, not taken and taken
+	reached  []bool      // Whether the JUMPIs executed
+}
+
+// WriteLCOV writes the line and branch coverage of the compiled contracts in
+// the LCOV trace file format, the branches being the JUMPI instructions. The
+// coverage of a line is the highest execution count of its instructions.
+func WriteLCOV(w io.Writer, p *Profiler, sources *Sources) error {
+	// Aggregate the executions of the contracts per compiled code
+	executed := make(map[*CodeMap][]*Contract)
+	for _, c := range p.Contracts() {
+		if m := sources.Find(c); m != nil {
+			executed[m] = append(executed[m], c)
+		}
+	}
+	files := make(map[*SourceFile]map[int]*lineCoverage)
+	for _, m := range sources.Codes {
+		for _, pc := range m.pcs {
+			loc, ok := m.locs[pc]
+			if !ok {
+				continue
+			}
+			lines := files[loc.File]
+			if lines == nil {
+				lines = make(map[int]*lineCoverage)
+				files[loc.File] = lines
+			}
+			line := lines[loc.Line]
+			if line == nil {
+				line = new(lineCoverage)
+				lines[loc.Line] = line
+			}
+			var (
+				hits   uint64
+				branch [2]uint64
+			)
+			for _, c := range executed[m] {
+				hits += c.Hits[pc]
+				if b := c.Branches[pc]; b != nil {
+					branch[0] += b[0]
+					branch[1] += b[1]
+				}
+			}
+			if hits > line.hits {
+				line.hits = hits
+			}
+			if vm.OpCode(m.code[pc]) == vm.JUMPI {
+				line.branches = append(line.branches, branch)
+				line.reached = append(line.reached, hits > 0)
+			}
+		}
+	}
+	bw := bufio.NewWriter(w)
+	for _, file := range sources.Files {
+		lines := files[file]
+		if lines == nil {
+			continue
+		}
+		numbers := make([]int, 0, len(lines))
+		for number := range lines {
+			numbers = append(numbers, number)
+		}
+		sort.Ints(numbers)
+
+		fmt.Fprintf(bw, "TN:\nSF:%s\n", file.Path)
+		var found, hit, branchesFound, branchesHit int
+		for _, number := range numbers {
+			line := lines[number]
+			for block, branch := range line.branches {
+				for taken, count := range branch {
+					branchesFound++
+					switch {
+					case !line.reached[block]:
+						fmt.Fprintf(bw, "BRDA:%d,%d,%d,-\n", number, block, taken)
+					default:
+						if count > 0 {
+							branchesHit++
+						}
+						fmt.Fprintf(bw, "BRDA:%d,%d,%d,%d\n", number, block, taken, count)
+					}
+				}
+			}
+		}
+		if branchesFound > 0 {
+			fmt.Fprintf(bw, "BRF:%d\nBRH:%d\n", branchesFound, branchesHit)
+		}
+		for _, number := range numbers {
+			found++
+			if lines[number].hits > 0 {
+				hit++
+			}
+			fmt.Fprintf(bw, "DA:%d,%d\n", number, lines[number].hits)
+		}
+		fmt.Fprintf(bw, "LF:%d\nLH:%d\nend_of_record\n", found, hit)
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,213 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package profiler
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/asm"
+)
+
+// SourceFile is a source file of compiled contracts.
+type SourceFile struct {
+	Path  string
+	lines []int // Offsets of the line starts
+}
+
+// line returns the line number, starting at 1, of the byte offset.
+func (f *SourceFile) line(offset int) int {
+	return sort.Search(len(f.lines), func(i int) bool { return f.lines[i] > offset })
+}
+
+// Location is the source location of an instruction.
+type Location struct {
+	File *SourceFile
+	Line int
+}
+
+// CodeMap maps the instructions of a compiled code to their source locations.
+type CodeMap struct {
+	Name   string              // Name of the compiled contract
+	Create bool                // Whether the code is the init code
+	code   []byte              // Compiled code, unlinked libraries zeroed
+	pcs    []uint64            // Pcs of the instructions, excluding metadata
+	locs   map[uint64]Location // Locations of the mapped instructions
+}
+
+// Lookup returns the source location of the instruction at pc.
+func (m *CodeMap) Lookup(pc uint64) (Location, bool) {
+	loc, ok := m.locs[pc]
+	return loc, ok
+}
+
+// matches reports whether the code executed is the compiled code. The
+// arguments of pushes and the metadata may differ, for immutables, linked
+// libraries and builds of the same sources to match. Init codes may be
+// followed by constructor arguments.
+func (m *CodeMap) matches(code []byte, create bool) bool {
+	if create != m.Create || len(code) < len(m.code) || (!create && len(code) != len(m.code)) {
+		return false
+	}
+	it := asm.NewInstructionIterator(code)
+	for _, pc := range m.pcs {
+		if !it.Next() || it.PC() != pc || byte(it.Op()) != m.code[pc] {
+			return false
+		}
+	}
+	return true
+}
+
+// Sources are the contracts compiled by solc, along with their source files.
+type Sources struct {
+	Files []*SourceFile
+	Codes []*CodeMap
+}
+
+// solcOutput is the output of solc --combined-json with, at least, the bin,
+// bin-runtime, srcmap and srcmap-runtime fields.
+type solcOutput struct {
+	Contracts map[string]struct {
+		Bin           string `json:"bin"`
+		BinRuntime    string `json:"bin-runtime"`
+		SrcMap        string `json:"srcmap"`
+		SrcMapRuntime string `json:"srcmap-runtime"`
+	} `json:"contracts"`
+	SourceList []string `json:"sourceList"`
+}
+
+// libraryPlaceholder matches the placeholders of unlinked library addresses.
+var libraryPlaceholder = regexp.MustCompile(`__.{36}__`)
+
+// LoadSources parses the output of solc --combined-json, reading the source
+// files it lists from dir.
+func LoadSources(combinedJSON []byte, dir string) (*Sources, error) {
+	var output solcOutput
+	if err := json.Unmarshal(combinedJSON, &output); err != nil {
+		return nil, fmt.Errorf("invalid solc output: %v", err)
+	}
+	sources := new(Sources)
+	for _, name := range output.SourceList {
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		file := &SourceFile{Path: path, lines: []int{0}}
+		for i, c := range content {
+			if c == '\n' {
+				file.lines = append(file.lines, i+1)
+			}
+		}
+		sources.Files = append(sources.Files, file)
+	}
+	names := make([]string, 0, len(output.Contracts))
+	for name := range output.Contracts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		contract := output.Contracts[name]
+		for _, compiled := range []struct {
+			bin, srcmap string
+			create      bool
+		}{
+			{contract.Bin, contract.SrcMap, true},
+			{contract.BinRuntime, contract.SrcMapRuntime, false},
+		} {
+			if compiled.bin == "" {
+				continue // Abstract contract or interface
+			}
+			code, err := hex.DecodeString(libraryPlaceholder.ReplaceAllString(compiled.bin, strings.Repeat("0", 40)))
+			if err != nil {
+				return nil, fmt.Errorf("invalid code of %s: %v", name, err)
+			}
+			m, err := sources.mapCode(code, compiled.srcmap)
+			if err != nil {
+				return nil, fmt.Errorf("invalid source map of %s: %v", name, err)
+			}
+			m.Name, m.Create = name, compiled.create
+			sources.Codes = append(sources.Codes, m)
+		}
+	}
+	return sources, nil
+}
+
+// Find returns the compiled code matching the executed contract, or nil if
+// none does.
+func (s *Sources) Find(c *Contract) *CodeMap {
+	if s == nil {
+		return nil
+	}
+	for _, m := range s.Codes {
+		if m.matches(c.Code, c.Create) {
+			return m
+		}
+	}
+	return nil
+}
+
+// mapCode maps the instructions of the code with the solc source map, a list
+// of s:l:f:j:m entries per instruction, omitted fields being those of the
+// previous entry.
+func (s *Sources) mapCode(code []byte, srcmap string) (*CodeMap, error) {
+	m := &CodeMap{code: code, locs: make(map[uint64]Location)}
+	it := asm.NewInstructionIterator(code)
+	for it.Next() {
+		m.pcs = append(m.pcs, it.PC())
+	}
+	if srcmap == "" {
+		return m, nil
+	}
+	// The source map has no entries for the metadata following the code
+	entries := strings.Split(srcmap, ";")
+	if len(entries) < len(m.pcs) {
+		m.pcs = m.pcs[:len(entries)]
+	}
+	fields := []int{-1, 0, -1}
+	for i, entry := range entries {
+		if i >= len(m.pcs) {
+			return nil, fmt.Errorf("%d entries for %d instructions", len(entries), len(m.pcs))
+		}
+		for j, field := range strings.Split(entry, ":") {
+			if j >= len(fields) || field == "" {
+				continue
+			}
+			n, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("entry %d: %v", i, err)
+			}
+			fields[j] = n
+		}
+		offset, file := fields[0], fields[2]
+		if offset < 0 || file < 0 || file >= len(s.Files) {
+			continue // Generated code
+		}
+		m.locs[m.pcs[i]] = Location{File: s.Files[file], Line: s.Files[file].line(offset)}
+	}
+	return m, nil
+}
@@ -0,0 +1,283 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package t8ntool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// bbInput contains the inputs of the block builder: the env, transactions and
+// result of a transition, along with the header fields not known to it.
+type bbInput struct {
+	Env    *stEnv             `json:"env,omitempty"`
+	Txs    types.Transactions `json:"txs,omitempty"`
+	Result *bbResult          `json:"result,omitempty"`
+	Header *bbHeader          `json:"header,omitempty"`
+	Ommers []hexutil.Bytes    `json:"ommers,omitempty"`
+}
+
+// bbResult contains the fields of the transition result used in the header.
+type bbResult struct {
+	StateRoot   common.Hash           `json:"stateRoot"`
+	TxRoot      common.Hash           `json:"txRoot"`
+	ReceiptRoot common.Hash           `json:"receiptRoot"`
+	Bloom       types.Bloom           `json:"logsBloom"`
+	Rejected    []int                 `json:"rejected"`
+	Difficulty  *math.HexOrDecimal256 `json:"currentDifficulty"`
+	GasUsed     math.HexOrDecimal64   `json:"gasUsed"`
+}
+
+// bbHeader contains the header fields which are not part of a transition.
+type bbHeader struct {
+	ParentHash *common.Hash     `json:"parentHash"`
+	Extra      hexutil.Bytes    `json:"extraData"`
+	MixDigest  common.Hash      `json:"mixHash"`
+	Nonce      types.BlockNonce `json:"nonce"`
+}
+
+// BuildBlock assembles a block from the inputs and outputs of a transition,
+// optionally sealing it.
+func BuildBlock(ctx *cli.Context) error {
+	// Configure the go-ethereum logger
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(ctx.Int(VerbosityFlag.Name)))
+	log.Root().SetHandler(glogger)
+
+	baseDir, err := createBasedir(ctx)
+	if err != nil {
+		return err
+	}
+	var (
+		envStr    = ctx.String(InputEnvFlag.Name)
+		txsStr    = ctx.String(InputTxsFlag.Name)
+		resultStr = ctx.String(InputResultFlag.Name)
+		headerStr = ctx.String(InputHeaderFlag.Name)
+		ommersStr = ctx.String(InputOmmersFlag.Name)
+		inputData = &bbInput{}
+	)
+	if envStr == stdinSelector || txsStr == stdinSelector || resultStr == stdinSelector ||
+		headerStr == stdinSelector || ommersStr == stdinSelector {
+		decoder := json.NewDecoder(os.Stdin)
+		if err := decoder.Decode(inputData); err != nil {
+			return NewError(ErrorJson, fmt.Errorf("failed unmarshaling stdin: %v", err))
+		}
+	}
+	if envStr != stdinSelector {
+		if err := readFile(envStr, "env", &inputData.Env); err != nil {
+			return err
+		}
+	}
+	if txsStr != stdinSelector {
+		if err := readFile(txsStr, "txs", &inputData.Txs); err != nil {
+			return err
+		}
+	}
+	if resultStr != stdinSelector {
+		if err := readFile(resultStr, "result", &inputData.Result); err != nil {
+			return err
+		}
+	}
+	if headerStr != stdinSelector && headerStr != "" {
+		if err := readFile(headerStr, "header", &inputData.Header); err != nil {
+			return err
+		}
+	}
+	if ommersStr != stdinSelector && ommersStr != "" {
+		if err := readFile(ommersStr, "ommers", &inputData.Ommers); err != nil {
+			return err
+		}
+	}
+	block, err := inputData.buildBlock()
+	if err != nil {
+		return err
+	}
+	if ctx.Bool(SealEthashFlag.Name) {
+		if block, err = sealEthash(ctx, block); err != nil {
+			return err
+		}
+	}
+	return dispatchBlock(ctx, baseDir, block)
+}
+
+// buildBlock assembles the block, checking the consistency of the inputs.
+func (i *bbInput) buildBlock() (*types.Block, error) {
+	if i.Env == nil {
+		return nil, NewError(ErrorJson, errors.New("missing env"))
+	}
+	if i.Result == nil {
+		return nil, NewError(ErrorJson, errors.New("missing result"))
+	}
+	// Only the transactions included by the transition go into the block
+	rejected := make(map[int]bool)
+	for _, index := range i.Result.Rejected {
+		rejected[index] = true
+	}
+	var txs types.Transactions
+	for index, tx := range i.Txs {
+		if !rejected[index] {
+			txs = append(txs, tx)
+		}
+	}
+	if root := types.DeriveSha(txs, new(trie.Trie)); root != i.Result.TxRoot {
+		return nil, NewError(ErrorJson, fmt.Errorf("transactions root mismatch: have %x, want %x", root, i.Result.TxRoot))
+	}
+	ommers, err := i.ommers()
+	if err != nil {
+		return nil, err
+	}
+	header := &types.Header{
+		UncleHash:   types.CalcUncleHash(ommers),
+		Coinbase:    i.Env.Coinbase,
+		Root:        i.Result.StateRoot,
+		TxHash:      i.Result.TxRoot,
+		ReceiptHash: i.Result.ReceiptRoot,
+		Bloom:       i.Result.Bloom,
+		Difficulty:  i.Env.Difficulty,
+		Number:      new(big.Int).SetUint64(i.Env.Number),
+		GasLimit:    i.Env.GasLimit,
+		GasUsed:     uint64(i.Result.GasUsed),
+		Time:        i.Env.Timestamp,
+		BaseFee:     i.Env.BaseFee,
+	}
+	if i.Result.Difficulty != nil {
+		header.Difficulty = (*big.Int)(i.Result.Difficulty)
+	}
+	if header.Difficulty == nil {
+		return nil, NewError(ErrorJson, errors.New("missing difficulty"))
+	}
+	if i.Env.Number > 0 {
+		header.ParentHash = i.Env.BlockHashes[math.HexOrDecimal64(i.Env.Number-1)]
+	}
+	if i.Header != nil {
+		if i.Header.ParentHash != nil {
+			header.ParentHash = *i.Header.ParentHash
+		}
+		header.Extra = i.Header.Extra
+		header.MixDigest = i.Header.MixDigest
+		header.Nonce = i.Header.Nonce
+	}
+	return types.NewBlockWithHeader(header).WithBody(txs, ommers), nil
+}
+
+// ommers decodes the ommer headers, checking them against the ommers of the
+// env the rewards were applied for.
+func (i *bbInput) ommers() ([]*types.Header, error) {
+	if len(i.Ommers) != len(i.Env.Ommers) {
+		return nil, NewError(ErrorJson, fmt.Errorf("ommer count mismatch: have %d headers, %d in env", len(i.Ommers), len(i.Env.Ommers)))
+	}
+	ommers := make([]*types.Header, len(i.Ommers))
+	for index, enc := range i.Ommers {
+		ommer := new(types.Header)
+		if err := rlp.DecodeBytes(enc, ommer); err != nil {
+			return nil, NewError(ErrorJson, fmt.Errorf("invalid ommer %d: %v", index, err))
+		}
+		want := i.Env.Ommers[index]
+		if ommer.Coinbase != want.Address || ommer.Number.Uint64()+want.Delta != i.Env.Number {
+			return nil, NewError(ErrorJson, fmt.Errorf("ommer %d mismatch: have number %d coinbase %x, env has delta %d address %x",
+				index, ommer.Number, ommer.Coinbase, want.Delta, want.Address))
+		}
+		ommers[index] = ommer
+	}
+	return ommers, nil
+}
+
+// sealEthash seals the block with an ethash engine in the configured mode.
+func sealEthash(ctx *cli.Context, block *types.Block) (*types.Block, error) {
+	config := ethash.Config{
+		CacheDir:       ctx.String(SealEthashDirFlag.Name),
+		CachesInMem:    2,
+		CachesOnDisk:   3,
+		DatasetDir:     ctx.String(SealEthashDirFlag.Name),
+		DatasetsInMem:  1,
+		DatasetsOnDisk: 2,
+	}
+	switch mode := ctx.String(SealEthashModeFlag.Name); mode {
+	case "normal":
+		config.PowMode = ethash.ModeNormal
+	case "test":
+		config.PowMode = ethash.ModeTest
+	case "fake":
+		config.PowMode = ethash.ModeFake
+	default:
+		return nil, NewError(ErrorVMConfig, fmt.Errorf("unknown ethash mode %q", mode))
+	}
+	engine := ethash.New(config, nil, false)
+	defer engine.Close()
+
+	// The engine drops results it cannot deliver immediately
+	results := make(chan *types.Block, 1)
+	if err := engine.Seal(nil, block, results, nil); err != nil {
+		return nil, NewError(ErrorEVM, fmt.Errorf("failed to seal block: %v", err))
+	}
+	return <-results, nil
+}
+
+// dispatchBlock writes the RLP encoding and hash of the block to either stderr
+// or stdout, or to the specified file.
+func dispatchBlock(ctx *cli.Context, baseDir string, block *types.Block) error {
+	raw, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		return NewError(ErrorJson, fmt.Errorf("failed encoding block: %v", err))
+	}
+	output := struct {
+		Rlp  hexutil.Bytes `json:"rlp"`
+		Hash common.Hash   `json:"hash"`
+	}{raw, block.Hash()}
+
+	switch dest := ctx.String(OutputBlockFlag.Name); dest {
+	case "stdout", "stderr":
+		b, err := json.MarshalIndent(output, "", " ")
+		if err != nil {
+			return NewError(ErrorJson, fmt.Errorf("failed marshalling output: %v", err))
+		}
+		if dest == "stdout" {
+			os.Stdout.Write(b)
+		} else {
+			os.Stderr.Write(b)
+		}
+		return nil
+	default:
+		return saveFile(baseDir, dest, output)
+	}
+}
+
+// readFile unmarshals the named input from the given file.
+func readFile(path, name string, dest interface{}) error {
+	inFile, err := os.Open(path)
+	if err != nil {
+		return NewError(ErrorIO, fmt.Errorf("failed reading %s file: %v", name, err))
+	}
+	defer inFile.Close()
+	if err := json.NewDecoder(inFile).Decode(dest); err != nil {
+		return NewError(ErrorJson, fmt.Errorf("failed unmarshaling %s file: %v", name, err))
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package t8ntool
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// knownChains are the chain configurations selectable by name.
+var knownChains = map[string]*params.ChainConfig{
+	"mainnet":  params.MainnetChainConfig,
+	"ropsten":  params.RopstenChainConfig,
+	"rinkeby":  params.RinkebyChainConfig,
+	"goerli":   params.GoerliChainConfig,
+	"classic":  params.ClassicChainConfig,
+	"mordor":   params.MordorChainConfig,
+	"kotti":    params.KottiChainConfig,
+	"ellaism":  params.EllaismChainConfig,
+	"musicoin": params.MusicoinChainConfig,
+}
+
+func availableChains() []string {
+	var chains []string
+	for name := range knownChains {
+		chains = append(chains, name)
+	}
+	sort.Strings(chains)
+	return chains
+}
+
+// loadChainConfig returns a copy of the chain configuration of the given
+// name, or loads it from the given JSON file, which may contain either a
+// chain configuration or a genesis.
+func loadChainConfig(chain string) (*params.ChainConfig, error) {
+	config := new(params.ChainConfig)
+	if known, ok := knownChains[chain]; ok {
+		*config = *known
+		return config, nil
+	}
+	data, err := ioutil.ReadFile(chain)
+	if err != nil {
+		return nil, fmt.Errorf("unknown chain %q: %v", chain, err)
+	}
+	var genesis struct {
+		Config *params.ChainConfig `json:"config"`
+	}
+	if err := json.Unmarshal(data, &genesis); err != nil {
+		return nil, fmt.Errorf("invalid chain configuration: %v", err)
+	}
+	if genesis.Config != nil {
+		return genesis.Config, nil
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("invalid chain configuration: %v", err)
+	}
+	return config, nil
+}
@@ -80,7 +80,7 @@ var (
 	}
 	RewardFlag = cli.Int64Flag{
 		Name:  "state.reward",
-		Usage: "Mining reward. Set to -1 to disable",
+		Usage: "Mining reward. Set to -1 to disable. Defaults to the engine rewards with --state.chain",
 		Value: 0,
 	}
 	ChainIDFlag = cli.Int64Flag{
@@ -100,6 +100,47 @@ var (
 			strings.Join(vm.ActivateableEips(), ", ")),
 		Value: "Istanbul",
 	}
+	ChainFlag = cli.StringFlag{
+		Name: "state.chain",
+		Usage: fmt.Sprintf("Name of a chain, or file name of a JSON chain configuration or genesis, to use instead of --state.fork."+
+			"\n\tAvailable chains:"+
+			"\n\t    %v",
+			strings.Join(availableChains(), ", ")),
+	}
+	InputResultFlag = cli.StringFlag{
+		Name:  "input.result",
+		Usage: "`stdin` or file name of where to find the transition result to build the block from.",
+		Value: "result.json",
+	}
+	InputHeaderFlag = cli.StringFlag{
+		Name:  "input.header",
+		Usage: "`stdin` or file name of where to find additional header fields (parentHash, extraData, mixHash, nonce).",
+	}
+	InputOmmersFlag = cli.StringFlag{
+		Name:  "input.ommers",
+		Usage: "`stdin` or file name of where to find the RLP encoded ommer headers to include.",
+	}
+	OutputBlockFlag = cli.StringFlag{
+		Name: "output.block",
+		Usage: "Determines where to put the built block.\n" +
+			"\t`stdout` - into the stdout output\n" +
+			"\t`stderr` - into the stderr output\n" +
+			"\t<file> - into the file <file> ",
+		Value: "block.json",
+	}
+	SealEthashFlag = cli.BoolFlag{
+		Name:  "seal.ethash",
+		Usage: "Seal the block with ethash",
+	}
+	SealEthashDirFlag = cli.StringFlag{
+		Name:  "seal.ethash.dir",
+		Usage: "Path to the ethash DAG, generated if missing",
+	}
+	SealEthashModeFlag = cli.StringFlag{
+		Name:  "seal.ethash.mode",
+		Usage: "Ethash sealing mode (normal, test or fake)",
+		Value: "normal",
+	}
 	VerbosityFlag = cli.IntFlag{
 		Name:  "verbosity",
 		Usage: "sets the verbosity level",
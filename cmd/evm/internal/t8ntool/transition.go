@@ -18,6 +18,7 @@ package t8ntool
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/big"
@@ -25,6 +26,7 @@ import (
 	"path"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -76,21 +78,14 @@ func Main(ctx *cli.Context) error {
 	log.Root().SetHandler(glogger)
 
 	var (
-		err     error
-		tracer  vm.Tracer
-		baseDir = ""
+		err    error
+		tracer vm.Tracer
 	)
 	var getTracer func(txIndex int, txHash common.Hash) (vm.Tracer, error)
 
-	// If user specified a basedir, make sure it exists
-	if ctx.IsSet(OutputBasedir.Name) {
-		if base := ctx.String(OutputBasedir.Name); len(base) > 0 {
-			err := os.MkdirAll(base, 0755) // //rw-r--r--
-			if err != nil {
-				return NewError(ErrorIO, fmt.Errorf("failed creating output basedir: %v", err))
-			}
-			baseDir = base
-		}
+	baseDir, err := createBasedir(ctx)
+	if err != nil {
+		return err
 	}
 	if ctx.Bool(TraceFlag.Name) {
 		// Configure the EVM logger
@@ -189,19 +184,37 @@ func Main(ctx *cli.Context) error {
 		Tracer: tracer,
 		Debug:  (tracer != nil),
 	}
-	// Construct the chainconfig
-	var chainConfig *params.ChainConfig
-	if cConf, extraEips, err := tests.GetChainConfig(ctx.String(ForknameFlag.Name)); err != nil {
+	// Construct the chainconfig, either a named or custom chain with the rewards
+	// of its engine, or a fork ruleset with a fixed reward
+	var (
+		chainConfig *params.ChainConfig
+		reward      = ctx.Int64(RewardFlag.Name)
+	)
+	if ctx.IsSet(ChainFlag.Name) {
+		if chainConfig, err = loadChainConfig(ctx.String(ChainFlag.Name)); err != nil {
+			return NewError(ErrorVMConfig, fmt.Errorf("Failed constructing chain configuration: %v", err))
+		}
+		if !ctx.IsSet(RewardFlag.Name) {
+			reward = EngineReward
+		}
+	} else if cConf, extraEips, err := tests.GetChainConfig(ctx.String(ForknameFlag.Name)); err != nil {
 		return NewError(ErrorVMConfig, fmt.Errorf("Failed constructing chain configuration: %v", err))
 	} else {
 		chainConfig = cConf
 		vmConfig.ExtraEips = extraEips
 	}
-	// Set the chain id
-	chainConfig.ChainID = big.NewInt(ctx.Int64(ChainIDFlag.Name))
-
+	// Set the chain id, keeping the one of a named chain unless overridden
+	if !ctx.IsSet(ChainFlag.Name) || ctx.IsSet(ChainIDFlag.Name) {
+		chainConfig.ChainID = big.NewInt(ctx.Int64(ChainIDFlag.Name))
+	}
+	// Calculate the difficulty from the parent if not supplied
+	if prestate.Env.Difficulty == nil {
+		if prestate.Env.Difficulty, err = calcDifficulty(chainConfig, &prestate.Env); err != nil {
+			return NewError(ErrorVMConfig, err)
+		}
+	}
 	// Run the test and aggregate the result
-	state, result, err := prestate.Apply(vmConfig, chainConfig, txs, ctx.Int64(RewardFlag.Name), getTracer)
+	state, result, err := prestate.Apply(vmConfig, chainConfig, txs, reward, getTracer)
 	if err != nil {
 		return err
 	}
@@ -213,6 +226,50 @@ func Main(ctx *cli.Context) error {
 
 }
 
+// createBasedir makes sure the basedir specified by the user exists, returning
+// it if set.
+func createBasedir(ctx *cli.Context) (string, error) {
+	if ctx.IsSet(OutputBasedir.Name) {
+		if base := ctx.String(OutputBasedir.Name); len(base) > 0 {
+			err := os.MkdirAll(base, 0755) // //rw-r--r--
+			if err != nil {
+				return "", NewError(ErrorIO, fmt.Errorf("failed creating output basedir: %v", err))
+			}
+			return base, nil
+		}
+	}
+	return "", nil
+}
+
+// calcDifficulty calculates the difficulty of the block from the parent fields
+// of the env, using the difficulty rules of the chain.
+func calcDifficulty(config *params.ChainConfig, env *stEnv) (*big.Int, error) {
+	if env.ParentDifficulty == nil {
+		return nil, errors.New("currentDifficulty was not provided, and cannot be calculated without parentDifficulty")
+	}
+	if env.Number == 0 {
+		return nil, errors.New("currentDifficulty needs to be provided for block number 0")
+	}
+	if config.Clique != nil {
+		return nil, errors.New("currentDifficulty needs to be provided for clique chains")
+	}
+	if env.Timestamp <= env.ParentTimestamp {
+		return nil, fmt.Errorf("currentTimestamp %d is not after parentTimestamp %d", env.Timestamp, env.ParentTimestamp)
+	}
+	// A parent without uncles is assumed if its uncle hash is not provided
+	uncleHash := env.ParentUncleHash
+	if uncleHash == (common.Hash{}) {
+		uncleHash = types.EmptyUncleHash
+	}
+	parent := &types.Header{
+		Number:     new(big.Int).SetUint64(env.Number - 1),
+		Time:       env.ParentTimestamp,
+		Difficulty: env.ParentDifficulty,
+		UncleHash:  uncleHash,
+	}
+	return ethash.CalcDifficulty(config, env.Timestamp, parent), nil
+}
+
 type Alloc map[common.Address]core.GenesisAccount
 
 func (g Alloc) OnRoot(common.Hash) {}
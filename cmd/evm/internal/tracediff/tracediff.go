@@ -0,0 +1,207 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+// Package tracediff compares EIP-3155 standard JSON traces, as produced by
+// different EVM implementations for the same execution.
+package tracediff
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+)
+
+// stepFields are the fields compared on execution steps. Op names, error
+// messages and timings differ between implementations and are ignored.
+var stepFields = []string{"pc", "op", "gas", "gasCost", "memSize", "stack", "depth", "returnData", "refund", "memory"}
+
+// summaryFields are the fields compared on the other lines.
+var summaryFields = []string{"stateRoot", "output", "gasUsed", "pass"}
+
+// optionalFields are only compared when present in both traces.
+var optionalFields = map[string]bool{"memory": true, "stateRoot": true}
+
+// numericFields hold quantities encoded either as JSON numbers or as decimal
+// or hex strings.
+var numericFields = map[string]bool{
+	"pc": true, "op": true, "gas": true, "gasCost": true, "memSize": true,
+	"stack": true, "depth": true, "refund": true, "gasUsed": true,
+}
+
+// Entry is a line of a trace.
+type Entry struct {
+	Line   int    // Line number in the trace file, starting at 1
+	Text   string // Raw content of the line
+	fields map[string]json.RawMessage
+}
+
+// IsStep reports whether the entry is an execution step, rather than a
+// summary of the execution.
+func (e *Entry) IsStep() bool {
+	_, ok := e.fields["pc"]
+	return ok
+}
+
+// Divergence is the first difference between two traces.
+type Divergence struct {
+	Step   int      // Number of common steps before the divergence
+	A, B   *Entry   // Divergent entries, nil if the trace ended before
+	Prev   *Entry   // Last common step of the first trace, nil if none
+	Fields []string // Names of the differing fields
+}
+
+func (d *Divergence) String() string {
+	describe := func(e *Entry) string {
+		if e == nil {
+			return "<end of trace>"
+		}
+		return fmt.Sprintf("line %d: %s", e.Line, e.Text)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Traces diverge after %d common steps", d.Step)
+	if len(d.Fields) > 0 {
+		fmt.Fprintf(&b, ", differing in %s", strings.Join(d.Fields, ", "))
+	}
+	b.WriteString("\n")
+	if d.Prev != nil {
+		fmt.Fprintf(&b, "previous: %s\n", describe(d.Prev))
+	}
+	fmt.Fprintf(&b, "a:        %s\nb:        %s\n", describe(d.A), describe(d.B))
+	return b.String()
+}
+
+// Reader reads the entries of a trace, skipping anything that is not a JSON
+// object, like log output interleaved by the client.
+type Reader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewReader creates a reader for the trace read from r.
+func NewReader(r io.Reader) *Reader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64*1024*1024)
+	return &Reader{scanner: scanner}
+}
+
+// Next returns the next entry of the trace, or nil at the end of the trace.
+func (r *Reader) Next() (*Entry, error) {
+	for r.scanner.Scan() {
+		r.line++
+		text := bytes.TrimSpace(r.scanner.Bytes())
+		if !bytes.HasPrefix(text, []byte("{")) {
+			continue
+		}
+		entry := &Entry{Line: r.line, Text: string(text)}
+		if err := json.Unmarshal(text, &entry.fields); err != nil {
+			return nil, fmt.Errorf("line %d: %v", r.line, err)
+		}
+		return entry, nil
+	}
+	return nil, r.scanner.Err()
+}
+
+// Compare reads two traces and returns the first divergence between them,
+// or nil if they are equivalent. The number of compared steps is returned.
+func Compare(a, b io.Reader) (*Divergence, int, error) {
+	var (
+		ra, rb = NewReader(a), NewReader(b)
+		prev   *Entry
+		steps  int
+	)
+	for {
+		ea, err := ra.Next()
+		if err != nil {
+			return nil, steps, fmt.Errorf("trace a: %v", err)
+		}
+		eb, err := rb.Next()
+		if err != nil {
+			return nil, steps, fmt.Errorf("trace b: %v", err)
+		}
+		if ea == nil && eb == nil {
+			return nil, steps, nil
+		}
+		if ea == nil || eb == nil || ea.IsStep() != eb.IsStep() {
+			return &Divergence{Step: steps, A: ea, B: eb, Prev: prev}, steps, nil
+		}
+		if fields := diff(ea, eb); len(fields) > 0 {
+			return &Divergence{Step: steps, A: ea, B: eb, Prev: prev, Fields: fields}, steps, nil
+		}
+		if ea.IsStep() {
+			prev = ea
+			steps++
+		}
+	}
+}
+
+// diff returns the names of the fields differing between two entries of
+// the same kind. Fields missing from both entries, or optional fields missing
+// from either, are not compared.
+func diff(a, b *Entry) []string {
+	fields := summaryFields
+	if a.IsStep() {
+		fields = stepFields
+	}
+	var differing []string
+	for _, field := range fields {
+		va, oka := a.fields[field]
+		vb, okb := b.fields[field]
+		if (!oka && !okb) || (optionalFields[field] && (!oka || !okb)) {
+			continue
+		}
+		if !oka || !okb || normalize(field, va) != normalize(field, vb) {
+			differing = append(differing, field)
+		}
+	}
+	return differing
+}
+
+// normalize returns the canonical form of a field value, for the encodings
+// of the same value by different implementations to compare equal.
+func normalize(field string, value json.RawMessage) string {
+	var list []json.RawMessage
+	if err := json.Unmarshal(value, &list); err == nil {
+		items := make([]string, len(list))
+		for i, item := range list {
+			items[i] = normalize(field, item)
+		}
+		// Memory may be encoded as a list of words instead of a single blob
+		if field == "memory" {
+			return strings.Join(items, "")
+		}
+		return "[" + strings.Join(items, ",") + "]"
+	}
+	var str string
+	if err := json.Unmarshal(value, &str); err != nil {
+		// Not a string, the JSON encoding of numbers and booleans is canonical
+		return string(value)
+	}
+	if numericFields[field] {
+		n, ok := new(big.Int).SetString(str, 10)
+		if strings.HasPrefix(str, "0x") {
+			n, ok = new(big.Int).SetString(str[2:], 16)
+		}
+		if ok {
+			return n.String()
+		}
+		return str
+	}
+	return strings.ToLower(strings.TrimPrefix(str, "0x"))
+}
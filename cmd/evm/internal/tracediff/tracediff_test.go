@@ -0,0 +1,104 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package tracediff
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+)
+
+// trace runs the code with the JSON logger, returning the trace.
+func trace(t *testing.T, code []byte) string {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+
+	var out bytes.Buffer
+	logger := vm.NewJSONLoggerWithStateRoot(nil, &out)
+	if _, _, err := runtime.Execute(code, nil, &runtime.Config{
+		State:     statedb,
+		EVMConfig: vm.Config{Debug: true, Tracer: logger},
+	}); err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	logger.CaptureStateRoot(statedb.IntermediateRoot(true), "Berlin")
+	return out.String()
+}
+
+func TestCompare(t *testing.T) {
+	var (
+		// Stores 1 in slot 0 and returns it
+		code = common.FromHex("600160005560005460005260206000f3")
+		// Stores 2 instead, diverging in the stack of the second step
+		other = common.FromHex("600260005560005460005260206000f3")
+		base  = trace(t, code)
+		lines = strings.Split(strings.TrimSpace(base), "\n")
+	)
+	tests := []struct {
+		b      string
+		step   int
+		fields []string
+		end    bool
+	}{
+		// Identical traces
+		{b: base, step: -1},
+		// Equivalent encodings and interleaved log output
+		{
+			b: "INFO starting\n" + strings.Replace(strings.Replace(base, `"gas":"0x2540be400"`, `"gas":10000000000`, 1),
+				`"stack":[]`, `"stack":[],"opName":"OTHER"`, 1),
+			step: -1,
+		},
+		// Different execution
+		{b: trace(t, other), step: 1, fields: []string{"stack"}},
+		// Truncated trace
+		{b: strings.Join(lines[:3], "\n"), step: 3, end: true},
+		// Summary without the fork, as printed by other implementations
+		{b: strings.Replace(base, `,"fork":"Berlin"`, "", 1), step: -1},
+		// Differing summary
+		{b: strings.Replace(base, `"pass":true`, `"pass":false`, 1), step: len(lines) - 1, fields: []string{"pass"}},
+	}
+	for i, tt := range tests {
+		div, _, err := Compare(strings.NewReader(base), strings.NewReader(tt.b))
+		if err != nil {
+			t.Fatalf("test %d: compare failed: %v", i, err)
+		}
+		if tt.step < 0 {
+			if div != nil {
+				t.Errorf("test %d: unexpected divergence: %v", i, div)
+			}
+			continue
+		}
+		if div == nil {
+			t.Fatalf("test %d: divergence not detected", i)
+		}
+		if div.Step != tt.step {
+			t.Errorf("test %d: step mismatch: have %d, want %d", i, div.Step, tt.step)
+		}
+		if !reflect.DeepEqual(div.Fields, tt.fields) {
+			t.Errorf("test %d: fields mismatch: have %v, want %v", i, div.Fields, tt.fields)
+		}
+		if (div.B == nil) != tt.end {
+			t.Errorf("test %d: end of trace mismatch: have %v, want %v", i, div.B == nil, tt.end)
+		}
+	}
+}
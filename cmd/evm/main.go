@@ -134,6 +134,10 @@ var (
 		Usage: "External EVM configuration (default = built-in interpreter)",
 		Value: "",
 	}
+	EVMDifferentialFlag = cli.BoolFlag{
+		Name:  "vm.evm.differential",
+		Usage: "Cross-check the external EVM against the built-in interpreter, reporting divergences",
+	}
 )
 
 var stateTransitionCommand = cli.Command{
@@ -154,11 +158,32 @@ var stateTransitionCommand = cli.Command{
 		t8ntool.InputTxsFlag,
 		t8ntool.ForknameFlag,
 		t8ntool.ChainIDFlag,
+		t8ntool.ChainFlag,
 		t8ntool.RewardFlag,
 		t8ntool.VerbosityFlag,
 	},
 }
 
+var blockBuilderCommand = cli.Command{
+	Name:    "block-builder",
+	Aliases: []string{"b11r"},
+	Usage:   "builds a block from the inputs and outputs of a state transition",
+	Action:  t8ntool.BuildBlock,
+	Flags: []cli.Flag{
+		t8ntool.OutputBasedir,
+		t8ntool.OutputBlockFlag,
+		t8ntool.InputEnvFlag,
+		t8ntool.InputTxsFlag,
+		t8ntool.InputResultFlag,
+		t8ntool.InputHeaderFlag,
+		t8ntool.InputOmmersFlag,
+		t8ntool.SealEthashFlag,
+		t8ntool.SealEthashDirFlag,
+		t8ntool.SealEthashModeFlag,
+		t8ntool.VerbosityFlag,
+	},
+}
+
 func init() {
 	app.Flags = []cli.Flag{
 		BenchFlag,
@@ -185,13 +210,19 @@ func init() {
 		DisableStorageFlag,
 		DisableReturnDataFlag,
 		EVMInterpreterFlag,
+		EVMDifferentialFlag,
 	}
 	app.Commands = []cli.Command{
+		blockBuilderCommand,
 		compileCommand,
+		debugCommand,
 		disasmCommand,
+		profileCommand,
 		runCommand,
 		stateTestCommand,
 		stateTransitionCommand,
+		statelessCommand,
+		traceDiffCommand,
 	}
 	cli.CommandHelpTemplate = flags.OriginCommandHelpTemplate
 }
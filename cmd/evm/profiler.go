@@ -0,0 +1,205 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/cmd/evm/internal/profiler"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	ProfileTxFlag = cli.StringFlag{
+		Name:  "tx",
+		Usage: "comma separated hashes of mined transactions to profile",
+	}
+	ProfileBlocksFlag = cli.StringFlag{
+		Name:  "blocks",
+		Usage: "number or first-last range of the blocks to profile",
+	}
+	ProfileLCOVFlag = cli.StringFlag{
+		Name:  "lcov",
+		Usage: "file to write the LCOV coverage of the compiled contracts to",
+	}
+	ProfilePprofFlag = cli.StringFlag{
+		Name:  "pprof",
+		Usage: "file to write the pprof gas profile to",
+	}
+	ProfileCombinedJSONFlag = cli.StringFlag{
+		Name:  "combined-json",
+		Usage: "output of solc --combined-json bin,bin-runtime,srcmap,srcmap-runtime",
+	}
+	ProfileSourcesFlag = cli.StringFlag{
+		Name:  "sources",
+		Usage: "directory the source files of the combined json are relative to",
+		Value: ".",
+	}
+)
+
+var profileCommand = cli.Command{
+	Action: profileCmd,
+	Name:   "profile",
+	Usage:  "profiles the coverage and gas usage of evm code or mined transactions",
+	Flags: []cli.Flag{
+		ProfileTxFlag,
+		ProfileBlocksFlag,
+		ChainDataFlag,
+		ProfileLCOVFlag,
+		ProfilePprofFlag,
+		ProfileCombinedJSONFlag,
+		ProfileSourcesFlag,
+	},
+	Description: `
+The profile command executes code like the run command, aggregating the
+executions and gas usage of every contract code instruction. It reports the gas
+spent by each code, inclusive and exclusive of the codes it calls, its most
+expensive opcodes and its instruction coverage.
+
+With --tx or --blocks and --chaindata, the given mined transactions or all the
+transactions of the given blocks are replayed from the chain database instead.
+The database must not be in use by a running node, and must still hold the
+states of the parent blocks.
+
+With --combined-json, the executed codes are matched with the compiled
+contracts, whose source maps locate the instructions in the source files.
+--lcov writes the line and branch coverage of the sources, and --pprof writes a
+profile of the gas usage along the call stacks for 'go tool pprof'.`,
+}
+
+func profileCmd(ctx *cli.Context) error {
+	var sources *profiler.Sources
+	if file := ctx.String(ProfileCombinedJSONFlag.Name); file != "" {
+		combined, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if sources, err = profiler.LoadSources(combined, ctx.String(ProfileSourcesFlag.Name)); err != nil {
+			return err
+		}
+	}
+	prof := profiler.New()
+	if ctx.IsSet(ProfileTxFlag.Name) || ctx.IsSet(ProfileBlocksFlag.Name) {
+		db, err := openChainDB(ctx)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		if err := profileChain(ctx, db, prof); err != nil {
+			return err
+		}
+	} else if err := runCode(ctx, prof); err != nil {
+		return err
+	}
+	if err := profiler.WriteReport(os.Stdout, prof, sources); err != nil {
+		return err
+	}
+	if file := ctx.String(ProfileLCOVFlag.Name); file != "" {
+		if err := writeProfile(file, prof, sources, profiler.WriteLCOV); err != nil {
+			return err
+		}
+	}
+	if file := ctx.String(ProfilePprofFlag.Name); file != "" {
+		if err := writeProfile(file, prof, sources, profiler.WritePprof); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// profileChain replays the transactions and blocks given by the flags with the
+// profiler.
+func profileChain(ctx *cli.Context, db ethdb.Database, prof *profiler.Profiler) error {
+	if hashes := ctx.String(ProfileTxFlag.Name); hashes != "" {
+		for _, hex := range strings.Split(hashes, ",") {
+			hash := common.HexToHash(strings.TrimSpace(hex))
+			block, index, err := lookupTransaction(db, hash)
+			if err != nil {
+				return err
+			}
+			if _, err := replayBlock(db, block, int(index), func(i int, tx *types.Transaction) vm.Tracer {
+				if uint64(i) == index {
+					return prof
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	if blocks := ctx.String(ProfileBlocksFlag.Name); blocks != "" {
+		first, last, err := parseBlockRange(blocks)
+		if err != nil {
+			return err
+		}
+		for number := first; number <= last; number++ {
+			block := rawdb.ReadBlock(db, rawdb.ReadCanonicalHash(db, number), number)
+			if block == nil {
+				return fmt.Errorf("block %d not found", number)
+			}
+			if _, err := replayBlock(db, block, block.Transactions().Len()-1, func(int, *types.Transaction) vm.Tracer {
+				return prof
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseBlockRange parses a block number or a first-last range of blocks.
+func parseBlockRange(blocks string) (uint64, uint64, error) {
+	bounds := strings.SplitN(blocks, "-", 2)
+	first, err := strconv.ParseUint(strings.TrimSpace(bounds[0]), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid block range %q: %v", blocks, err)
+	}
+	last := first
+	if len(bounds) == 2 {
+		if last, err = strconv.ParseUint(strings.TrimSpace(bounds[1]), 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid block range %q: %v", blocks, err)
+		}
+	}
+	if first == 0 || last < first {
+		return 0, 0, fmt.Errorf("invalid block range %q", blocks)
+	}
+	return first, last, nil
+}
+
+// writeProfile writes a report of the profile to the file.
+func writeProfile(file string, prof *profiler.Profiler, sources *profiler.Sources, write func(io.Writer, *profiler.Profiler, *profiler.Sources) error) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := write(f, prof, sources); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
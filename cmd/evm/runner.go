@@ -104,6 +104,12 @@ func timedExec(bench bool, execFunc func() ([]byte, uint64, error)) (output []by
 }
 
 func runCmd(ctx *cli.Context) error {
+	return runCode(ctx, nil)
+}
+
+// runCode runs the code configured by the flags. The execution is traced by
+// the given tracer if set, or as requested by the flags otherwise.
+func runCode(ctx *cli.Context, override vm.Tracer) error {
 	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
 	glogger.Verbosity(log.Lvl(ctx.GlobalInt(VerbosityFlag.Name)))
 	log.Root().SetHandler(glogger)
@@ -118,14 +124,18 @@ func runCmd(ctx *cli.Context) error {
 	var (
 		tracer        vm.Tracer
 		debugLogger   *vm.StructLogger
+		jsonLogger    *vm.JSONLogger
 		statedb       *state.StateDB
 		chainConfig   *params.ChainConfig
 		sender        = common.BytesToAddress([]byte("sender"))
 		receiver      = common.BytesToAddress([]byte("receiver"))
 		genesisConfig *core.Genesis
 	)
-	if ctx.GlobalBool(MachineFlag.Name) {
-		tracer = vm.NewJSONLogger(logconfig, os.Stdout)
+	if override != nil {
+		tracer = override
+	} else if ctx.GlobalBool(MachineFlag.Name) {
+		jsonLogger = vm.NewJSONLoggerWithStateRoot(logconfig, os.Stdout)
+		tracer = jsonLogger
 	} else if ctx.GlobalBool(DebugFlag.Name) {
 		debugLogger = vm.NewStructLogger(logconfig)
 		tracer = debugLogger
@@ -196,6 +206,12 @@ func runCmd(ctx *cli.Context) error {
 		}
 		code = common.Hex2Bytes(bin)
 	}
+	// Fail if the external EVM can't be loaded instead of running the built-in one
+	if config := ctx.GlobalString(EVMInterpreterFlag.Name); config != "" {
+		if _, err := vm.LoadEVMC(config); err != nil {
+			return err
+		}
+	}
 	initialGas := ctx.GlobalUint64(GasFlag.Name)
 	if genesisConfig.GasLimit != 0 {
 		initialGas = genesisConfig.GasLimit
@@ -211,9 +227,10 @@ func runCmd(ctx *cli.Context) error {
 		Coinbase:    genesisConfig.Coinbase,
 		BlockNumber: new(big.Int).SetUint64(genesisConfig.Number),
 		EVMConfig: vm.Config{
-			Tracer:         tracer,
-			Debug:          ctx.GlobalBool(DebugFlag.Name) || ctx.GlobalBool(MachineFlag.Name),
-			EVMInterpreter: ctx.GlobalString(EVMInterpreterFlag.Name),
+			Tracer:           tracer,
+			Debug:            ctx.GlobalBool(DebugFlag.Name) || ctx.GlobalBool(MachineFlag.Name) || override != nil,
+			EVMInterpreter:   ctx.GlobalString(EVMInterpreterFlag.Name),
+			EVMCDifferential: ctx.GlobalBool(EVMDifferentialFlag.Name),
 		},
 	}
 
@@ -266,6 +283,9 @@ func runCmd(ctx *cli.Context) error {
 
 	bench := ctx.GlobalBool(BenchFlag.Name)
 	output, leftOverGas, stats, err := timedExec(bench, execFunc)
+	if jsonLogger != nil {
+		jsonLogger.CaptureStateRoot(statedb.IntermediateRoot(true), "")
+	}
 
 	if ctx.GlobalBool(DumpFlag.Name) {
 		statedb.Commit(true)
@@ -0,0 +1,82 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/clique"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/stateless"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var statelessCommand = cli.Command{
+	Action:    statelessCmd,
+	Name:      "stateless",
+	Usage:     "executes a block from its witness, without a database",
+	ArgsUsage: "<witness file>",
+	Description: `
+The stateless command re-executes the block of a witness, as returned by
+debug_getBlockWitness, using only the data in the witness, and checks the
+resulting state root. The witness file may contain the binary RLP encoding
+or its hex encoding. The chain configuration is taken from the --genesis
+file, defaulting to the mainnet configuration.`,
+}
+
+func statelessCmd(ctx *cli.Context) error {
+	if len(ctx.Args().First()) == 0 {
+		return errors.New("missing witness file")
+	}
+	input, err := ioutil.ReadFile(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	// Accept the hex encoding returned over RPC, with or without JSON quotes
+	if text := bytes.Trim(bytes.TrimSpace(input), `"`); bytes.HasPrefix(text, []byte("0x")) {
+		if input, err = hexutil.Decode(string(text)); err != nil {
+			return fmt.Errorf("invalid witness hex encoding: %v", err)
+		}
+	}
+	witness := new(stateless.Witness)
+	if err := rlp.DecodeBytes(input, witness); err != nil {
+		return fmt.Errorf("invalid witness: %v", err)
+	}
+	config := params.MainnetChainConfig
+	if ctx.GlobalString(GenesisFlag.Name) != "" {
+		config = readGenesis(ctx.GlobalString(GenesisFlag.Name)).Config
+	}
+	// Seals are not verified, the engine is only used for finalization
+	var engine consensus.Engine = ethash.NewFaker()
+	if config.Clique != nil {
+		engine = clique.New(config.Clique, rawdb.NewMemoryDatabase())
+	}
+	root, err := stateless.Execute(config, engine, witness)
+	if err != nil {
+		return fmt.Errorf("block %d: %v", witness.Block.NumberU64(), err)
+	}
+	fmt.Printf("block %d: post-state root %x verified\n", witness.Block.NumberU64(), root)
+	return nil
+}
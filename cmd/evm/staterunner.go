@@ -65,12 +65,14 @@ func stateTestCmd(ctx *cli.Context) error {
 		DisableReturnData: ctx.GlobalBool(DisableReturnDataFlag.Name),
 	}
 	var (
-		tracer   vm.Tracer
-		debugger *vm.StructLogger
+		tracer     vm.Tracer
+		debugger   *vm.StructLogger
+		jsonLogger *vm.JSONLogger
 	)
 	switch {
 	case ctx.GlobalBool(MachineFlag.Name):
-		tracer = vm.NewJSONLogger(config, os.Stderr)
+		jsonLogger = vm.NewJSONLoggerWithStateRoot(config, os.Stderr)
+		tracer = jsonLogger
 
 	case ctx.GlobalBool(DebugFlag.Name):
 		debugger = vm.NewStructLogger(config)
@@ -99,9 +101,9 @@ func stateTestCmd(ctx *cli.Context) error {
 			// Run the test and aggregate the result
 			result := &StatetestResult{Name: key, Fork: st.Fork, Pass: true}
 			_, state, err := test.Run(st, cfg, false)
-			// print state root for evmlab tracing
-			if ctx.GlobalBool(MachineFlag.Name) && state != nil {
-				fmt.Fprintf(os.Stderr, "{\"stateRoot\": \"%x\"}\n", state.IntermediateRoot(false))
+			// print the execution summary along with the state root for evmlab tracing
+			if jsonLogger != nil && state != nil {
+				jsonLogger.CaptureStateRoot(state.IntermediateRoot(false), st.Fork)
 			}
 			if err != nil {
 				// Test failed, mark as so and dump any state to aid debugging
@@ -0,0 +1,65 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/cmd/evm/internal/tracediff"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var traceDiffCommand = cli.Command{
+	Action:    traceDiffCmd,
+	Name:      "tracediff",
+	Usage:     "compares two EIP-3155 standard json traces",
+	ArgsUsage: "<trace a> <trace b>",
+	Description: `
+The tracediff command compares two standard json traces of the same execution,
+as emitted with --json or by other EVM implementations, and reports the first
+divergent step. Op names, error messages and timings are not compared. The
+command fails if the traces diverge.`,
+}
+
+func traceDiffCmd(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return errors.New("expected two trace files")
+	}
+	a, err := os.Open(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	b, err := os.Open(ctx.Args().Get(1))
+	if err != nil {
+		return err
+	}
+	defer b.Close()
+
+	div, steps, err := tracediff.Compare(a, b)
+	if err != nil {
+		return err
+	}
+	if div != nil {
+		fmt.Print(div)
+		return errors.New("traces diverge")
+	}
+	fmt.Printf("Traces are equivalent, %d steps compared\n", steps)
+	return nil
+}
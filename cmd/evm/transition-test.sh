@@ -189,3 +189,46 @@ echo ""
 echo "In order to meaningfully chain invocations, one would need to provide meaningful new \`env\`, otherwise the"
 echo "actual blocknumber (exposed to the EVM) would not increase."
 echo ""
+
+echo "### Chain configurations"
+echo ""
+echo "Instead of a fork ruleset, any chain configuration can be used with \`--state.chain\`, given either"
+echo "the name of a known chain or a JSON file containing a chain configuration or a genesis. The"
+echo "block and ommer rewards are then those of the chain's consensus engine, like the ECIP-1017"
+echo "eras of Ethereum Classic or the MCIP eras of Musicoin, unless \`--state.reward\` is given."
+echo ""
+echo "The \`currentDifficulty\` may be left out of the \`env\`, to have it calculated from"
+echo "\`parentDifficulty\`, \`parentTimestamp\` and \`parentUncleHash\` by the difficulty rules"
+echo "of the chain. A missing \`parentUncleHash\` is taken to be that of a parent without ommers."
+echo "The difficulty is included in the result."
+echo ""
+echo "Example, applying an Ethereum Classic block in the second ECIP-1017 era, with one ommer:"
+showjson ./testdata/8/env.json
+cmd="./evm t8n --input.alloc=./testdata/8/alloc.json --input.txs=./testdata/8/txs.json --input.env=./testdata/8/env.json --state.chain=classic --output.alloc=stdout --output.result=stdout"
+tick && echo $cmd && tick
+echo "${ticks}json"
+$cmd 2>/dev/null
+echo ""
+echo "$ticks"
+echo ""
+
+echo "### Block building"
+echo ""
+echo "The \`evm b11r\` tool assembles a block from the \`env\`, \`txs\` and \`result\` of a transition,"
+echo "leaving out the rejected transactions. The parent hash is taken from the \`blockHashes\` of the"
+echo "\`env\`, and can be overridden with the other fields outside of the transition, \`extraData\`,"
+echo "\`mixHash\` and \`nonce\`, in an \`--input.header\` file. The ommers rewarded by the transition"
+echo "are given as a list of RLP encoded headers in an \`--input.ommers\` file."
+echo ""
+echo "The block can be sealed with \`--seal.ethash\`, using a real (\`normal\`), small (\`test\`)"
+echo "or \`fake\` proof-of-work as selected by \`--seal.ethash.mode\`."
+echo ""
+cmd1="./evm t8n --input.alloc=./testdata/8/alloc.json --input.txs=./testdata/8/txs.json --input.env=./testdata/8/env.json --state.chain=classic --output.result=stdout --output.alloc=/dev/null"
+cmd2="./evm b11r --input.env=./testdata/8/env.json --input.txs=./testdata/8/txs.json --input.result=stdin --input.ommers=./testdata/8/ommers.json --output.block=stdout"
+echo "$ticks"
+echo "$cmd1 | $cmd2"
+echo "$ticks"
+echo "${ticks}json"
+$cmd1 2>/dev/null | $cmd2
+echo ""
+echo "$ticks"
@@ -88,11 +88,16 @@ var (
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
 		utils.TxPoolLifetimeFlag,
+		utils.TxPoolPolicyFlag,
 		utils.SyncModeFlag,
 		utils.ExitWhenSyncedFlag,
 		utils.GCModeFlag,
 		utils.SnapshotFlag,
 		utils.TxLookupLimitFlag,
+		utils.TraceIndexFlag,
+		utils.TraceIndexLimitFlag,
+		utils.SideBlocksFlag,
+		utils.SideBlocksLimitFlag,
 		utils.LightServeFlag,
 		utils.LegacyLightServFlag,
 		utils.LightIngressFlag,
@@ -141,6 +146,7 @@ var (
 		utils.DNSDiscoveryFlag,
 		utils.DeveloperFlag,
 		utils.DeveloperPeriodFlag,
+		utils.DeveloperInstantFlag,
 		utils.LegacyTestnetFlag,
 		utils.RopstenFlag,
 		utils.RinkebyFlag,
@@ -158,6 +164,7 @@ var (
 		utils.GpoMaxGasPriceFlag,
 		utils.EWASMInterpreterFlag,
 		utils.EVMInterpreterFlag,
+		utils.EVMDifferentialFlag,
 		utils.ClassicFlag,
 		utils.MordorFlag,
 		utils.KottiFlag,
@@ -197,6 +204,7 @@ var (
 		utils.InsecureUnlockAllowedFlag,
 		utils.RPCGlobalGasCapFlag,
 		utils.RPCGlobalTxFeeCapFlag,
+		utils.RPCErrorSignaturesFlag,
 	}
 
 	whisperFlags = []cli.Flag{
@@ -445,8 +453,9 @@ func startNode(ctx *cli.Context, stack *node.Node, backend ethapi.Backend) {
 		}()
 	}
 
-	// Start auxiliary services if enabled
-	if ctx.GlobalBool(utils.MiningEnabledFlag.Name) || ctx.GlobalBool(utils.DeveloperFlag.Name) {
+	// Start auxiliary services if enabled, instantly sealed developer chains are
+	// mined on demand via the dev API instead
+	if ctx.GlobalBool(utils.MiningEnabledFlag.Name) || (ctx.GlobalBool(utils.DeveloperFlag.Name) && !ctx.GlobalBool(utils.DeveloperInstantFlag.Name)) {
 		// Mining only makes sense if a full Ethereum node is running
 		if ctx.GlobalString(utils.SyncModeFlag.Name) == "light" {
 			utils.Fatalf("Light clients do not support mining")
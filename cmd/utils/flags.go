@@ -175,6 +175,10 @@ var (
 		Name:  "dev.period",
 		Usage: "Block period to use in developer mode (0 = mine only if transaction pending)",
 	}
+	DeveloperInstantFlag = cli.BoolFlag{
+		Name:  "dev.instant",
+		Usage: "Seal developer mode blocks instantly and on demand, enabling the dev API (mining, time travel, snapshots)",
+	}
 	IdentityFlag = cli.StringFlag{
 		Name:  "identity",
 		Usage: "Custom node name",
@@ -224,6 +228,24 @@ var (
 		Usage: "Number of recent blocks to maintain transactions index by-hash for (default = index all blocks)",
 		Value: 0,
 	}
+	TraceIndexFlag = cli.BoolFlag{
+		Name:  "traceindex",
+		Usage: "Record the call traces of all imported blocks to serve trace_filter queries",
+	}
+	TraceIndexLimitFlag = cli.Uint64Flag{
+		Name:  "traceindex.limit",
+		Usage: "Number of recent blocks to retain the call traces of (default = all blocks)",
+		Value: 0,
+	}
+	SideBlocksFlag = cli.BoolFlag{
+		Name:  "sideblocks",
+		Usage: "Record the side chain blocks seen by the node to serve uncle analytics",
+	}
+	SideBlocksLimitFlag = cli.Uint64Flag{
+		Name:  "sideblocks.limit",
+		Usage: "Number of recent blocks to retain the side blocks of (0 = all blocks)",
+		Value: eth.DefaultConfig.SideBlocksLimit,
+	}
 	LightKDFFlag = cli.BoolFlag{
 		Name:  "lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
@@ -367,6 +389,10 @@ var (
 		Usage: "Maximum amount of time non-executable transaction are queued",
 		Value: eth.DefaultConfig.TxPool.Lifetime,
 	}
+	TxPoolPolicyFlag = cli.StringFlag{
+		Name:  "txpool.policy",
+		Usage: "Admission policy file (TOML or JSON) restricting accepted transactions",
+	}
 	// Performance tuning settings
 	CacheFlag = cli.IntFlag{
 		Name:  "cache",
@@ -492,6 +518,10 @@ var (
 		Usage: "Sets a cap on transaction fee (in ether) that can be sent via the RPC APIs (0 = no cap)",
 		Value: eth.DefaultConfig.RPCTxFeeCap,
 	}
+	RPCErrorSignaturesFlag = cli.StringFlag{
+		Name:  "rpc.errorsignatures",
+		Usage: "4byte database (JSON, as used by clef) decoding custom errors of reverted calls",
+	}
 	// Logging and debug settings
 	EthStatsURLFlag = cli.StringFlag{
 		Name:  "ethstats",
@@ -750,6 +780,10 @@ var (
 		Usage: "External EVM configuration (default = built-in interpreter)",
 		Value: "",
 	}
+	EVMDifferentialFlag = cli.BoolFlag{
+		Name:  "vm.evm.differential",
+		Usage: "Cross-check the external EVM against the built-in interpreter, reporting divergences",
+	}
 )
 
 // MakeDataDir retrieves the currently requested data directory, terminating
@@ -1414,6 +1448,9 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.GlobalDuration(TxPoolLifetimeFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolPolicyFlag.Name) {
+		cfg.Policy = ctx.GlobalString(TxPoolPolicyFlag.Name)
+	}
 }
 
 func setEthash(ctx *cli.Context, cfg *eth.Config) {
@@ -1608,6 +1645,18 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(TxLookupLimitFlag.Name) {
 		cfg.TxLookupLimit = ctx.GlobalUint64(TxLookupLimitFlag.Name)
 	}
+	if ctx.GlobalIsSet(TraceIndexFlag.Name) {
+		cfg.TraceIndex = ctx.GlobalBool(TraceIndexFlag.Name)
+	}
+	if ctx.GlobalIsSet(TraceIndexLimitFlag.Name) {
+		cfg.TraceIndexLimit = ctx.GlobalUint64(TraceIndexLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(SideBlocksFlag.Name) {
+		cfg.SideBlocks = ctx.GlobalBool(SideBlocksFlag.Name)
+	}
+	if ctx.GlobalIsSet(SideBlocksLimitFlag.Name) {
+		cfg.SideBlocksLimit = ctx.GlobalUint64(SideBlocksLimitFlag.Name)
+	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheTrieFlag.Name) {
 		cfg.TrieCleanCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheTrieFlag.Name) / 100
 	}
@@ -1642,6 +1691,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(EVMInterpreterFlag.Name) {
 		cfg.EVMInterpreter = ctx.GlobalString(EVMInterpreterFlag.Name)
 	}
+	if ctx.GlobalIsSet(EVMDifferentialFlag.Name) {
+		cfg.EVMDifferential = ctx.GlobalBool(EVMDifferentialFlag.Name)
+	}
 	if ctx.GlobalIsSet(RPCGlobalGasCapFlag.Name) {
 		cfg.RPCGasCap = ctx.GlobalUint64(RPCGlobalGasCapFlag.Name)
 	}
@@ -1653,6 +1705,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(RPCGlobalTxFeeCapFlag.Name) {
 		cfg.RPCTxFeeCap = ctx.GlobalFloat64(RPCGlobalTxFeeCapFlag.Name)
 	}
+	if ctx.GlobalIsSet(RPCErrorSignaturesFlag.Name) {
+		cfg.RPCErrorSignatures = ctx.GlobalString(RPCErrorSignaturesFlag.Name)
+	}
 	if ctx.GlobalIsSet(NoDiscoverFlag.Name) {
 		cfg.DiscoveryURLs = []string{}
 	} else if ctx.GlobalIsSet(DNSDiscoveryFlag.Name) {
@@ -1747,7 +1802,11 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 		log.Info("Using developer account", "address", developer.Address)
 
 		// Create a new developer genesis block or reuse existing one
-		cfg.Genesis = core.DeveloperGenesisBlock(uint64(ctx.GlobalInt(DeveloperPeriodFlag.Name)), developer.Address)
+		if ctx.GlobalBool(DeveloperInstantFlag.Name) {
+			cfg.Genesis = core.DeveloperInstantGenesisBlock(developer.Address)
+		} else {
+			cfg.Genesis = core.DeveloperGenesisBlock(uint64(ctx.GlobalInt(DeveloperPeriodFlag.Name)), developer.Address)
+		}
 		if ctx.GlobalIsSet(DataDirFlag.Name) {
 			// Check if we have an already initialized chain and fall back to
 			// that if so. Otherwise we need to generate a new genesis spec.
@@ -273,16 +273,18 @@ func (ethash *Ethash) verifyHeader(chain consensus.ChainHeaderReader, header, pa
 	if header.GasUsed > header.GasLimit {
 		return fmt.Errorf("invalid gasUsed: have %d, gasLimit %d", header.GasUsed, header.GasLimit)
 	}
-
-	// Verify that the gas limit remains within allowed bounds
-	diff := int64(parent.GasLimit) - int64(header.GasLimit)
-	if diff < 0 {
-		diff *= -1
-	}
-	limit := parent.GasLimit / params.GasLimitBoundDivisor
-
-	if uint64(diff) >= limit || header.GasLimit < params.MinGasLimit {
-		return fmt.Errorf("invalid gas limit: have %d, want %d += %d", header.GasLimit, parent.GasLimit, limit)
+	// Verify the block's gas usage and (if applicable) verify the base fee.
+	if !chain.Config().IsEIP1559(header.Number) {
+		// Verify BaseFee not present before EIP-1559 fork.
+		if err := misc.VerifyPreEip1559Header(header); err != nil {
+			return err
+		}
+		if err := misc.VerifyGaslimit(parent.GasLimit, header.GasLimit); err != nil {
+			return err
+		}
+	} else if err := misc.VerifyEip1559Header(chain.Config(), parent, header); err != nil {
+		// Verify the header's EIP-1559 attributes.
+		return err
 	}
 	// Verify that the block number is parent's +1
 	if diff := new(big.Int).Sub(header.Number, parent.Number); diff.Cmp(big.NewInt(1)) != 0 {
@@ -595,7 +597,7 @@ func (ethash *Ethash) FinalizeAndAssemble(chain consensus.ChainHeaderReader, hea
 func (ethash *Ethash) SealHash(header *types.Header) (hash common.Hash) {
 	hasher := sha3.NewLegacyKeccak256()
 
-	rlp.Encode(hasher, []interface{}{
+	enc := []interface{}{
 		header.ParentHash,
 		header.UncleHash,
 		header.Coinbase,
@@ -609,7 +611,11 @@ func (ethash *Ethash) SealHash(header *types.Header) (hash common.Hash) {
 		header.GasUsed,
 		header.Time,
 		header.Extra,
-	})
+	}
+	if header.BaseFee != nil {
+		enc = append(enc, header.BaseFee)
+	}
+	rlp.Encode(hasher, enc)
 	hasher.Sum(hash[:0])
 	return hash
 }
@@ -620,19 +626,31 @@ var (
 	big32 = big.NewInt(32)
 )
 
-// AccumulateRewards credits the coinbase of the given block with the mining
-// reward. The total reward consists of the static block reward and rewards for
-// included uncles. The coinbase of each uncle block is also rewarded.
-func accumulateRewards(config *params.ChainConfig, state *state.StateDB, header *types.Header, uncles []*types.Header) {
+// Types of the rewards credited when finalizing a block.
+const (
+	RewardBlock    = "block"    // Reward of the block's miner
+	RewardUncle    = "uncle"    // Reward of an included uncle's miner
+	RewardExternal = "external" // Reward of a fixed beneficiary other than a miner
+)
+
+// BlockReward is a single balance credit made when finalizing a block.
+type BlockReward struct {
+	Beneficiary common.Address
+	Type        string
+	Value       *big.Int
+}
+
+// BlockRewards returns the rewards credited when finalizing a block with the
+// given uncles, starting with the reward of the block's miner. The total reward
+// of the miner consists of the static block reward and rewards for included
+// uncles. The coinbase of each uncle block is also rewarded.
+func BlockRewards(config *params.ChainConfig, header *types.Header, uncles []*types.Header) []BlockReward {
 	if config.IsMCIP0(header.Number) {
-		accumulateMCIP0Rewards(config, state, header, uncles)
-		return
+		return mcip0Rewards(config, header, uncles)
 	}
 	if config.HasECIP1017() {
-		accumulateECIP1017Rewards(config, state, header, uncles)
-		return
+		return ecip1017Rewards(config, header, uncles)
 	}
-
 	// Select the correct block reward based on chain progression
 	blockReward := FrontierBlockReward
 	if config.IsByzantium(header.Number) {
@@ -642,17 +660,23 @@ func accumulateRewards(config *params.ChainConfig, state *state.StateDB, header
 		blockReward = ConstantinopleBlockReward
 	}
 	// Accumulate the rewards for the miner and any included uncles
-	reward := new(big.Int).Set(blockReward)
-	r := new(big.Int)
+	rewards := []BlockReward{{Beneficiary: header.Coinbase, Type: RewardBlock, Value: new(big.Int).Set(blockReward)}}
 	for _, uncle := range uncles {
-		r.Add(uncle.Number, big8)
+		r := new(big.Int).Add(uncle.Number, big8)
 		r.Sub(r, header.Number)
 		r.Mul(r, blockReward)
 		r.Div(r, big8)
-		state.AddBalance(uncle.Coinbase, r)
+		rewards = append(rewards, BlockReward{Beneficiary: uncle.Coinbase, Type: RewardUncle, Value: r})
 
-		r.Div(blockReward, big32)
-		reward.Add(reward, r)
+		rewards[0].Value.Add(rewards[0].Value, new(big.Int).Div(blockReward, big32))
+	}
+	return rewards
+}
+
+// accumulateRewards credits the coinbase of the given block with the mining
+// reward, along with the coinbases of the included uncles.
+func accumulateRewards(config *params.ChainConfig, state *state.StateDB, header *types.Header, uncles []*types.Header) {
+	for _, reward := range BlockRewards(config, header, uncles) {
+		state.AddBalance(reward.Beneficiary, reward.Value)
 	}
-	state.AddBalance(header.Coinbase, reward)
 }
@@ -18,12 +18,13 @@ package ethash
 import (
 	"math/big"
 
-	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/params"
 )
 
-func accumulateECIP1017Rewards(config *params.ChainConfig, state *state.StateDB, header *types.Header, uncles []*types.Header) {
+// ecip1017Rewards returns the block rewards of chains following the ECIP-1017
+// monetary policy, reducing the rewards by 20% every era.
+func ecip1017Rewards(config *params.ChainConfig, header *types.Header, uncles []*types.Header) []BlockReward {
 	blockReward := FrontierBlockReward
 
 	// Ensure value 'era' is configured.
@@ -32,13 +33,14 @@ func accumulateECIP1017Rewards(config *params.ChainConfig, state *state.StateDB,
 	wr := getBlockWinnerRewardByEra(era, blockReward)                    // wr "winner reward". 5, 4, 3.2, 2.56, ...
 	wurs := getBlockWinnerRewardForUnclesByEra(era, uncles, blockReward) // wurs "winner uncle rewards"
 	wr.Add(wr, wurs)
-	state.AddBalance(header.Coinbase, wr) // $$
+	rewards := []BlockReward{{Beneficiary: header.Coinbase, Type: RewardBlock, Value: wr}} // $$
 
 	// Reward uncle miners.
 	for _, uncle := range uncles {
 		ur := getBlockUncleRewardByEra(era, header, uncle, blockReward)
-		state.AddBalance(uncle.Coinbase, ur) // $$
+		rewards = append(rewards, BlockReward{Beneficiary: uncle.Coinbase, Type: RewardUncle, Value: ur}) // $$
 	}
+	return rewards
 }
 
 func ecip1010Explosion(config *params.ChainConfig, next *big.Int, exPeriodRef *big.Int) {
@@ -19,12 +19,13 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/params"
 )
 
-func accumulateMCIP0Rewards(config *params.ChainConfig, state *state.StateDB, header *types.Header, uncles []*types.Header) {
+// mcip0Rewards returns the block rewards of the Musicoin chain, which credits
+// the UBI and development reservoirs alongside the miners since MCIP-3.
+func mcip0Rewards(config *params.ChainConfig, header *types.Header, uncles []*types.Header) []BlockReward {
 	// Select the correct block reward based on chain progression
 	blockReward := params.MCIP0BlockReward
 	mcip3Reward := params.MCIP3BlockReward
@@ -32,34 +33,32 @@ func accumulateMCIP0Rewards(config *params.ChainConfig, state *state.StateDB, he
 	ubiReservoir := params.MusicoinUbiBlockReward
 	devReservoir := params.MusicoinDevBlockReward
 
-	reward := new(big.Int).Set(blockReward)
-
-	if config.IsMCIP8(header.Number) {
-		state.AddBalance(header.Coinbase, mcip8Reward)
-		state.AddBalance(common.HexToAddress("0x00eFdd5883eC628983E9063c7d969fE268BBf310"), ubiReservoir)
-		state.AddBalance(common.HexToAddress("0x00756cF8159095948496617F5FB17ED95059f536"), devReservoir)
-		blockReward := mcip8Reward
-		reward := new(big.Int).Set(blockReward)
-		_ = reward
-	} else if config.IsMCIP3(header.Number) {
-		state.AddBalance(header.Coinbase, mcip3Reward)
-		state.AddBalance(common.HexToAddress("0x00eFdd5883eC628983E9063c7d969fE268BBf310"), ubiReservoir)
-		state.AddBalance(common.HexToAddress("0x00756cF8159095948496617F5FB17ED95059f536"), devReservoir)
+	var rewards []BlockReward
+	switch {
+	case config.IsMCIP8(header.Number):
+		rewards = []BlockReward{
+			{Beneficiary: header.Coinbase, Type: RewardBlock, Value: new(big.Int).Set(mcip8Reward)},
+			{Beneficiary: common.HexToAddress("0x00eFdd5883eC628983E9063c7d969fE268BBf310"), Type: RewardExternal, Value: new(big.Int).Set(ubiReservoir)},
+			{Beneficiary: common.HexToAddress("0x00756cF8159095948496617F5FB17ED95059f536"), Type: RewardExternal, Value: new(big.Int).Set(devReservoir)},
+		}
+	case config.IsMCIP3(header.Number):
+		rewards = []BlockReward{
+			{Beneficiary: header.Coinbase, Type: RewardBlock, Value: new(big.Int).Set(mcip3Reward)},
+			{Beneficiary: common.HexToAddress("0x00eFdd5883eC628983E9063c7d969fE268BBf310"), Type: RewardExternal, Value: new(big.Int).Set(ubiReservoir)},
+			{Beneficiary: common.HexToAddress("0x00756cF8159095948496617F5FB17ED95059f536"), Type: RewardExternal, Value: new(big.Int).Set(devReservoir)},
+		}
 		// no change to uncle reward during UBI fork, a mistake but now a legacy
-	} else {
-		state.AddBalance(header.Coinbase, reward)
+	default:
+		rewards = []BlockReward{{Beneficiary: header.Coinbase, Type: RewardBlock, Value: new(big.Int).Set(blockReward)}}
 	}
-
-	// Accumulate the rewards for the miner and any included uncles
-	r := new(big.Int)
+	// Reward the miners of any included uncles. Contrary to the other chains,
+	// the miner doesn't receive any additional reward for including them.
 	for _, uncle := range uncles {
-		r.Add(uncle.Number, big8)
+		r := new(big.Int).Add(uncle.Number, big8)
 		r.Sub(r, header.Number)
 		r.Mul(r, blockReward)
 		r.Div(r, big8)
-		state.AddBalance(uncle.Coinbase, r)
-
-		r.Div(blockReward, big32)
-		reward.Add(reward, r)
+		rewards = append(rewards, BlockReward{Beneficiary: uncle.Coinbase, Type: RewardUncle, Value: r})
 	}
+	return rewards
 }
@@ -0,0 +1,235 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package instaseal implements a consensus engine for development chains that
+// seals blocks instantly and places no restrictions on block timestamps other
+// than them being increasing, allowing the chain to travel forward in time.
+package instaseal
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	// difficulty is the fixed difficulty of every instantly sealed block.
+	difficulty = big.NewInt(1)
+
+	// uncleHash is the uncle hash of every block, as uncles cannot exist
+	// without competing miners.
+	uncleHash = types.CalcUncleHash(nil)
+)
+
+// Various error messages to mark blocks invalid.
+var (
+	// errUnknownBlock is returned when a header without a number is verified.
+	errUnknownBlock = errors.New("unknown block")
+
+	// errInvalidTimestamp is returned if the timestamp of a block is not above
+	// that of its parent.
+	errInvalidTimestamp = errors.New("invalid timestamp")
+
+	// errInvalidDifficulty is returned if the difficulty of a block is not 1.
+	errInvalidDifficulty = errors.New("invalid difficulty")
+
+	// errInvalidUncleHash is returned if a block contains a non-empty uncle list.
+	errInvalidUncleHash = errors.New("non empty uncle hash")
+
+	// errUnclesNotAllowed is returned if a block body contains uncles.
+	errUnclesNotAllowed = errors.New("uncles not allowed")
+)
+
+// InstaSeal is a consensus engine for single node development chains. Blocks
+// are sealed as soon as they are assembled, carry no proof and pay no rewards.
+type InstaSeal struct{}
+
+// New creates an instant seal consensus engine.
+func New() *InstaSeal {
+	return new(InstaSeal)
+}
+
+// Author implements consensus.Engine, returning the header's coinbase.
+func (i *InstaSeal) Author(header *types.Header) (common.Address, error) {
+	return header.Coinbase, nil
+}
+
+// VerifyHeader checks whether a header conforms to the consensus rules.
+func (i *InstaSeal) VerifyHeader(chain consensus.ChainHeaderReader, header *types.Header, seal bool) error {
+	return i.verifyHeader(chain, header, nil)
+}
+
+// VerifyHeaders is similar to VerifyHeader, but verifies a batch of headers. The
+// method returns a quit channel to abort the operations and a results channel to
+// retrieve the async verifications (the order is that of the input slice).
+func (i *InstaSeal) VerifyHeaders(chain consensus.ChainHeaderReader, headers []*types.Header, seals []bool) (chan<- struct{}, <-chan error) {
+	abort := make(chan struct{})
+	results := make(chan error, len(headers))
+
+	go func() {
+		for n, header := range headers {
+			err := i.verifyHeader(chain, header, headers[:n])
+
+			select {
+			case <-abort:
+				return
+			case results <- err:
+			}
+		}
+	}()
+	return abort, results
+}
+
+// verifyHeader checks whether a header conforms to the consensus rules. The
+// caller may optionally pass in a batch of parents (ascending order) to avoid
+// looking those up from the database.
+//
+// Contrary to the production engines, headers from the future are accepted,
+// since moving the chain ahead of the wall clock is the point of the engine.
+func (i *InstaSeal) verifyHeader(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header) error {
+	if header.Number == nil {
+		return errUnknownBlock
+	}
+	number := header.Number.Uint64()
+	if number == 0 {
+		return nil
+	}
+	if uint64(len(header.Extra)) > params.MaximumExtraDataSize {
+		return fmt.Errorf("extra-data too long: %d > %d", len(header.Extra), params.MaximumExtraDataSize)
+	}
+	if header.UncleHash != uncleHash {
+		return errInvalidUncleHash
+	}
+	if header.Difficulty == nil || header.Difficulty.Cmp(difficulty) != 0 {
+		return errInvalidDifficulty
+	}
+	// Verify that the gas limit is <= 2^63-1
+	cap := uint64(0x7fffffffffffffff)
+	if header.GasLimit > cap {
+		return fmt.Errorf("invalid gasLimit: have %v, max %v", header.GasLimit, cap)
+	}
+	if header.GasLimit < params.MinGasLimit {
+		return fmt.Errorf("invalid gasLimit: have %v, min %v", header.GasLimit, params.MinGasLimit)
+	}
+	if header.GasUsed > header.GasLimit {
+		return fmt.Errorf("invalid gasUsed: have %d, gasLimit %d", header.GasUsed, header.GasLimit)
+	}
+	if err := misc.VerifyForkHashes(chain.Config(), header, false); err != nil {
+		return err
+	}
+	var parent *types.Header
+	if len(parents) > 0 {
+		parent = parents[len(parents)-1]
+	} else {
+		parent = chain.GetHeader(header.ParentHash, number-1)
+	}
+	if parent == nil || parent.Number.Uint64() != number-1 || parent.Hash() != header.ParentHash {
+		return consensus.ErrUnknownAncestor
+	}
+	if header.Time <= parent.Time {
+		return errInvalidTimestamp
+	}
+	if !chain.Config().IsEIP1559(header.Number) {
+		return misc.VerifyPreEip1559Header(header)
+	}
+	return misc.VerifyEip1559Header(chain.Config(), parent, header)
+}
+
+// VerifyUncles implements consensus.Engine, always returning an error for any
+// uncles as this consensus mechanism doesn't permit uncles.
+func (i *InstaSeal) VerifyUncles(chain consensus.ChainReader, block *types.Block) error {
+	if len(block.Uncles()) > 0 {
+		return errUnclesNotAllowed
+	}
+	return nil
+}
+
+// VerifySeal implements consensus.Engine. Instantly sealed blocks carry no
+// proof, so there is nothing to verify.
+func (i *InstaSeal) VerifySeal(chain consensus.ChainHeaderReader, header *types.Header) error {
+	return nil
+}
+
+// Prepare implements consensus.Engine, setting the fixed block difficulty.
+func (i *InstaSeal) Prepare(chain consensus.ChainHeaderReader, header *types.Header) error {
+	if chain.GetHeader(header.ParentHash, header.Number.Uint64()-1) == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	header.Difficulty = new(big.Int).Set(difficulty)
+	return nil
+}
+
+// Finalize implements consensus.Engine. There are no block rewards on
+// development chains, so the state remains as is and uncles are dropped.
+func (i *InstaSeal) Finalize(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header) {
+	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
+	header.UncleHash = uncleHash
+}
+
+// FinalizeAndAssemble implements consensus.Engine, ensuring no uncles are set,
+// nor block rewards given, and returns the final block.
+func (i *InstaSeal) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
+	i.Finalize(chain, header, state, txs, nil)
+	return types.NewBlock(header, txs, nil, receipts, new(trie.Trie)), nil
+}
+
+// Seal implements consensus.Engine, delivering the block as is to the results
+// channel.
+func (i *InstaSeal) Seal(chain consensus.ChainHeaderReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}) error {
+	if block.NumberU64() == 0 {
+		return errUnknownBlock
+	}
+	select {
+	case results <- block:
+	default:
+		log.Warn("Sealing result is not read by miner", "sealhash", i.SealHash(block.Header()))
+	}
+	return nil
+}
+
+// SealHash returns the hash of a block prior to it being sealed, which is the
+// block hash itself as sealing doesn't modify the header.
+func (i *InstaSeal) SealHash(header *types.Header) common.Hash {
+	return header.Hash()
+}
+
+// CalcDifficulty is the difficulty adjustment algorithm. It returns the fixed
+// difficulty of instantly sealed blocks.
+func (i *InstaSeal) CalcDifficulty(chain consensus.ChainHeaderReader, time uint64, parent *types.Header) *big.Int {
+	return new(big.Int).Set(difficulty)
+}
+
+// APIs implements consensus.Engine. The chain controls of development chains
+// are exposed by the node itself, as they need access to the transaction pool.
+func (i *InstaSeal) APIs(chain consensus.ChainHeaderReader) []rpc.API {
+	return nil
+}
+
+// Close implements consensus.Engine. It's a noop as there are no background
+// threads.
+func (i *InstaSeal) Close() error {
+	return nil
+}
@@ -3030,3 +3030,168 @@ func TestInitThenFailCreateContract(t *testing.T) {
 		}
 	}
 }
+
+// TestEIP2718Transition tests that an EIP-2718 access list transaction is
+// processed correctly, with its access list pre-warmed and charged for.
+//
+// The contract reads two storage slots, only one of which is in the access list.
+func TestEIP2718Transition(t *testing.T) {
+	var (
+		aa = common.HexToAddress("0x000000000000000000000000000000000000aaaa")
+
+		// Generate a canonical chain to act as the main dataset
+		engine = ethash.NewFaker()
+		db     = rawdb.NewMemoryDatabase()
+
+		// A sender who makes transactions, has some funds
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		funds   = big.NewInt(1000000000)
+		config  = *params.AllEthashProtocolChanges
+		gspec   = &Genesis{
+			Config: &config,
+			Alloc: GenesisAlloc{
+				address: {Balance: funds},
+				// The address 0xAAAA sloads 0x00 and 0x01
+				aa: {
+					Code: []byte{
+						byte(vm.PC),
+						byte(vm.PC),
+						byte(vm.SLOAD),
+						byte(vm.SLOAD),
+					},
+					Nonce:   0,
+					Balance: big.NewInt(0),
+				},
+			},
+		}
+	)
+	config.YoloV2Block = big.NewInt(0)
+	genesis := gspec.MustCommit(db)
+
+	blocks, _ := GenerateChain(gspec.Config, genesis, engine, db, 1, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{1})
+
+		// One transaction to 0xAAAA
+		signer := types.LatestSigner(gspec.Config)
+		tx, _ := types.SignTx(types.NewTx(&types.AccessListTx{
+			ChainID:  gspec.Config.ChainID,
+			Nonce:    0,
+			To:       &aa,
+			Gas:      30000,
+			GasPrice: big.NewInt(1),
+			AccessList: types.AccessList{{
+				Address:     aa,
+				StorageKeys: []common.Hash{{0}},
+			}},
+		}), signer, key)
+		b.AddTx(tx)
+	})
+	// Import the canonical chain
+	diskdb := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(diskdb)
+
+	chain, err := NewBlockChain(diskdb, nil, gspec.Config, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	block := chain.GetBlockByNumber(1)
+
+	// Expected gas is intrinsic + 2 * pc + hot load + cold load, since only one load is in the access list
+	expected := params.TxGas + params.TxAccessListAddressGas + params.TxAccessListStorageKeyGas +
+		vm.GasQuickStep*2 + vm.WarmStorageReadCostEIP2929 + vm.ColdSloadCostEIP2929
+	if block.GasUsed() != expected {
+		t.Fatalf("incorrect amount of gas spent: expected %d, got %d", expected, block.GasUsed())
+	}
+	// The receipt of the transaction must carry its type
+	receipts := chain.GetReceiptsByHash(block.Hash())
+	if len(receipts) != 1 || receipts[0].Type != types.AccessListTxType {
+		t.Fatalf("receipt type mismatch: %v", receipts)
+	}
+}
+
+// TestEIP1559Transition tests that EIP-1559 dynamic fee transactions pay their
+// tip to the miner, while the base fee is either burned or credited to the fee
+// recipient configured by the chain.
+func TestEIP1559Transition(t *testing.T) {
+	var (
+		treasury = common.HexToAddress("0x000000000000000000000000000000000000bbbb")
+		miner    = common.Address{1}
+
+		// A sender who makes transactions, has some funds
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		funds   = big.NewInt(params.Ether)
+		tip     = big.NewInt(2)
+	)
+	for _, recipient := range []*common.Address{nil, &treasury} {
+		var (
+			engine = ethash.NewFaker()
+			db     = rawdb.NewMemoryDatabase()
+			config = *params.AllEthashProtocolChanges
+			gspec  = &Genesis{
+				Config: &config,
+				Alloc:  GenesisAlloc{address: {Balance: funds}},
+			}
+		)
+		config.EIP1559Block = big.NewInt(0)
+		config.EIP1559FeeRecipient = recipient
+		genesis := gspec.MustCommit(db)
+
+		blocks, _ := GenerateChain(gspec.Config, genesis, engine, db, 1, func(i int, b *BlockGen) {
+			b.SetCoinbase(miner)
+
+			tx, _ := types.SignTx(types.NewTx(&types.DynamicFeeTx{
+				ChainID:   gspec.Config.ChainID,
+				Nonce:     0,
+				To:        &common.Address{0xaa},
+				Gas:       params.TxGas,
+				GasFeeCap: new(big.Int).SetUint64(2 * params.InitialBaseFee),
+				GasTipCap: tip,
+			}), types.LatestSigner(gspec.Config), key)
+			b.AddTx(tx)
+		})
+		// Import the canonical chain
+		diskdb := rawdb.NewMemoryDatabase()
+		gspec.MustCommit(diskdb)
+
+		chain, err := NewBlockChain(diskdb, nil, gspec.Config, engine, vm.Config{}, nil, nil)
+		if err != nil {
+			t.Fatalf("failed to create tester chain: %v", err)
+		}
+		if n, err := chain.InsertChain(blocks); err != nil {
+			t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+		}
+		block := chain.GetBlockByNumber(1)
+		if block.BaseFee() == nil {
+			t.Fatalf("block is missing its base fee")
+		}
+		state, _ := chain.State()
+		gasUsed := new(big.Int).SetUint64(block.GasUsed())
+
+		// The sender pays the base fee and the tip for all the gas used
+		price := new(big.Int).Add(block.BaseFee(), tip)
+		if have, want := new(big.Int).Sub(funds, state.GetBalance(address)), new(big.Int).Mul(gasUsed, price); have.Cmp(want) != 0 {
+			t.Errorf("recipient %v: sender cost mismatch: have %v, want %v", recipient, have, want)
+		}
+		// The miner only collects the tip on top of its block reward
+		reward := new(big.Int).Sub(state.GetBalance(miner), new(big.Int).Mul(gasUsed, tip))
+		if reward.Cmp(ethash.ConstantinopleBlockReward) != 0 {
+			t.Errorf("recipient %v: miner reward mismatch: have %v, want %v", recipient, reward, ethash.ConstantinopleBlockReward)
+		}
+		// The base fee is either burned or paid to the fee recipient
+		want := new(big.Int)
+		if recipient != nil {
+			want.Mul(gasUsed, block.BaseFee())
+		}
+		if have := state.GetBalance(treasury); have.Cmp(want) != 0 {
+			t.Errorf("recipient %v: treasury balance mismatch: have %v, want %v", recipient, have, want)
+		}
+		chain.Stop()
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// CallTrace is a single call made while executing a transaction, flattened out
+// of the call tree. Its depth is the length of its trace address.
+type CallTrace struct {
+	TxIndex      uint64   // Index of the transaction within the block
+	TraceAddress []uint64 // Path of the call within the call tree of the transaction
+	Subtraces    uint64   // Number of calls made directly by this call
+	Type         string   // Opcode of the call (CALL, CREATE, SELFDESTRUCT, etc)
+	From         common.Address
+	To           common.Address // Callee, created contract or self-destruct beneficiary
+	Value        *big.Int
+	Gas          uint64
+	GasUsed      uint64
+	Input        []byte
+	Output       []byte
+	Error        string // Failure of the call, empty if successful
+}
+
+// CallTraces is the flattened call traces of all the transactions of a block.
+type CallTraces struct {
+	Hash      common.Hash      // Hash of the traced block
+	Addresses []common.Address // Addresses indexed as touched by the block
+	Traces    []*CallTrace
+	Error     string // Failure tracing the block, indexed without traces, empty if traced
+}
+
+// ReadCallTraceHead retrieves the number of the latest block whose call traces
+// have been indexed.
+func ReadCallTraceHead(db ethdb.KeyValueReader) *uint64 {
+	data, _ := db.Get(callTraceHeadKey)
+	if len(data) != 8 {
+		return nil
+	}
+	number := binary.BigEndian.Uint64(data)
+	return &number
+}
+
+// WriteCallTraceHead stores the number of the latest block whose call traces
+// have been indexed.
+func WriteCallTraceHead(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Put(callTraceHeadKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store the call trace index head", "err", err)
+	}
+}
+
+// ReadCallTraceTail retrieves the number of the oldest block whose call traces
+// have been indexed.
+func ReadCallTraceTail(db ethdb.KeyValueReader) *uint64 {
+	data, _ := db.Get(callTraceTailKey)
+	if len(data) != 8 {
+		return nil
+	}
+	number := binary.BigEndian.Uint64(data)
+	return &number
+}
+
+// WriteCallTraceTail stores the number of the oldest block whose call traces
+// have been indexed.
+func WriteCallTraceTail(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Put(callTraceTailKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store the call trace index tail", "err", err)
+	}
+}
+
+// ReadCallTraces retrieves the call traces of the canonical block with the
+// given number.
+func ReadCallTraces(db ethdb.KeyValueReader, number uint64) *CallTraces {
+	data, _ := db.Get(callTraceKey(number))
+	if len(data) == 0 {
+		return nil
+	}
+	traces := new(CallTraces)
+	if err := rlp.DecodeBytes(data, traces); err != nil {
+		log.Error("Invalid call traces RLP", "number", number, "err", err)
+		return nil
+	}
+	return traces
+}
+
+// WriteCallTraces stores the call traces of the canonical block with the given
+// number, indexing the block under all the addresses it touched. Any traces of
+// a previous block with the same number must be deleted beforehand.
+func WriteCallTraces(db ethdb.KeyValueWriter, number uint64, traces *CallTraces) {
+	data, err := rlp.EncodeToBytes(traces)
+	if err != nil {
+		log.Crit("Failed to RLP encode call traces", "err", err)
+	}
+	if err := db.Put(callTraceKey(number), data); err != nil {
+		log.Crit("Failed to store call traces", "err", err)
+	}
+	for _, addr := range traces.Addresses {
+		if err := db.Put(callTraceAddrKey(addr, number), nil); err != nil {
+			log.Crit("Failed to store call trace index", "err", err)
+		}
+	}
+}
+
+// DeleteCallTraces removes the call traces of the block with the given number,
+// along with the address index entries of the given stored traces.
+func DeleteCallTraces(db ethdb.KeyValueWriter, number uint64, traces *CallTraces) {
+	if traces != nil {
+		for _, addr := range traces.Addresses {
+			if err := db.Delete(callTraceAddrKey(addr, number)); err != nil {
+				log.Crit("Failed to delete call trace index", "err", err)
+			}
+		}
+	}
+	if err := db.Delete(callTraceKey(number)); err != nil {
+		log.Crit("Failed to delete call traces", "err", err)
+	}
+}
+
+// ReadCallTraceBlocks retrieves the numbers of the indexed blocks in the
+// inclusive range [from, to] which touched the given address, in ascending
+// order.
+func ReadCallTraceBlocks(db ethdb.Iteratee, address common.Address, from, to uint64) []uint64 {
+	prefix := append(append([]byte{}, callTraceAddrPrefix...), address.Bytes()...)
+
+	it := db.NewIterator(prefix, encodeBlockNumber(from))
+	defer it.Release()
+
+	var numbers []uint64
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(prefix)+8 {
+			continue
+		}
+		number := binary.BigEndian.Uint64(key[len(prefix):])
+		if number > to {
+			break
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers
+}
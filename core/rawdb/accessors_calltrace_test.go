@@ -0,0 +1,70 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Tests call trace storage, address index retrieval and deletion.
+func TestCallTraceStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	var (
+		alice = common.Address{0x0a}
+		bob   = common.Address{0x0b}
+	)
+	for i := uint64(1); i <= 4; i++ {
+		traces := &CallTraces{
+			Hash:      common.BytesToHash([]byte{byte(i)}),
+			Addresses: []common.Address{alice},
+			Traces: []*CallTrace{{
+				TraceAddress: []uint64{},
+				Type:         "CALL",
+				From:         alice,
+				To:           alice,
+				Value:        big.NewInt(int64(i)),
+				Input:        []byte{},
+				Output:       []byte{},
+			}},
+		}
+		if i%2 == 0 {
+			traces.Addresses = append(traces.Addresses, bob)
+		}
+		WriteCallTraces(db, i, traces)
+	}
+	if traces := ReadCallTraces(db, 3); traces == nil || traces.Hash != common.BytesToHash([]byte{3}) || traces.Traces[0].Value.Uint64() != 3 {
+		t.Fatalf("call traces mismatch: have %+v", traces)
+	}
+	if have := ReadCallTraceBlocks(db, alice, 2, 3); !reflect.DeepEqual(have, []uint64{2, 3}) {
+		t.Fatalf("indexed blocks mismatch: have %v, want [2 3]", have)
+	}
+	if have := ReadCallTraceBlocks(db, bob, 0, 10); !reflect.DeepEqual(have, []uint64{2, 4}) {
+		t.Fatalf("indexed blocks mismatch: have %v, want [2 4]", have)
+	}
+	DeleteCallTraces(db, 2, ReadCallTraces(db, 2))
+	if traces := ReadCallTraces(db, 2); traces != nil {
+		t.Fatalf("deleted call traces returned: %+v", traces)
+	}
+	if have := ReadCallTraceBlocks(db, bob, 0, 10); !reflect.DeepEqual(have, []uint64{4}) {
+		t.Fatalf("indexed blocks mismatch after deletion: have %v, want [4]", have)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// ChainStats is the aggregated difficulty and timing data of a section of the
+// canonical chain, used to answer network statistics queries without having
+// to walk all the headers.
+type ChainStats struct {
+	Blocks          uint64   // Number of blocks aggregated
+	Difficulty      *big.Int // Sum of the block difficulties
+	MinDifficulty   *big.Int // Lowest block difficulty
+	MaxDifficulty   *big.Int // Highest block difficulty
+	StartTime       uint64   // Timestamp of the parent of the first block
+	EndTime         uint64   // Timestamp of the last block
+	MaxBlockTime    uint64   // Longest time between two consecutive blocks
+	TotalDifficulty *big.Int // Total difficulty at the last block
+}
+
+// ReadChainStats retrieves the network statistics of the given section, keyed
+// by the hash of its last header.
+func ReadChainStats(db ethdb.KeyValueReader, section uint64, head common.Hash) *ChainStats {
+	data, _ := db.Get(chainStatsKey(section, head))
+	if len(data) == 0 {
+		return nil
+	}
+	stats := new(ChainStats)
+	if err := rlp.DecodeBytes(data, stats); err != nil {
+		log.Error("Invalid network statistics RLP", "section", section, "head", head, "err", err)
+		return nil
+	}
+	return stats
+}
+
+// WriteChainStats stores the network statistics of the given section.
+func WriteChainStats(db ethdb.KeyValueWriter, section uint64, head common.Hash, stats *ChainStats) {
+	data, err := rlp.EncodeToBytes(stats)
+	if err != nil {
+		log.Crit("Failed to RLP encode network statistics", "err", err)
+	}
+	if err := db.Put(chainStatsKey(section, head), data); err != nil {
+		log.Crit("Failed to store network statistics", "err", err)
+	}
+}
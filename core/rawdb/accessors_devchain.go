@@ -0,0 +1,39 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// HasAlteredBlock checks whether the block with the given hash was marked as
+// altered, its state transition not being reproducible from its contents. That
+// is the case of the development chain blocks carrying unsigned transactions or
+// direct state modifications.
+func HasAlteredBlock(db ethdb.KeyValueReader, hash common.Hash) bool {
+	ok, _ := db.Has(alteredBlockKey(hash))
+	return ok
+}
+
+// WriteAlteredBlock marks the block with the given hash as altered.
+func WriteAlteredBlock(db ethdb.KeyValueWriter, hash common.Hash) {
+	if err := db.Put(alteredBlockKey(hash), []byte{1}); err != nil {
+		log.Crit("Failed to store altered block marker", "err", err)
+	}
+}
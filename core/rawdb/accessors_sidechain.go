@@ -0,0 +1,121 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// SideBlock is the metadata retained about a block that ended up outside of the
+// canonical chain, used for uncle and fork analytics.
+type SideBlock struct {
+	Hash       common.Hash
+	ParentHash common.Hash
+	Number     uint64
+	Coinbase   common.Address
+	Difficulty *big.Int
+	Time       uint64 // Timestamp of the block header
+	Seen       uint64 // Local time the block was first seen (unix milliseconds)
+	CanonSeen  uint64 // Local time the canonical sibling was first seen (unix milliseconds, 0 if unknown)
+}
+
+// ReadSideBlock retrieves the side block metadata for the given number and hash.
+func ReadSideBlock(db ethdb.KeyValueReader, number uint64, hash common.Hash) *SideBlock {
+	data, _ := db.Get(sideBlockKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	block := new(SideBlock)
+	if err := rlp.DecodeBytes(data, block); err != nil {
+		log.Error("Invalid side block RLP", "number", number, "hash", hash, "err", err)
+		return nil
+	}
+	return block
+}
+
+// ReadSideBlocks retrieves the metadata of all side blocks with a number in
+// the inclusive range [from, to], ordered by number.
+func ReadSideBlocks(db ethdb.Iteratee, from, to uint64) []*SideBlock {
+	it := db.NewIterator(sideBlockPrefix, encodeBlockNumber(from))
+	defer it.Release()
+
+	var blocks []*SideBlock
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(sideBlockPrefix)+8+common.HashLength {
+			continue
+		}
+		if binary.BigEndian.Uint64(key[len(sideBlockPrefix):]) > to {
+			break
+		}
+		block := new(SideBlock)
+		if err := rlp.DecodeBytes(it.Value(), block); err != nil {
+			log.Error("Invalid side block RLP", "key", key, "err", err)
+			continue
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// WriteSideBlock stores the metadata of a side block into the database.
+func WriteSideBlock(db ethdb.KeyValueWriter, block *SideBlock) {
+	data, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		log.Crit("Failed to RLP encode side block", "err", err)
+	}
+	if err := db.Put(sideBlockKey(block.Number, block.Hash), data); err != nil {
+		log.Crit("Failed to store side block", "err", err)
+	}
+}
+
+// DeleteSideBlocksBelow removes the metadata of all side blocks with a number
+// below the given one from the database.
+func DeleteSideBlocksBelow(db ethdb.KeyValueStore, number uint64) {
+	it := db.NewIterator(sideBlockPrefix, nil)
+	defer it.Release()
+
+	batch := db.NewBatch()
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(sideBlockPrefix)+8+common.HashLength {
+			continue
+		}
+		if binary.BigEndian.Uint64(key[len(sideBlockPrefix):]) >= number {
+			break
+		}
+		if err := batch.Delete(key); err != nil {
+			log.Crit("Failed to delete side block", "err", err)
+		}
+	}
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to delete side blocks", "err", err)
+	}
+}
+
+// DeleteSideBlock removes the metadata of a side block from the database.
+func DeleteSideBlock(db ethdb.KeyValueWriter, number uint64, hash common.Hash) {
+	if err := db.Delete(sideBlockKey(number, hash)); err != nil {
+		log.Crit("Failed to delete side block", "err", err)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Tests side block metadata storage, range retrieval and deletion.
+func TestSideBlockStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	var blocks []*SideBlock
+	for i := uint64(1); i <= 4; i++ {
+		block := &SideBlock{
+			Hash:       common.BytesToHash([]byte{byte(i)}),
+			Number:     i * 10,
+			Coinbase:   common.BytesToAddress([]byte{byte(i)}),
+			Difficulty: big.NewInt(int64(i)),
+			Seen:       i,
+		}
+		WriteSideBlock(db, block)
+		blocks = append(blocks, block)
+	}
+	if block := ReadSideBlock(db, 20, blocks[1].Hash); block == nil || block.Coinbase != blocks[1].Coinbase {
+		t.Fatalf("side block mismatch: have %v, want %v", block, blocks[1])
+	}
+	if block := ReadSideBlock(db, 30, blocks[1].Hash); block != nil {
+		t.Fatalf("side block returned for wrong number: %v", block)
+	}
+	if have := ReadSideBlocks(db, 15, 30); len(have) != 2 || have[0].Number != 20 || have[1].Number != 30 {
+		t.Fatalf("side block range mismatch: have %v", have)
+	}
+	DeleteSideBlock(db, 20, blocks[1].Hash)
+	if have := ReadSideBlocks(db, 0, 100); len(have) != 3 {
+		t.Fatalf("side block count mismatch after deletion: have %d, want %d", len(have), 3)
+	}
+	DeleteSideBlocksBelow(db, 30)
+	if have := ReadSideBlocks(db, 0, 100); len(have) != 2 || have[0].Number != 30 || have[1].Number != 40 {
+		t.Fatalf("side block range mismatch after pruning: have %v", have)
+	}
+}
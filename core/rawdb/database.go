@@ -294,6 +294,11 @@ func InspectDatabase(db ethdb.Database) error {
 		preimages       stat
 		bloomBits       stat
 		cliqueSnaps     stat
+		sideBlocks      stat
+		chainStats      stat
+		callTraces      stat
+		callTraceAddrs  stat
+		alteredBlocks   stat
 
 		// Ancient store statistics
 		ancientHeadersSize  common.StorageSize
@@ -347,6 +352,16 @@ func InspectDatabase(db ethdb.Database) error {
 			preimages.Add(size)
 		case bytes.HasPrefix(key, bloomBitsPrefix) && len(key) == (len(bloomBitsPrefix)+10+common.HashLength):
 			bloomBits.Add(size)
+		case bytes.HasPrefix(key, sideBlockPrefix) && len(key) == (len(sideBlockPrefix)+8+common.HashLength):
+			sideBlocks.Add(size)
+		case bytes.HasPrefix(key, chainStatsPrefix) && len(key) == (len(chainStatsPrefix)+8+common.HashLength):
+			chainStats.Add(size)
+		case bytes.HasPrefix(key, callTracePrefix) && len(key) == (len(callTracePrefix)+8):
+			callTraces.Add(size)
+		case bytes.HasPrefix(key, callTraceAddrPrefix) && len(key) == (len(callTraceAddrPrefix)+common.AddressLength+8):
+			callTraceAddrs.Add(size)
+		case bytes.HasPrefix(key, alteredBlockPrefix) && len(key) == (len(alteredBlockPrefix)+common.HashLength):
+			alteredBlocks.Add(size)
 		case bytes.HasPrefix(key, []byte("clique-")) && len(key) == 7+common.HashLength:
 			cliqueSnaps.Add(size)
 		case bytes.HasPrefix(key, []byte("cht-")) && len(key) == 4+common.HashLength:
@@ -395,6 +410,11 @@ func InspectDatabase(db ethdb.Database) error {
 		{"Key-Value store", "Block hash->number", hashNumPairings.Size(), hashNumPairings.Count()},
 		{"Key-Value store", "Transaction index", txLookups.Size(), txLookups.Count()},
 		{"Key-Value store", "Bloombit index", bloomBits.Size(), bloomBits.Count()},
+		{"Key-Value store", "Side blocks", sideBlocks.Size(), sideBlocks.Count()},
+		{"Key-Value store", "Network statistics", chainStats.Size(), chainStats.Count()},
+		{"Key-Value store", "Call traces", callTraces.Size(), callTraces.Count()},
+		{"Key-Value store", "Call trace index", callTraceAddrs.Size(), callTraceAddrs.Count()},
+		{"Key-Value store", "Altered blocks", alteredBlocks.Size(), alteredBlocks.Count()},
 		{"Key-Value store", "Contract codes", codes.Size(), codes.Count()},
 		{"Key-Value store", "Trie nodes", tries.Size(), tries.Count()},
 		{"Key-Value store", "Trie preimages", preimages.Size(), preimages.Count()},
@@ -60,6 +60,12 @@ var (
 	// txIndexTailKey tracks the oldest block whose transactions have been indexed.
 	txIndexTailKey = []byte("TransactionIndexTail")
 
+	// callTraceHeadKey tracks the latest block whose call traces have been indexed.
+	callTraceHeadKey = []byte("CallTraceIndexHead")
+
+	// callTraceTailKey tracks the oldest block whose call traces have been indexed.
+	callTraceTailKey = []byte("CallTraceIndexTail")
+
 	// fastTxLookupLimitKey tracks the transaction lookup limit during fast sync.
 	fastTxLookupLimitKey = []byte("FastTransactionLookupLimit")
 
@@ -78,11 +84,18 @@ var (
 	SnapshotStoragePrefix = []byte("o") // SnapshotStoragePrefix + account hash + storage hash -> storage trie value
 	codePrefix            = []byte("c") // codePrefix + code hash -> account code
 
+	sideBlockPrefix     = []byte("side-")       // sideBlockPrefix + num (uint64 big endian) + hash -> side block metadata
+	chainStatsPrefix    = []byte("chainstats-") // chainStatsPrefix + section (uint64 big endian) + hash -> network statistics section
+	callTracePrefix     = []byte("ctrace-")     // callTracePrefix + num (uint64 big endian) -> flattened call traces of a block
+	callTraceAddrPrefix = []byte("ctraceaddr-") // callTraceAddrPrefix + address + num (uint64 big endian) -> block touching the address
+	alteredBlockPrefix  = []byte("altered-")    // alteredBlockPrefix + hash -> marker of a block whose execution cannot be reproduced
+
 	preimagePrefix = []byte("secure-key-")      // preimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-") // config prefix for the db
 
 	// Chain index prefixes (use `i` + single byte to avoid mixing data types).
-	BloomBitsIndexPrefix = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
+	BloomBitsIndexPrefix  = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
+	ChainStatsIndexPrefix = []byte("iS") // ChainStatsIndexPrefix is the data table of the network statistics indexer to track its progress
 
 	preimageCounter    = metrics.NewRegisteredCounter("db/preimage/total", nil)
 	preimageHitCounter = metrics.NewRegisteredCounter("db/preimage/hits", nil)
@@ -165,6 +178,31 @@ func blockReceiptsKey(number uint64, hash common.Hash) []byte {
 	return append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
 }
 
+// sideBlockKey = sideBlockPrefix + num (uint64 big endian) + hash
+func sideBlockKey(number uint64, hash common.Hash) []byte {
+	return append(append(sideBlockPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// chainStatsKey = chainStatsPrefix + section (uint64 big endian) + hash
+func chainStatsKey(section uint64, hash common.Hash) []byte {
+	return append(append(chainStatsPrefix, encodeBlockNumber(section)...), hash.Bytes()...)
+}
+
+// callTraceKey = callTracePrefix + num (uint64 big endian)
+func callTraceKey(number uint64) []byte {
+	return append(callTracePrefix, encodeBlockNumber(number)...)
+}
+
+// callTraceAddrKey = callTraceAddrPrefix + address + num (uint64 big endian)
+func callTraceAddrKey(address common.Address, number uint64) []byte {
+	return append(append(callTraceAddrPrefix, address.Bytes()...), encodeBlockNumber(number)...)
+}
+
+// alteredBlockKey = alteredBlockPrefix + hash
+func alteredBlockKey(hash common.Hash) []byte {
+	return append(alteredBlockPrefix, hash.Bytes()...)
+}
+
 // txLookupKey = txLookupPrefix + hash
 func txLookupKey(hash common.Hash) []byte {
 	return append(txLookupPrefix, hash.Bytes()...)
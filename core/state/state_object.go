@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"math/big"
+	"sort"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -313,8 +314,13 @@ func (s *stateObject) updateTrie(db Database) Trie {
 			s.db.snapStorage[s.addrHash] = storage
 		}
 	}
-	// Insert all the pending updates into the trie
-	tr := s.getTrie(db)
+	// Insert all the pending updates into the trie. Deletions are done last and
+	// in order, as collapsing nodes resolves their siblings: the trie nodes
+	// accessed must not depend on the map order for witnesses to be complete.
+	var (
+		tr      = s.getTrie(db)
+		deleted []common.Hash
+	)
 	for key, value := range s.pendingStorage {
 		// Skip noop changes, persist actual changes
 		if value == s.originStorage[key] {
@@ -324,7 +330,7 @@ func (s *stateObject) updateTrie(db Database) Trie {
 
 		var v []byte
 		if (value == common.Hash{}) {
-			s.setError(tr.TryDelete(key[:]))
+			deleted = append(deleted, key)
 		} else {
 			// Encoding []byte cannot fail, ok to ignore the error.
 			v, _ = rlp.EncodeToBytes(common.TrimLeftZeroes(value[:]))
@@ -335,6 +341,10 @@ func (s *stateObject) updateTrie(db Database) Trie {
 			storage[crypto.Keccak256Hash(key[:])] = v // v will be nil if value is 0x00
 		}
 	}
+	sort.Slice(deleted, func(i, j int) bool { return bytes.Compare(deleted[i][:], deleted[j][:]) < 0 })
+	for _, key := range deleted {
+		s.setError(tr.TryDelete(key[:]))
+	}
 	if len(s.pendingStorage) > 0 {
 		s.pendingStorage = make(Storage)
 	}
@@ -0,0 +1,135 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Witness collects the trie nodes and contract code accessed through a state,
+// which are sufficient to repeat the same accesses without the database.
+type Witness struct {
+	nodes map[common.Hash][]byte
+	codes map[common.Hash][]byte
+	lock  sync.Mutex
+}
+
+// NewWitness creates an empty witness.
+func NewWitness() *Witness {
+	return &Witness{
+		nodes: make(map[common.Hash][]byte),
+		codes: make(map[common.Hash][]byte),
+	}
+}
+
+// Put stores a trie node into the witness, implementing ethdb.KeyValueWriter.
+func (w *Witness) Put(key []byte, value []byte) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.nodes[common.BytesToHash(key)] = common.CopyBytes(value)
+	return nil
+}
+
+// Delete is not supported, the witness only grows.
+func (w *Witness) Delete(key []byte) error {
+	return errors.New("witness deletion not supported")
+}
+
+// addCode stores a contract code into the witness.
+func (w *Witness) addCode(hash common.Hash, code []byte) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.codes[hash] = code
+}
+
+// Nodes returns the RLP encoded trie nodes of the witness, ordered by hash.
+func (w *Witness) Nodes() [][]byte {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return sortedValues(w.nodes)
+}
+
+// Codes returns the contract codes of the witness, ordered by hash.
+func (w *Witness) Codes() [][]byte {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return sortedValues(w.codes)
+}
+
+func sortedValues(items map[common.Hash][]byte) [][]byte {
+	hashes := make([]common.Hash, 0, len(items))
+	for hash := range items {
+		hashes = append(hashes, hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool { return bytes.Compare(hashes[i][:], hashes[j][:]) < 0 })
+
+	values := make([][]byte, len(hashes))
+	for i, hash := range hashes {
+		values[i] = items[hash]
+	}
+	return values
+}
+
+// witnessDB is a state database recording the accessed trie nodes and code.
+type witnessDB struct {
+	Database
+	witness *Witness
+}
+
+// OpenTrie opens the main account trie, recording the nodes it loads.
+func (db *witnessDB) OpenTrie(root common.Hash) (Trie, error) {
+	return trie.NewSecureWithWitness(root, db.TrieDB(), db.witness)
+}
+
+// OpenStorageTrie opens the storage trie of an account, recording the nodes
+// it loads.
+func (db *witnessDB) OpenStorageTrie(addrHash, root common.Hash) (Trie, error) {
+	return trie.NewSecureWithWitness(root, db.TrieDB(), db.witness)
+}
+
+// ContractCode retrieves and records a particular contract's code.
+func (db *witnessDB) ContractCode(addrHash, codeHash common.Hash) ([]byte, error) {
+	code, err := db.Database.ContractCode(addrHash, codeHash)
+	if err == nil {
+		db.witness.addCode(codeHash, code)
+	}
+	return code, err
+}
+
+// ContractCodeSize retrieves the size of a particular contract's code. The
+// code itself is recorded, as the size can't be verified otherwise.
+func (db *witnessDB) ContractCodeSize(addrHash, codeHash common.Hash) (int, error) {
+	code, err := db.ContractCode(addrHash, codeHash)
+	return len(code), err
+}
+
+// NewWithWitness creates a new state from a given trie, recording all trie
+// nodes and contract code accessed into witness. Snapshots are not used, as
+// their reads would bypass the tries.
+func NewWithWitness(root common.Hash, db Database, witness *Witness) (*StateDB, error) {
+	return New(root, &witnessDB{Database: db, witness: witness}, nil)
+}
@@ -0,0 +1,162 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package stateless
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// chainContext is the chain access needed to process a block.
+type chainContext interface {
+	consensus.ChainHeaderReader
+	Engine() consensus.Engine
+}
+
+// Execute re-executes the block of the witness using only the data in the
+// witness, and checks the resulting state root against the block. The post
+// state root is returned.
+func Execute(config *params.ChainConfig, engine consensus.Engine, witness *Witness) (common.Hash, error) {
+	if witness.Block == nil {
+		return common.Hash{}, errors.New("witness without block")
+	}
+	var (
+		block = witness.Block
+		chain = newWitnessChain(config, engine, witness)
+	)
+	parent := chain.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return common.Hash{}, errors.New("parent header missing from witness")
+	}
+	db := rawdb.NewMemoryDatabase()
+	for _, node := range witness.Nodes {
+		db.Put(crypto.Keccak256(node), node)
+	}
+	for _, code := range witness.Codes {
+		rawdb.WriteCode(db, crypto.Keccak256Hash(code), code)
+	}
+	statedb, err := state.New(parent.Root, state.NewDatabase(db), nil)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("incomplete witness: %v", err)
+	}
+	root, err := process(chain, block, statedb)
+	if err != nil {
+		return root, err
+	}
+	// Missing state is not fatal to the execution, check it explicitly. Storage
+	// errors only surface when committing.
+	if err := statedb.Error(); err != nil {
+		return root, fmt.Errorf("incomplete witness: %v", err)
+	}
+	if _, err := statedb.Commit(config.IsEIP158(block.Number())); err != nil {
+		return root, fmt.Errorf("incomplete witness: %v", err)
+	}
+	if root != block.Root() {
+		return root, fmt.Errorf("post-state root mismatch: have %x, want %x", root, block.Root())
+	}
+	return root, nil
+}
+
+// process applies the transactions and the consensus rules of the block to
+// the state, returning the post state root.
+func process(chain chainContext, block *types.Block, statedb *state.StateDB) (common.Hash, error) {
+	var (
+		config  = chain.Config()
+		header  = block.Header()
+		usedGas = new(uint64)
+		gp      = new(core.GasPool).AddGas(block.GasLimit())
+	)
+	if config.DAOForkSupport && config.DAOForkBlock != nil && config.DAOForkBlock.Cmp(block.Number()) == 0 {
+		misc.ApplyDAOHardFork(statedb)
+	}
+	for i, tx := range block.Transactions() {
+		statedb.Prepare(tx.Hash(), block.Hash(), i)
+		if _, err := core.ApplyTransaction(config, chain, nil, gp, statedb, header, tx, usedGas, vm.Config{}); err != nil {
+			return common.Hash{}, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+		}
+	}
+	if *usedGas != block.GasUsed() {
+		return common.Hash{}, fmt.Errorf("invalid gas used (remote: %d local: %d)", block.GasUsed(), *usedGas)
+	}
+	chain.Engine().Finalize(chain, header, statedb, block.Transactions(), block.Uncles())
+
+	return statedb.IntermediateRoot(config.IsEIP158(header.Number)), nil
+}
+
+// witnessChain is a chain serving the headers of a witness.
+type witnessChain struct {
+	config  *params.ChainConfig
+	engine  consensus.Engine
+	current *types.Header
+	headers map[common.Hash]*types.Header
+}
+
+func newWitnessChain(config *params.ChainConfig, engine consensus.Engine, witness *Witness) *witnessChain {
+	chain := &witnessChain{
+		config:  config,
+		engine:  engine,
+		current: witness.Block.Header(),
+		headers: make(map[common.Hash]*types.Header),
+	}
+	for _, header := range witness.Headers {
+		chain.headers[header.Hash()] = header
+	}
+	return chain
+}
+
+// Config retrieves the chain configuration.
+func (c *witnessChain) Config() *params.ChainConfig { return c.config }
+
+// Engine retrieves the consensus engine.
+func (c *witnessChain) Engine() consensus.Engine { return c.engine }
+
+// CurrentHeader retrieves the header of the block being executed.
+func (c *witnessChain) CurrentHeader() *types.Header { return c.current }
+
+// GetHeader retrieves a header of the witness by hash and number.
+func (c *witnessChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header := c.headers[hash]; header != nil && header.Number.Uint64() == number {
+		return header
+	}
+	return nil
+}
+
+// GetHeaderByHash retrieves a header of the witness by hash.
+func (c *witnessChain) GetHeaderByHash(hash common.Hash) *types.Header {
+	return c.headers[hash]
+}
+
+// GetHeaderByNumber retrieves a header of the witness by number.
+func (c *witnessChain) GetHeaderByNumber(number uint64) *types.Header {
+	for _, header := range c.headers {
+		if header.Number.Uint64() == number {
+			return header
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package stateless
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr    = crypto.PubkeyToAddress(testKey.PublicKey)
+	testBalance = big.NewInt(1000000000000000000)
+
+	// testContract stores BLOCKHASH(NUMBER-3)+1 in slot NUMBER and clears
+	// slot NUMBER-2, exercising ancestor headers and storage deletions.
+	testContract     = common.HexToAddress("0xc0de")
+	testContractCode = common.FromHex("60034303406001014355600060024303550000")
+)
+
+// newTestChain creates a chain of n blocks calling the test contract and
+// transferring to new accounts.
+func newTestChain(t *testing.T, n int) (*core.BlockChain, []*types.Block) {
+	var (
+		db    = rawdb.NewMemoryDatabase()
+		gspec = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				testAddr:     {Balance: testBalance},
+				testContract: {Code: testContractCode, Balance: new(big.Int)},
+			},
+		}
+		genesis = gspec.MustCommit(db)
+		signer  = types.HomesteadSigner{}
+	)
+	chain, err := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	// Blocks are generated one by one on top of the chain, to have BLOCKHASH
+	// access the previous ones.
+	blocks := []*types.Block{genesis}
+	for i := 0; i < n; i++ {
+		generated, _ := core.GenerateChain(gspec.Config, blocks[i], ethash.NewFaker(), db, 1, func(_ int, b *core.BlockGen) {
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(testAddr), testContract, new(big.Int), 100000, big.NewInt(1), nil), signer, testKey)
+			b.AddTxWithChain(chain, tx)
+			tx, _ = types.SignTx(types.NewTransaction(b.TxNonce(testAddr), common.Address{byte(i + 1)}, big.NewInt(1000), params.TxGas, big.NewInt(1), nil), signer, testKey)
+			b.AddTxWithChain(chain, tx)
+		})
+		if _, err := chain.InsertChain(generated); err != nil {
+			t.Fatalf("failed to insert block %d: %v", i+1, err)
+		}
+		blocks = append(blocks, generated[0])
+	}
+	blocks = blocks[1:]
+	return chain, blocks
+}
+
+func TestWitnessExecution(t *testing.T) {
+	chain, blocks := newTestChain(t, 5)
+	defer chain.Stop()
+
+	for _, block := range blocks {
+		witness, err := Record(chain, chain.Engine(), chain.StateCache(), block)
+		if err != nil {
+			t.Fatalf("block %d: failed to record witness: %v", block.NumberU64(), err)
+		}
+		// Execute the witness after a round trip through its encoding
+		enc, err := rlp.EncodeToBytes(witness)
+		if err != nil {
+			t.Fatalf("block %d: failed to encode witness: %v", block.NumberU64(), err)
+		}
+		var dec Witness
+		if err := rlp.DecodeBytes(enc, &dec); err != nil {
+			t.Fatalf("block %d: failed to decode witness: %v", block.NumberU64(), err)
+		}
+		root, err := Execute(chain.Config(), ethash.NewFaker(), &dec)
+		if err != nil {
+			t.Fatalf("block %d: stateless execution failed: %v", block.NumberU64(), err)
+		}
+		if root != block.Root() {
+			t.Errorf("block %d: root mismatch: have %x, want %x", block.NumberU64(), root, block.Root())
+		}
+		if len(dec.Codes) != 1 {
+			t.Errorf("block %d: code count mismatch: have %d, want 1", block.NumberU64(), len(dec.Codes))
+		}
+		// The parent and the ancestor read by BLOCKHASH must be included
+		want := 1
+		if block.NumberU64() > 3 {
+			want = 2
+		}
+		if len(dec.Headers) < want || dec.Headers[0].Hash() != block.ParentHash() {
+			t.Errorf("block %d: headers mismatch: have %d, want %d starting with the parent", block.NumberU64(), len(dec.Headers), want)
+		}
+	}
+}
+
+func TestIncompleteWitness(t *testing.T) {
+	chain, blocks := newTestChain(t, 3)
+	defer chain.Stop()
+
+	block := blocks[2]
+	witness, err := Record(chain, chain.Engine(), chain.StateCache(), block)
+	if err != nil {
+		t.Fatalf("failed to record witness: %v", err)
+	}
+	// Dropping any node must fail the execution
+	for i := range witness.Nodes {
+		incomplete := *witness
+		incomplete.Nodes = append(append([][]byte{}, witness.Nodes[:i]...), witness.Nodes[i+1:]...)
+		if _, err := Execute(chain.Config(), ethash.NewFaker(), &incomplete); err == nil {
+			t.Errorf("execution succeeded without node %d", i)
+		}
+	}
+	// Dropping the code must fail the execution
+	incomplete := *witness
+	incomplete.Codes = nil
+	if _, err := Execute(chain.Config(), ethash.NewFaker(), &incomplete); err == nil {
+		t.Error("execution succeeded without code")
+	}
+	// Missing the parent header must fail the execution
+	incomplete = *witness
+	incomplete.Headers = incomplete.Headers[1:]
+	if _, err := Execute(chain.Config(), ethash.NewFaker(), &incomplete); err == nil {
+		t.Error("execution succeeded without parent header")
+	}
+}
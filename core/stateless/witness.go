@@ -0,0 +1,124 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package stateless implements the generation of block witnesses, containing
+// the data needed to execute a block without a database, and the stateless
+// execution of blocks from their witnesses.
+package stateless
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Witness contains a block and the minimal data needed to re-execute it: the
+// pre-state trie nodes and contract code accessed, and the headers of the
+// parent and of any ancestor read by BLOCKHASH.
+type Witness struct {
+	Block   *types.Block
+	Headers []*types.Header // Ordered by descending number, starting with the parent
+	Codes   [][]byte
+	Nodes   [][]byte
+}
+
+// Record executes the block on top of its parent state in db, returning the
+// witness needed to re-execute it statelessly. The parent state must be
+// available.
+func Record(chain consensus.ChainHeaderReader, engine consensus.Engine, db state.Database, block *types.Block) (*Witness, error) {
+	if block.NumberU64() == 0 {
+		return nil, errors.New("no witness for the genesis block")
+	}
+	parent := chain.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	recorder := &recordingChain{
+		ChainHeaderReader: chain,
+		engine:            engine,
+		headers:           map[common.Hash]*types.Header{parent.Hash(): parent},
+	}
+	stateWitness := state.NewWitness()
+	statedb, err := state.NewWithWitness(parent.Root, db, stateWitness)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := process(recorder, block, statedb); err != nil {
+		return nil, err
+	}
+	return &Witness{
+		Block:   block,
+		Headers: recorder.recorded(),
+		Codes:   stateWitness.Codes(),
+		Nodes:   stateWitness.Nodes(),
+	}, nil
+}
+
+// recordingChain is a chain recording the headers retrieved through it.
+type recordingChain struct {
+	consensus.ChainHeaderReader
+	engine consensus.Engine
+
+	headers map[common.Hash]*types.Header
+	lock    sync.Mutex
+}
+
+func (c *recordingChain) record(header *types.Header) *types.Header {
+	if header != nil {
+		c.lock.Lock()
+		c.headers[header.Hash()] = header
+		c.lock.Unlock()
+	}
+	return header
+}
+
+// recorded returns the recorded headers, ordered by descending number.
+func (c *recordingChain) recorded() []*types.Header {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	headers := make([]*types.Header, 0, len(c.headers))
+	for _, header := range c.headers {
+		headers = append(headers, header)
+	}
+	sort.Slice(headers, func(i, j int) bool { return headers[i].Number.Cmp(headers[j].Number) > 0 })
+	return headers
+}
+
+// Engine retrieves the chain's consensus engine.
+func (c *recordingChain) Engine() consensus.Engine {
+	return c.engine
+}
+
+// GetHeader retrieves and records a block header by hash and number.
+func (c *recordingChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	return c.record(c.ChainHeaderReader.GetHeader(hash, number))
+}
+
+// GetHeaderByNumber retrieves and records a block header by number.
+func (c *recordingChain) GetHeaderByNumber(number uint64) *types.Header {
+	return c.record(c.ChainHeaderReader.GetHeaderByNumber(number))
+}
+
+// GetHeaderByHash retrieves and records a block header by hash.
+func (c *recordingChain) GetHeaderByHash(hash common.Hash) *types.Header {
+	return c.record(c.ChainHeaderReader.GetHeaderByHash(hash))
+}
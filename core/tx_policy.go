@@ -0,0 +1,176 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/naoina/toml"
+)
+
+var (
+	// ErrPolicySender is returned if the sender of a transaction is not permitted
+	// by the admission policy of the transaction pool.
+	ErrPolicySender = errors.New("sender rejected by pool policy")
+
+	// ErrPolicyRecipient is returned if the recipient of a transaction is not
+	// permitted by the admission policy of the transaction pool.
+	ErrPolicyRecipient = errors.New("recipient rejected by pool policy")
+
+	// ErrPolicyContractCreation is returned if a contract creation is attempted
+	// by an account not permitted to deploy by the admission policy.
+	ErrPolicyContractCreation = errors.New("contract creation rejected by pool policy")
+
+	// ErrPolicyGasPrice is returned if a transaction's effective tip is below the
+	// minimum configured for its sender by the admission policy.
+	ErrPolicyGasPrice = errors.New("gas price below pool policy minimum")
+
+	// ErrPolicyGasLimit is returned if a transaction's gas limit exceeds the
+	// maximum permitted by the admission policy.
+	ErrPolicyGasLimit = errors.New("gas limit above pool policy maximum")
+)
+
+// TxPolicy is a set of admission rules enforced by the transaction pool on top
+// of the generic validity checks. It is meant for permissioned networks where
+// the operator needs to restrict who can transact, with whom and how. Empty
+// lists and zero values disable the corresponding rule.
+type TxPolicy struct {
+	SenderAllow    []common.Address `json:"senderAllow,omitempty" toml:",omitempty"`    // If set, only these accounts may send transactions
+	SenderDeny     []common.Address `json:"senderDeny,omitempty" toml:",omitempty"`     // Accounts that may not send transactions
+	RecipientAllow []common.Address `json:"recipientAllow,omitempty" toml:",omitempty"` // If set, only these accounts may be called or paid
+	RecipientDeny  []common.Address `json:"recipientDeny,omitempty" toml:",omitempty"`  // Accounts that may not be called or paid
+
+	NoContractCreation bool             `json:"noContractCreation,omitempty" toml:",omitempty"` // Whether contract creations are rejected altogether
+	CreatorAllow       []common.Address `json:"creatorAllow,omitempty" toml:",omitempty"`       // If set, only these accounts may create contracts
+
+	MaxGas       uint64                                   `json:"maxGas,omitempty" toml:",omitempty"`       // Maximum gas limit of a single transaction
+	MinGasPrices map[common.Address]*math.HexOrDecimal256 `json:"minGasPrices,omitempty" toml:",omitempty"` // Per-sender minimum gas prices, or tips past EIP-1559
+
+	senderAllow    map[common.Address]struct{}
+	senderDeny     map[common.Address]struct{}
+	recipientAllow map[common.Address]struct{}
+	recipientDeny  map[common.Address]struct{}
+	creatorAllow   map[common.Address]struct{}
+}
+
+// policyTomlSettings ensures that TOML keys use the same names as Go struct
+// fields, matching the node configuration file.
+var policyTomlSettings = toml.Config{
+	NormFieldName: func(rt reflect.Type, key string) string {
+		return key
+	},
+	FieldToKey: func(rt reflect.Type, field string) string {
+		return field
+	},
+	MissingField: func(rt reflect.Type, field string) error {
+		return fmt.Errorf("field '%s' is not defined in %s", field, rt.String())
+	},
+}
+
+// LoadTxPolicy reads a transaction pool admission policy from the given file.
+// Files with a .toml extension are parsed as TOML, anything else as JSON.
+func LoadTxPolicy(path string) (*TxPolicy, error) {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	policy := new(TxPolicy)
+	if strings.ToLower(filepath.Ext(path)) == ".toml" {
+		err = policyTomlSettings.Unmarshal(blob, policy)
+	} else {
+		err = json.Unmarshal(blob, policy)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid txpool policy %s: %v", path, err)
+	}
+	policy.init()
+	return policy, nil
+}
+
+// init builds the lookup sets used during transaction validation.
+func (p *TxPolicy) init() {
+	p.senderAllow = addressSet(p.SenderAllow)
+	p.senderDeny = addressSet(p.SenderDeny)
+	p.recipientAllow = addressSet(p.RecipientAllow)
+	p.recipientDeny = addressSet(p.RecipientDeny)
+	p.creatorAllow = addressSet(p.CreatorAllow)
+}
+
+// addressSet converts a list of addresses into a set, returning nil for an
+// empty list so that the rule it represents is treated as disabled.
+func addressSet(addrs []common.Address) map[common.Address]struct{} {
+	if len(addrs) == 0 {
+		return nil
+	}
+	set := make(map[common.Address]struct{}, len(addrs))
+	for _, addr := range addrs {
+		set[addr] = struct{}{}
+	}
+	return set
+}
+
+// check verifies whether a transaction from the given sender is admissible
+// according to the policy, returning a descriptive error if not. The minimum
+// gas prices apply to the tip the transaction pays on top of the given base fee.
+func (p *TxPolicy) check(from common.Address, tx *types.Transaction, baseFee *big.Int) error {
+	if p.senderAllow != nil {
+		if _, ok := p.senderAllow[from]; !ok {
+			return fmt.Errorf("%w: %s not allowed", ErrPolicySender, from.Hex())
+		}
+	}
+	if _, ok := p.senderDeny[from]; ok {
+		return fmt.Errorf("%w: %s denied", ErrPolicySender, from.Hex())
+	}
+	if to := tx.To(); to == nil {
+		if p.NoContractCreation {
+			return fmt.Errorf("%w: disabled", ErrPolicyContractCreation)
+		}
+		if p.creatorAllow != nil {
+			if _, ok := p.creatorAllow[from]; !ok {
+				return fmt.Errorf("%w: %s not allowed to deploy", ErrPolicyContractCreation, from.Hex())
+			}
+		}
+	} else {
+		if p.recipientAllow != nil {
+			if _, ok := p.recipientAllow[*to]; !ok {
+				return fmt.Errorf("%w: %s not allowed", ErrPolicyRecipient, to.Hex())
+			}
+		}
+		if _, ok := p.recipientDeny[*to]; ok {
+			return fmt.Errorf("%w: %s denied", ErrPolicyRecipient, to.Hex())
+		}
+	}
+	if p.MaxGas != 0 && tx.Gas() > p.MaxGas {
+		return fmt.Errorf("%w: have %d, max %d", ErrPolicyGasLimit, tx.Gas(), p.MaxGas)
+	}
+	if min := p.MinGasPrices[from]; min != nil {
+		if tip := tx.EffectiveGasTipValue(baseFee); tip.Cmp((*big.Int)(min)) < 0 {
+			return fmt.Errorf("%w: have %v, min %v", ErrPolicyGasPrice, tip, (*big.Int)(min))
+		}
+	}
+	return nil
+}
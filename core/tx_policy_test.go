@@ -0,0 +1,185 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tests that admission policies can be loaded from both JSON and TOML files.
+func TestTxPolicyLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "txpolicy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"policy.json": `{
+			"senderDeny": ["0x0000000000000000000000000000000000000001"],
+			"noContractCreation": true,
+			"maxGas": 100000,
+			"minGasPrices": {"0x0000000000000000000000000000000000000002": "0x10"}
+		}`,
+		"policy.toml": `
+SenderDeny = ["0x0000000000000000000000000000000000000001"]
+NoContractCreation = true
+MaxGas = 100000
+
+[MinGasPrices]
+0x0000000000000000000000000000000000000002 = "16"
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		policy, err := LoadTxPolicy(path)
+		if err != nil {
+			t.Fatalf("%s: failed to load policy: %v", name, err)
+		}
+		if len(policy.SenderDeny) != 1 || policy.SenderDeny[0] != common.HexToAddress("0x01") {
+			t.Errorf("%s: sender deny list mismatch: %v", name, policy.SenderDeny)
+		}
+		if !policy.NoContractCreation {
+			t.Errorf("%s: contract creation not disabled", name)
+		}
+		if policy.MaxGas != 100000 {
+			t.Errorf("%s: max gas mismatch: have %d, want %d", name, policy.MaxGas, 100000)
+		}
+		if min := policy.MinGasPrices[common.HexToAddress("0x02")]; min == nil || (*big.Int)(min).Int64() != 16 {
+			t.Errorf("%s: minimum gas price mismatch: have %v, want %d", name, min, 16)
+		}
+	}
+}
+
+// Tests that the individual policy rules reject the expected transactions.
+func TestTxPolicyCheck(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		from    = crypto.PubkeyToAddress(key.PublicKey)
+		allowed = common.HexToAddress("0xa11ce")
+		denied  = common.HexToAddress("0xdead")
+	)
+	call := func(to common.Address, gas uint64, price int64) *types.Transaction {
+		return types.NewTransaction(0, to, big.NewInt(0), gas, big.NewInt(price), nil)
+	}
+	create := types.NewContractCreation(0, big.NewInt(0), 100000, big.NewInt(1), nil)
+
+	tests := []struct {
+		policy TxPolicy
+		tx     *types.Transaction
+		err    error
+	}{
+		{TxPolicy{}, call(denied, 21000, 1), nil},
+		{TxPolicy{SenderAllow: []common.Address{allowed}}, call(allowed, 21000, 1), ErrPolicySender},
+		{TxPolicy{SenderAllow: []common.Address{from}}, call(allowed, 21000, 1), nil},
+		{TxPolicy{SenderDeny: []common.Address{from}}, call(allowed, 21000, 1), ErrPolicySender},
+		{TxPolicy{RecipientAllow: []common.Address{allowed}}, call(denied, 21000, 1), ErrPolicyRecipient},
+		{TxPolicy{RecipientAllow: []common.Address{allowed}}, call(allowed, 21000, 1), nil},
+		{TxPolicy{RecipientDeny: []common.Address{denied}}, call(denied, 21000, 1), ErrPolicyRecipient},
+		{TxPolicy{RecipientAllow: []common.Address{allowed}}, create, nil},
+		{TxPolicy{NoContractCreation: true}, create, ErrPolicyContractCreation},
+		{TxPolicy{CreatorAllow: []common.Address{allowed}}, create, ErrPolicyContractCreation},
+		{TxPolicy{CreatorAllow: []common.Address{from}}, create, nil},
+		{TxPolicy{MaxGas: 50000}, call(allowed, 50001, 1), ErrPolicyGasLimit},
+		{TxPolicy{MaxGas: 50000}, call(allowed, 50000, 1), nil},
+		{TxPolicy{MinGasPrices: map[common.Address]*math.HexOrDecimal256{from: math.NewHexOrDecimal256(10)}}, call(allowed, 21000, 9), ErrPolicyGasPrice},
+		{TxPolicy{MinGasPrices: map[common.Address]*math.HexOrDecimal256{from: math.NewHexOrDecimal256(10)}}, call(allowed, 21000, 10), nil},
+		{TxPolicy{MinGasPrices: map[common.Address]*math.HexOrDecimal256{allowed: math.NewHexOrDecimal256(10)}}, call(allowed, 21000, 1), nil},
+	}
+	for i, tt := range tests {
+		tt.policy.init()
+		if err := tt.policy.check(from, tt.tx, nil); !errors.Is(err, tt.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+	// Past EIP-1559 the minimum gas prices apply to the tip above the base fee
+	var (
+		baseFee = big.NewInt(100)
+		policy  = TxPolicy{MinGasPrices: map[common.Address]*math.HexOrDecimal256{from: math.NewHexOrDecimal256(10)}}
+	)
+	dynamic := func(feeCap, tip int64) *types.Transaction {
+		return types.NewTx(&types.DynamicFeeTx{To: &allowed, Gas: 21000, GasFeeCap: big.NewInt(feeCap), GasTipCap: big.NewInt(tip)})
+	}
+	policy.init()
+	for i, tt := range []struct {
+		tx  *types.Transaction
+		err error
+	}{
+		{dynamic(1000, 9), ErrPolicyGasPrice},
+		{dynamic(1000, 10), nil},
+		{dynamic(105, 10), ErrPolicyGasPrice},
+		{call(allowed, 21000, 105), ErrPolicyGasPrice},
+		{call(allowed, 21000, 110), nil},
+	} {
+		if err := policy.check(from, tt.tx, baseFee); !errors.Is(err, tt.err) {
+			t.Errorf("dynamic fee test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+// Tests that the pool enforces its admission policy both for local and remote
+// transactions, and that updating the policy evicts no longer admissible ones.
+func TestTxPoolPolicy(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.AddBalance(from, big.NewInt(1000000000))
+
+	pool.SetPolicy(&TxPolicy{MaxGas: 50000})
+	if err := pool.AddLocal(transaction(0, 60000, key)); !errors.Is(err, ErrPolicyGasLimit) {
+		t.Fatalf("local transaction error mismatch: have %v, want %v", err, ErrPolicyGasLimit)
+	}
+	if err := pool.AddRemote(transaction(0, 60000, key)); !errors.Is(err, ErrPolicyGasLimit) {
+		t.Fatalf("remote transaction error mismatch: have %v, want %v", err, ErrPolicyGasLimit)
+	}
+	if err := pool.addRemoteSync(transaction(0, 30000, key)); err != nil {
+		t.Fatalf("failed to add admissible transaction: %v", err)
+	}
+	if err := pool.addRemoteSync(transaction(1, 30000, key)); err != nil {
+		t.Fatalf("failed to add admissible transaction: %v", err)
+	}
+	if pending, _ := pool.Stats(); pending != 2 {
+		t.Fatalf("pending transactions mismatch: have %d, want %d", pending, 2)
+	}
+	pool.SetPolicy(&TxPolicy{SenderDeny: []common.Address{from}})
+	if pending, queued := pool.Stats(); pending != 0 || queued != 0 {
+		t.Fatalf("pool not purged: pending %d, queued %d", pending, queued)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	pool.SetPolicy(nil)
+	if err := pool.addRemoteSync(transaction(0, 30000, key)); err != nil {
+		t.Fatalf("failed to add transaction without policy: %v", err)
+	}
+}
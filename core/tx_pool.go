@@ -26,6 +26,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/prque"
+	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/event"
@@ -148,6 +149,8 @@ type TxPoolConfig struct {
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	Policy string // Admission policy file (TOML or JSON) to restrict accepted transactions
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -218,12 +221,15 @@ type TxPool struct {
 	chainconfig *params.ChainConfig
 	chain       blockChain
 	gasPrice    *big.Int
+	policy      *TxPolicy
 	txFeed      event.Feed
 	scope       event.SubscriptionScope
 	signer      types.Signer
 	mu          sync.RWMutex
 
 	istanbul bool // Fork indicator whether we are in the istanbul stage.
+	eip2718  bool // Fork indicator whether we are using EIP-2718 type transactions.
+	eip1559  bool // Fork indicator whether we are using EIP-1559 type transactions.
 
 	currentState  *state.StateDB // Current state in the blockchain head
 	pendingNonces *txNoncer      // Pending state tracking virtual nonces
@@ -263,7 +269,7 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		config:          config,
 		chainconfig:     chainconfig,
 		chain:           chain,
-		signer:          types.NewEIP155Signer(chainconfig.ChainID),
+		signer:          types.LatestSigner(chainconfig),
 		pending:         make(map[common.Address]*txList),
 		queue:           make(map[common.Address]*txList),
 		beats:           make(map[common.Address]time.Time),
@@ -427,6 +433,61 @@ func (pool *TxPool) SetGasPrice(price *big.Int) {
 	log.Info("Transaction pool price threshold updated", "price", price)
 }
 
+// Policy returns the admission policy currently enforced by the transaction
+// pool, or nil if none is configured.
+func (pool *TxPool) Policy() *TxPolicy {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.policy
+}
+
+// SetPolicy replaces the admission policy enforced by the transaction pool and
+// drops all pooled transactions that are no longer admissible. A nil policy
+// disables the admission rules altogether.
+func (pool *TxPool) SetPolicy(policy *TxPolicy) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if policy != nil {
+		policy.init()
+	}
+	pool.policy = policy
+	if policy == nil {
+		log.Info("Transaction pool policy disabled")
+		return
+	}
+	var drop []*types.Transaction
+	for _, lists := range []map[common.Address]*txList{pool.pending, pool.queue} {
+		for addr, list := range lists {
+			for _, tx := range list.Flatten() {
+				if err := policy.check(addr, tx, pool.priced.items.baseFee); err != nil {
+					drop = append(drop, tx)
+				}
+			}
+		}
+	}
+	for _, tx := range drop {
+		log.Trace("Removing transaction rejected by policy", "hash", tx.Hash())
+		pool.removeTx(tx.Hash(), true)
+	}
+	log.Info("Transaction pool policy updated", "dropped", len(drop))
+}
+
+// ReloadPolicy re-reads the admission policy from the file configured for the
+// transaction pool and applies it.
+func (pool *TxPool) ReloadPolicy() error {
+	if pool.config.Policy == "" {
+		return errors.New("no transaction pool policy file configured")
+	}
+	policy, err := LoadTxPolicy(pool.config.Policy)
+	if err != nil {
+		return err
+	}
+	pool.SetPolicy(policy)
+	return nil
+}
+
 // Nonce returns the next nonce of an account, with all transactions executable
 // by the pool already applied on top.
 func (pool *TxPool) Nonce(addr common.Address) uint64 {
@@ -517,6 +578,14 @@ func (pool *TxPool) local() map[common.Address]types.Transactions {
 // validateTx checks whether a transaction is valid according to the consensus
 // rules and adheres to some heuristic limits of the local node (price and size).
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
+	// Reject typed transactions until EIP-2718 activates
+	if !pool.eip2718 && tx.Type() != types.LegacyTxType {
+		return ErrTxTypeNotSupported
+	}
+	// Reject dynamic fee transactions until EIP-1559 activates
+	if !pool.eip1559 && tx.Type() == types.DynamicFeeTxType {
+		return ErrTxTypeNotSupported
+	}
 	// Reject transactions over defined size to prevent DOS attacks
 	if uint64(tx.Size()) > txMaxSize {
 		return ErrOversizedData
@@ -530,14 +599,31 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if pool.currentMaxGas < tx.Gas() {
 		return ErrGasLimit
 	}
+	// Sanity check for extremely large numbers
+	if tx.GasFeeCap().BitLen() > 256 {
+		return ErrFeeCapVeryHigh
+	}
+	if tx.GasTipCap().BitLen() > 256 {
+		return ErrTipVeryHigh
+	}
+	// Ensure gasFeeCap is greater than or equal to gasTipCap.
+	if tx.GasFeeCapIntCmp(tx.GasTipCap()) < 0 {
+		return ErrTipAboveFeeCap
+	}
 	// Make sure the transaction is signed properly
 	from, err := types.Sender(pool.signer, tx)
 	if err != nil {
 		return ErrInvalidSender
 	}
-	// Drop non-local transactions under our own minimal accepted gas price
+	// Enforce the operator's admission policy, if any, irrespective of origin
+	if pool.policy != nil {
+		if err := pool.policy.check(from, tx, pool.priced.items.baseFee); err != nil {
+			return err
+		}
+	}
+	// Drop non-local transactions under our own minimal accepted gas price or tip
 	local = local || pool.locals.contains(from) // account may be local even if the transaction arrived from the network
-	if !local && tx.GasPriceIntCmp(pool.gasPrice) < 0 {
+	if !local && tx.GasTipCapIntCmp(pool.gasPrice) < 0 {
 		return ErrUnderpriced
 	}
 	// Ensure the transaction adheres to nonce ordering
@@ -545,12 +631,12 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 		return ErrNonceTooLow
 	}
 	// Transactor should have enough funds to cover the costs
-	// cost == V + GP * GL
+	// cost == V + GP * GL (the fee cap stands in for GP of dynamic fee transactions)
 	if pool.currentState.GetBalance(from).Cmp(tx.Cost()) < 0 {
 		return ErrInsufficientFunds
 	}
 	// Ensure the transaction has more gas than the basic tx fee.
-	intrGas, err := IntrinsicGas(tx.Data(), tx.To() == nil, true, pool.istanbul)
+	intrGas, err := IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil, true, pool.istanbul)
 	if err != nil {
 		return err
 	}
@@ -1180,6 +1266,13 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	// Update all fork indicator by next pending block number.
 	next := new(big.Int).Add(newHead.Number, big.NewInt(1))
 	pool.istanbul = pool.chainconfig.IsIstanbul(next)
+	pool.eip2718 = pool.chainconfig.IsEIP2718(next)
+	pool.eip1559 = pool.chainconfig.IsEIP1559(next)
+
+	// Order the pooled transactions by the tip they would pay in the next block
+	if pool.eip1559 {
+		pool.priced.SetBaseFee(misc.CalcBaseFee(pool.chainconfig, newHead))
+	}
 }
 
 // promoteExecutables moves transactions that have become processable from the
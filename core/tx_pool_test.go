@@ -269,6 +269,101 @@ func TestInvalidTransactions(t *testing.T) {
 	}
 }
 
+// Tests that typed transactions are only accepted after EIP-2718 activates, and
+// that their access list is charged as intrinsic gas.
+func TestTransactionTypeFork(t *testing.T) {
+	t.Parallel()
+
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+
+	newTx := func(gas uint64) *types.Transaction {
+		tx, _ := types.SignTx(types.NewTx(&types.AccessListTx{
+			ChainID:    params.TestChainConfig.ChainID,
+			Gas:        gas,
+			GasPrice:   big.NewInt(1),
+			To:         &common.Address{},
+			Value:      big.NewInt(100),
+			AccessList: types.AccessList{{Address: common.Address{0x01}, StorageKeys: []common.Hash{{}}}},
+		}), types.NewEIP2930Signer(params.TestChainConfig.ChainID), key)
+		return tx
+	}
+	newPool := func(config *params.ChainConfig) *TxPool {
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		statedb.AddBalance(from, big.NewInt(1000000))
+		blockchain := &testBlockChain{statedb, 10000000, new(event.Feed)}
+		return NewTxPool(testTxPoolConfig, config, blockchain)
+	}
+	// Typed transactions are rejected before the fork
+	config := *params.TestChainConfig
+	config.EIP2718Block = big.NewInt(100)
+	config.EIP2930Block = big.NewInt(100)
+
+	pool := newPool(&config)
+	defer pool.Stop()
+	if err := pool.AddRemote(newTx(100000)); !errors.Is(err, ErrTxTypeNotSupported) {
+		t.Errorf("pre-fork error mismatch: have %v, want %v", err, ErrTxTypeNotSupported)
+	}
+	// And accepted afterwards, provided they pay for their access list
+	pool = newPool(params.TestChainConfig)
+	defer pool.Stop()
+
+	intrinsic := params.TxGas + params.TxAccessListAddressGas + params.TxAccessListStorageKeyGas
+	if err := pool.AddRemote(newTx(intrinsic - 1)); !errors.Is(err, ErrIntrinsicGas) {
+		t.Errorf("intrinsic gas error mismatch: have %v, want %v", err, ErrIntrinsicGas)
+	}
+	if err := pool.AddRemote(newTx(intrinsic)); err != nil {
+		t.Errorf("failed to add typed transaction: %v", err)
+	}
+}
+
+// Tests that dynamic fee transactions are only accepted after EIP-1559 activates,
+// and that their fee caps are sanity checked.
+func TestTransactionDynamicFeeFork(t *testing.T) {
+	t.Parallel()
+
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+
+	newTx := func(config *params.ChainConfig, tip, feeCap int64) *types.Transaction {
+		tx, _ := types.SignTx(types.NewTx(&types.DynamicFeeTx{
+			ChainID:   config.ChainID,
+			Gas:       params.TxGas,
+			GasTipCap: big.NewInt(tip),
+			GasFeeCap: big.NewInt(feeCap),
+			To:        &common.Address{},
+			Value:     big.NewInt(100),
+		}), types.LatestSigner(config), key)
+		return tx
+	}
+	newPool := func(config *params.ChainConfig) *TxPool {
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		statedb.AddBalance(from, big.NewInt(params.Ether))
+		blockchain := &testBlockChain{statedb, 10000000, new(event.Feed)}
+		return NewTxPool(testTxPoolConfig, config, blockchain)
+	}
+	// Dynamic fee transactions are rejected before the fork
+	config := *params.TestChainConfig
+	config.EIP1559Block = big.NewInt(100)
+
+	pool := newPool(&config)
+	defer pool.Stop()
+	if err := pool.AddRemote(newTx(&config, 1, 2*params.GWei)); !errors.Is(err, ErrTxTypeNotSupported) {
+		t.Errorf("pre-fork error mismatch: have %v, want %v", err, ErrTxTypeNotSupported)
+	}
+	// And accepted afterwards, provided the tip doesn't exceed the fee cap
+	config.EIP1559Block = big.NewInt(1)
+
+	pool = newPool(&config)
+	defer pool.Stop()
+	if err := pool.AddRemote(newTx(&config, 2*params.GWei, params.GWei)); !errors.Is(err, ErrTipAboveFeeCap) {
+		t.Errorf("tip above fee cap error mismatch: have %v, want %v", err, ErrTipAboveFeeCap)
+	}
+	if err := pool.AddRemote(newTx(&config, 1, 2*params.GWei)); err != nil {
+		t.Errorf("failed to add dynamic fee transaction: %v", err)
+	}
+}
+
 func TestTransactionQueue(t *testing.T) {
 	t.Parallel()
 
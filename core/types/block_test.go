@@ -69,6 +69,45 @@ func TestBlockEncoding(t *testing.T) {
 	}
 }
 
+func TestEIP1559HeaderEncoding(t *testing.T) {
+	legacy := &Header{
+		Difficulty: big.NewInt(131072),
+		Number:     big.NewInt(1),
+		GasLimit:   3141592,
+		Extra:      []byte("test"),
+	}
+	legacyEnc, err := rlp.EncodeToBytes(legacy)
+	if err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	header := CopyHeader(legacy)
+	header.BaseFee = new(big.Int).SetUint64(params.InitialBaseFee)
+	enc, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	if bytes.Equal(enc, legacyEnc) {
+		t.Fatal("base fee not included in the header encoding")
+	}
+	if legacy.Hash() == header.Hash() {
+		t.Fatal("base fee not included in the header hash")
+	}
+	// Legacy headers must decode without a base fee, fee market ones with it
+	var dec Header
+	if err := rlp.DecodeBytes(legacyEnc, &dec); err != nil {
+		t.Fatal("decode error: ", err)
+	}
+	if dec.BaseFee != nil || dec.Hash() != legacy.Hash() {
+		t.Errorf("legacy header mismatch: have base fee %v, hash %x, want hash %x", dec.BaseFee, dec.Hash(), legacy.Hash())
+	}
+	if err := rlp.DecodeBytes(enc, &dec); err != nil {
+		t.Fatal("decode error: ", err)
+	}
+	if dec.BaseFee == nil || dec.BaseFee.Cmp(header.BaseFee) != 0 || dec.Hash() != header.Hash() {
+		t.Errorf("header mismatch: have base fee %v, hash %x, want %v, %x", dec.BaseFee, dec.Hash(), header.BaseFee, header.Hash())
+	}
+}
+
 func TestUncleHash(t *testing.T) {
 	uncles := make([]*Header, 0)
 	h := CalcUncleHash(uncles)
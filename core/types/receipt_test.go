@@ -154,9 +154,18 @@ func encodeAsV3StoredReceiptRLP(want *Receipt) ([]byte, error) {
 // Tests that receipt data can be correctly derived from the contextual infos
 func TestDeriveFields(t *testing.T) {
 	// Create a few transactions to have receipts for
+	to3 := common.HexToAddress("0x3")
 	txs := Transactions{
 		NewContractCreation(1, big.NewInt(1), 1, big.NewInt(1), nil),
 		NewTransaction(2, common.HexToAddress("0x2"), big.NewInt(2), 2, big.NewInt(2), nil),
+		NewTx(&AccessListTx{
+			ChainID:  params.TestChainConfig.ChainID,
+			Nonce:    3,
+			To:       &to3,
+			Value:    big.NewInt(3),
+			Gas:      3,
+			GasPrice: big.NewInt(3),
+		}),
 	}
 	// Create the corresponding receipts
 	receipts := Receipts{
@@ -182,6 +191,18 @@ func TestDeriveFields(t *testing.T) {
 			ContractAddress: common.BytesToAddress([]byte{0x02, 0x22, 0x22}),
 			GasUsed:         2,
 		},
+		&Receipt{
+			Type:              AccessListTxType,
+			PostState:         common.Hash{3}.Bytes(),
+			CumulativeGasUsed: 6,
+			Logs: []*Log{
+				{Address: common.BytesToAddress([]byte{0x33})},
+				{Address: common.BytesToAddress([]byte{0x03, 0x33})},
+			},
+			TxHash:          txs[2].Hash(),
+			ContractAddress: common.BytesToAddress([]byte{0x03, 0x33, 0x33}),
+			GasUsed:         3,
+		},
 	}
 	// Clear all the computed fields and re-derive them
 	number := big.NewInt(1)
@@ -196,6 +217,9 @@ func TestDeriveFields(t *testing.T) {
 
 	logIndex := uint(0)
 	for i := range receipts {
+		if receipts[i].Type != txs[i].Type() {
+			t.Errorf("receipts[%d].Type = %d, want %d", i, receipts[i].Type, txs[i].Type())
+		}
 		if receipts[i].TxHash != txs[i].Hash() {
 			t.Errorf("receipts[%d].TxHash = %s, want %s", i, receipts[i].TxHash.String(), txs[i].Hash().String())
 		}
@@ -243,6 +267,59 @@ func TestDeriveFields(t *testing.T) {
 	}
 }
 
+// Tests that receipts of typed transactions are wrapped into the EIP-2718
+// envelope, both in their RLP and in their consensus (trie) encodings.
+func TestTypedReceiptEncodingDecoding(t *testing.T) {
+	receipt := &Receipt{
+		Type:              AccessListTxType,
+		Status:            ReceiptStatusSuccessful,
+		CumulativeGasUsed: 1,
+		Logs: []*Log{
+			{
+				Address: common.BytesToAddress([]byte{0x11}),
+				Topics:  []common.Hash{common.HexToHash("dead"), common.HexToHash("beef")},
+				Data:    []byte{0x01, 0x00, 0xff},
+			},
+		},
+	}
+	receipt.Bloom = CreateBloom(Receipts{receipt})
+
+	payload, err := rlp.EncodeToBytes(&receiptRLP{receipt.statusEncoding(), receipt.CumulativeGasUsed, receipt.Bloom, receipt.Logs})
+	if err != nil {
+		t.Fatalf("failed to encode receipt payload: %v", err)
+	}
+	want := append([]byte{AccessListTxType}, payload...)
+
+	// The consensus encoding is the type byte followed by the legacy payload
+	if have := (Receipts{receipt}).GetRlp(0); !bytes.Equal(have, want) {
+		t.Errorf("consensus encoding mismatch:\nhave %x\nwant %x", have, want)
+	}
+	// The RLP encoding wraps the consensus encoding into a byte string
+	enc, err := rlp.EncodeToBytes(receipt)
+	if err != nil {
+		t.Fatalf("failed to encode receipt: %v", err)
+	}
+	wantEnc, _ := rlp.EncodeToBytes(want)
+	if !bytes.Equal(enc, wantEnc) {
+		t.Errorf("RLP encoding mismatch:\nhave %x\nwant %x", enc, wantEnc)
+	}
+	var dec Receipt
+	if err := rlp.DecodeBytes(enc, &dec); err != nil {
+		t.Fatalf("failed to decode receipt: %v", err)
+	}
+	if dec.Type != receipt.Type || dec.Status != receipt.Status || dec.CumulativeGasUsed != receipt.CumulativeGasUsed || dec.Bloom != receipt.Bloom {
+		t.Errorf("decoded receipt mismatch: have %+v, want %+v", dec, receipt)
+	}
+	if !reflect.DeepEqual(dec.Logs, receipt.Logs) {
+		t.Errorf("decoded logs mismatch: have %v, want %v", dec.Logs, receipt.Logs)
+	}
+	// Legacy receipts keep being encoded as plain lists
+	receipt.Type = LegacyTxType
+	if have := (Receipts{receipt}).GetRlp(0); !bytes.Equal(have, payload) {
+		t.Errorf("legacy consensus encoding mismatch:\nhave %x\nwant %x", have, payload)
+	}
+}
+
 func clearComputedFieldsOnReceipts(t *testing.T, receipts Receipts) {
 	t.Helper()
 
@@ -254,6 +331,7 @@ func clearComputedFieldsOnReceipts(t *testing.T, receipts Receipts) {
 func clearComputedFieldsOnReceipt(t *testing.T, receipt *Receipt) {
 	t.Helper()
 
+	receipt.Type = 0x0
 	receipt.TxHash = common.Hash{}
 	receipt.BlockHash = common.Hash{}
 	receipt.BlockNumber = big.NewInt(math.MaxUint32)
@@ -76,6 +76,99 @@ func TestEIP155ChainId(t *testing.T) {
 	}
 }
 
+func TestEIP2930Signing(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	signer := NewEIP2930Signer(big.NewInt(18))
+	txs := []*Transaction{
+		NewTransaction(0, addr, new(big.Int), 0, new(big.Int), nil),
+		NewTx(&AccessListTx{
+			ChainID:    big.NewInt(18),
+			Nonce:      1,
+			To:         &addr,
+			Value:      new(big.Int),
+			GasPrice:   new(big.Int),
+			AccessList: AccessList{{Address: addr, StorageKeys: []common.Hash{{0x01}}}},
+		}),
+	}
+	for i, tx := range txs {
+		tx, err := SignTx(tx, signer, key)
+		if err != nil {
+			t.Fatalf("tx %d: failed to sign: %v", i, err)
+		}
+		if !tx.Protected() {
+			t.Errorf("tx %d: expected tx to be protected", i)
+		}
+		from, err := Sender(signer, tx)
+		if err != nil {
+			t.Fatalf("tx %d: failed to recover sender: %v", i, err)
+		}
+		if from != addr {
+			t.Errorf("tx %d: exected from and address to be equal. Got %x want %x", i, from, addr)
+		}
+		// Legacy signers must reject typed transactions
+		if tx.Type() != LegacyTxType {
+			if _, err := Sender(NewEIP155Signer(big.NewInt(18)), tx); err != ErrTxTypeNotSupported {
+				t.Errorf("tx %d: EIP155 signer error mismatch: have %v, want %v", i, err, ErrTxTypeNotSupported)
+			}
+		}
+		// Signers of other chains must reject the transaction
+		if _, err := Sender(NewEIP2930Signer(big.NewInt(19)), tx); err != ErrInvalidChainId {
+			t.Errorf("tx %d: foreign chain error mismatch: have %v, want %v", i, err, ErrInvalidChainId)
+		}
+	}
+}
+
+func TestEIP1559Signing(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	signer := NewEIP1559Signer(big.NewInt(18))
+	txs := []*Transaction{
+		NewTransaction(0, addr, new(big.Int), 0, new(big.Int), nil),
+		NewTx(&AccessListTx{
+			ChainID:  big.NewInt(18),
+			Nonce:    1,
+			To:       &addr,
+			Value:    new(big.Int),
+			GasPrice: new(big.Int),
+		}),
+		NewTx(&DynamicFeeTx{
+			ChainID:    big.NewInt(18),
+			Nonce:      2,
+			To:         &addr,
+			Value:      new(big.Int),
+			GasTipCap:  big.NewInt(1),
+			GasFeeCap:  big.NewInt(2),
+			AccessList: AccessList{{Address: addr, StorageKeys: []common.Hash{{0x01}}}},
+		}),
+	}
+	for i, tx := range txs {
+		tx, err := SignTx(tx, signer, key)
+		if err != nil {
+			t.Fatalf("tx %d: failed to sign: %v", i, err)
+		}
+		from, err := Sender(signer, tx)
+		if err != nil {
+			t.Fatalf("tx %d: failed to recover sender: %v", i, err)
+		}
+		if from != addr {
+			t.Errorf("tx %d: exected from and address to be equal. Got %x want %x", i, from, addr)
+		}
+		// Pre-1559 signers must reject dynamic fee transactions
+		if tx.Type() == DynamicFeeTxType {
+			if _, err := Sender(NewEIP2930Signer(big.NewInt(18)), tx); err != ErrTxTypeNotSupported {
+				t.Errorf("tx %d: EIP2930 signer error mismatch: have %v, want %v", i, err, ErrTxTypeNotSupported)
+			}
+		}
+		// Signers of other chains must reject the transaction
+		if _, err := Sender(NewEIP1559Signer(big.NewInt(19)), tx); err != ErrInvalidChainId {
+			t.Errorf("tx %d: foreign chain error mismatch: have %v, want %v", i, err, ErrInvalidChainId)
+		}
+	}
+}
+
 func TestEIP155SigningVitalik(t *testing.T) {
 	// Test vectors come from http://vitalik.ca/files/eip155_testvec.txt
 	for i, test := range []struct {
@@ -21,6 +21,7 @@ import (
 	"crypto/ecdsa"
 	"encoding/json"
 	"math/big"
+	"math/rand"
 	"testing"
 	"time"
 
@@ -32,6 +33,8 @@ import (
 // The values in those tests are from the Transaction Tests
 // at github.com/ethereum/tests.
 var (
+	testAddr = common.HexToAddress("b94f5374fce5edbc8e2a8697c15331677e6ebf0b")
+
 	emptyTx = NewTransaction(
 		0,
 		common.HexToAddress("095e7baea6a6c7c4c2dfeb977efac326af552d87"),
@@ -50,6 +53,21 @@ var (
 		HomesteadSigner{},
 		common.Hex2Bytes("98ff921201554726367d2be8c804a7ff89ccf285ebc57dff8ae4c44b9c19ac4a8887321be575c8095f789dd4c743dfe42c1820f9231f98a962b210e3ac2452a301"),
 	)
+
+	emptyEip2718Tx = NewTx(&AccessListTx{
+		ChainID:  big.NewInt(1),
+		Nonce:    3,
+		To:       &testAddr,
+		Value:    big.NewInt(10),
+		Gas:      25000,
+		GasPrice: big.NewInt(1),
+		Data:     common.FromHex("5544"),
+	})
+
+	signedEip2718Tx, _ = emptyEip2718Tx.WithSignature(
+		NewEIP2930Signer(big.NewInt(1)),
+		common.Hex2Bytes("c9519f4f2b30335884581971573fadf60c6204f59a911df35ee8a540456b266032f1e8e2c5dd761f9e4f88f41c8310aeaba26a8bfcdacfedfa12ec3862d3752101"),
+	)
 )
 
 func TestTransactionSigHash(t *testing.T) {
@@ -73,6 +91,74 @@ func TestTransactionEncode(t *testing.T) {
 	}
 }
 
+func TestEIP2718TransactionSigHash(t *testing.T) {
+	s := NewEIP2930Signer(big.NewInt(1))
+	if s.Hash(emptyEip2718Tx) != common.HexToHash("49b486f0ec0a60dfbbca2d30cb07c9e8ffb2a2ff41f29a1ab6737475f6ff69f3") {
+		t.Errorf("empty EIP-2718 transaction hash mismatch, got %x", s.Hash(emptyEip2718Tx))
+	}
+	if s.Hash(signedEip2718Tx) != common.HexToHash("49b486f0ec0a60dfbbca2d30cb07c9e8ffb2a2ff41f29a1ab6737475f6ff69f3") {
+		t.Errorf("signed EIP-2718 transaction hash mismatch, got %x", s.Hash(signedEip2718Tx))
+	}
+}
+
+func TestEIP2718TransactionEncode(t *testing.T) {
+	// RLP representation
+	{
+		have, err := rlp.EncodeToBytes(signedEip2718Tx)
+		if err != nil {
+			t.Fatalf("encode error: %v", err)
+		}
+		want := common.FromHex("b86601f8630103018261a894b94f5374fce5edbc8e2a8697c15331677e6ebf0b0a825544c001a0c9519f4f2b30335884581971573fadf60c6204f59a911df35ee8a540456b2660a032f1e8e2c5dd761f9e4f88f41c8310aeaba26a8bfcdacfedfa12ec3862d37521")
+		if !bytes.Equal(have, want) {
+			t.Errorf("encoded RLP mismatch, got %x", have)
+		}
+	}
+	// Binary representation
+	{
+		have, err := signedEip2718Tx.MarshalBinary()
+		if err != nil {
+			t.Fatalf("encode error: %v", err)
+		}
+		want := common.FromHex("01f8630103018261a894b94f5374fce5edbc8e2a8697c15331677e6ebf0b0a825544c001a0c9519f4f2b30335884581971573fadf60c6204f59a911df35ee8a540456b2660a032f1e8e2c5dd761f9e4f88f41c8310aeaba26a8bfcdacfedfa12ec3862d37521")
+		if !bytes.Equal(have, want) {
+			t.Errorf("encoded binary mismatch, got %x", have)
+		}
+	}
+}
+
+// Tests that typed transactions survive both the RLP and the binary encodings,
+// and that malformed envelopes are rejected.
+func TestEIP2718TransactionDecode(t *testing.T) {
+	enc, err := rlp.EncodeToBytes(signedEip2718Tx)
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	tx, err := decodeTx(enc)
+	if err != nil {
+		t.Fatalf("RLP decode error: %v", err)
+	}
+	if tx.Type() != AccessListTxType || tx.Hash() != signedEip2718Tx.Hash() {
+		t.Errorf("RLP decoded transaction mismatch: type %d, hash %x", tx.Type(), tx.Hash())
+	}
+	bin, err := signedEip2718Tx.MarshalBinary()
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	tx = new(Transaction)
+	if err := tx.UnmarshalBinary(bin); err != nil {
+		t.Fatalf("binary decode error: %v", err)
+	}
+	if tx.Type() != AccessListTxType || tx.Hash() != signedEip2718Tx.Hash() {
+		t.Errorf("binary decoded transaction mismatch: type %d, hash %x", tx.Type(), tx.Hash())
+	}
+	if err := new(Transaction).UnmarshalBinary(nil); err != errEmptyTypedTx {
+		t.Errorf("empty envelope: have error %v, want %v", err, errEmptyTypedTx)
+	}
+	if err := new(Transaction).UnmarshalBinary(append([]byte{0x7f}, bin[1:]...)); err != ErrTxTypeNotSupported {
+		t.Errorf("unknown type: have error %v, want %v", err, ErrTxTypeNotSupported)
+	}
+}
+
 func decodeTx(data []byte) (*Transaction, error) {
 	var tx Transaction
 	t, err := &tx, rlp.Decode(bytes.NewReader(data), &tx)
@@ -140,7 +226,7 @@ func TestTransactionPriceNonceSort(t *testing.T) {
 		}
 	}
 	// Sort the transactions and cross check the nonce ordering
-	txset := NewTransactionsByPriceAndNonce(signer, groups)
+	txset := NewTransactionsByPriceAndNonce(signer, groups, nil)
 
 	txs := Transactions{}
 	for tx := txset.Peek(); tx != nil; tx = txset.Peek() {
@@ -171,6 +257,84 @@ func TestTransactionPriceNonceSort(t *testing.T) {
 	}
 }
 
+// Tests that with a base fee set, transactions are sorted by the effective tip
+// they pay the miner, and that accounts whose next transaction cannot cover the
+// base fee are skipped.
+func TestTransactionPriceNonceSortEIP1559(t *testing.T) {
+	keys := make([]*ecdsa.PrivateKey, 25)
+	for i := 0; i < len(keys); i++ {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	var (
+		signer  = NewEIP1559Signer(common.Big1)
+		baseFee = big.NewInt(10)
+		groups  = map[common.Address]Transactions{}
+		valid   int
+	)
+	for start, key := range keys {
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+		for i := 0; i < 25; i++ {
+			var tx *Transaction
+			gasFeeCap := big.NewInt(int64(start + i))
+			if i%2 == 0 {
+				tx = NewTransaction(uint64(start+i), common.Address{}, big.NewInt(100), 100, gasFeeCap, nil)
+			} else {
+				tx = NewTx(&DynamicFeeTx{
+					Nonce:     uint64(start + i),
+					To:        &common.Address{},
+					Value:     big.NewInt(100),
+					Gas:       100,
+					GasFeeCap: gasFeeCap,
+					GasTipCap: big.NewInt(int64(rand.Intn(start + i + 1))),
+				})
+			}
+			tx, _ = SignTx(tx, signer, key)
+			groups[addr] = append(groups[addr], tx)
+		}
+		// Transactions up to the first one unable to pay the base fee are included
+		for _, tx := range groups[addr] {
+			if tx.GasFeeCapIntCmp(baseFee) < 0 {
+				break
+			}
+			valid++
+		}
+	}
+	txset := NewTransactionsByPriceAndNonce(signer, groups, baseFee)
+
+	txs := Transactions{}
+	for tx := txset.Peek(); tx != nil; tx = txset.Peek() {
+		txs = append(txs, tx)
+		txset.Shift()
+	}
+	if len(txs) != valid {
+		t.Errorf("expected %d transactions, found %d", valid, len(txs))
+	}
+	for i, txi := range txs {
+		fromi, _ := Sender(signer, txi)
+
+		// Make sure the nonce order is valid
+		for j, txj := range txs[i+1:] {
+			fromj, _ := Sender(signer, txj)
+			if fromi == fromj && txi.Nonce() > txj.Nonce() {
+				t.Errorf("invalid nonce ordering: tx #%d (A=%x N=%v) < tx #%d (A=%x N=%v)", i, fromi[:4], txi.Nonce(), i+j, fromj[:4], txj.Nonce())
+			}
+		}
+		// If the next tx has different from account, the tip must be lower than the current one
+		if i+1 < len(txs) {
+			next := txs[i+1]
+			fromNext, _ := Sender(signer, next)
+			tip, err := txi.EffectiveGasTip(baseFee)
+			nextTip, nextErr := next.EffectiveGasTip(baseFee)
+			if err != nil || nextErr != nil {
+				t.Errorf("error calculating effective tip")
+			}
+			if fromi != fromNext && tip.Cmp(nextTip) < 0 {
+				t.Errorf("invalid tip ordering: tx #%d (A=%x T=%v) < tx #%d (A=%x T=%v)", i, fromi[:4], tip, i+1, fromNext[:4], nextTip)
+			}
+		}
+	}
+}
+
 // Tests that if multiple transactions have the same price, the ones seen earlier
 // are prioritized to avoid network spam attacks aiming for a specific ordering.
 func TestTransactionTimeSort(t *testing.T) {
@@ -192,7 +356,7 @@ func TestTransactionTimeSort(t *testing.T) {
 		groups[addr] = append(groups[addr], tx)
 	}
 	// Sort the transactions and cross check the nonce ordering
-	txset := NewTransactionsByPriceAndNonce(signer, groups)
+	txset := NewTransactionsByPriceAndNonce(signer, groups, nil)
 
 	txs := Transactions{}
 	for tx := txset.Peek(); tx != nil; tx = txset.Peek() {
@@ -225,16 +389,39 @@ func TestTransactionJSON(t *testing.T) {
 	if err != nil {
 		t.Fatalf("could not generate key: %v", err)
 	}
-	signer := NewEIP155Signer(common.Big1)
+	signer := NewEIP1559Signer(common.Big1)
 
 	transactions := make([]*Transaction, 0, 50)
 	for i := uint64(0); i < 25; i++ {
 		var tx *Transaction
-		switch i % 2 {
+		switch i % 4 {
 		case 0:
 			tx = NewTransaction(i, common.Address{1}, common.Big0, 1, common.Big2, []byte("abcdef"))
 		case 1:
 			tx = NewContractCreation(i, common.Big0, 1, common.Big2, []byte("abcdef"))
+		case 2:
+			tx = NewTx(&AccessListTx{
+				ChainID:  big.NewInt(1),
+				Nonce:    i,
+				Gas:      1,
+				GasPrice: common.Big2,
+				AccessList: AccessList{
+					{Address: common.Address{2}, StorageKeys: []common.Hash{EmptyRootHash}},
+				},
+				Data: []byte("abcdef"),
+			})
+		case 3:
+			tx = NewTx(&DynamicFeeTx{
+				ChainID:   big.NewInt(1),
+				Nonce:     i,
+				Gas:       1,
+				GasTipCap: common.Big1,
+				GasFeeCap: common.Big2,
+				AccessList: AccessList{
+					{Address: common.Address{2}, StorageKeys: []common.Hash{EmptyRootHash}},
+				},
+				Data: []byte("abcdef"),
+			})
 		}
 		transactions = append(transactions, tx)
 
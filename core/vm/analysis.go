@@ -16,6 +16,54 @@
 
 package vm
 
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// jumpdestCacheSize is the number of JUMPDEST analyses kept in the shared
+// cache. With the 24KB code size limit an analysis is at most ~3KB, bounding
+// the cache to roughly 12MB.
+const jumpdestCacheSize = 4096
+
+var (
+	jumpdestCacheHitMeter  = metrics.NewRegisteredMeter("vm/jumpdest/hit", nil)
+	jumpdestCacheMissMeter = metrics.NewRegisteredMeter("vm/jumpdest/miss", nil)
+
+	// jumpdestCache holds the JUMPDEST analyses of the deployed contracts,
+	// shared by all EVM instances.
+	jumpdestCache = newAnalysisCache(jumpdestCacheSize)
+)
+
+// analysisCache is a bounded, concurrency-safe cache of JUMPDEST analyses
+// keyed by code hash.
+type analysisCache struct {
+	cache *lru.Cache
+}
+
+// newAnalysisCache creates a cache holding up to size analyses.
+func newAnalysisCache(size int) *analysisCache {
+	cache, _ := lru.New(size)
+	return &analysisCache{cache: cache}
+}
+
+// analysis returns the JUMPDEST analysis of the code with the given hash,
+// analysing it on a cache miss. A nil cache analyses the code every time.
+func (c *analysisCache) analysis(hash common.Hash, code []byte) bitvec {
+	if c == nil {
+		return codeBitmap(code)
+	}
+	if bits, ok := c.cache.Get(hash); ok {
+		jumpdestCacheHitMeter.Mark(1)
+		return bits.(bitvec)
+	}
+	jumpdestCacheMissMeter.Mark(1)
+	bits := codeBitmap(code)
+	c.cache.Add(hash, bits)
+	return bits
+}
+
 // bitvec is a bit vector which maps bytes in a program.
 // An unset bit means the byte is an opcode, a set bit means
 // it's data (i.e. argument of PUSHxx).
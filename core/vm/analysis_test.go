@@ -17,9 +17,15 @@
 package vm
 
 import (
+	"bytes"
+	"math/big"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 func TestJumpDestAnalysis(t *testing.T) {
@@ -73,3 +79,68 @@ func BenchmarkJumpdestHashing_1200k(bench *testing.B) {
 	}
 	bench.StopTimer()
 }
+
+func TestJumpdestCache(t *testing.T) {
+	var (
+		cache = newAnalysisCache(2)
+		codes = [][]byte{
+			{byte(PUSH1), byte(JUMPDEST), byte(JUMPDEST)},
+			{byte(PUSH2), byte(JUMPDEST), byte(JUMPDEST), byte(JUMPDEST)},
+			{byte(JUMPDEST)},
+		}
+	)
+	for i := 0; i < 2; i++ {
+		for j, code := range codes {
+			bits := cache.analysis(crypto.Keccak256Hash(code), code)
+			if want := codeBitmap(code); !bytes.Equal(bits, want) {
+				t.Fatalf("round %d, code %d: analysis mismatch: have %x, want %x", i, j, bits, want)
+			}
+		}
+	}
+	if have := cache.cache.Len(); have != 2 {
+		t.Errorf("cache size mismatch: have %d, want 2", have)
+	}
+	// A nil cache should still do the analysis
+	var nilCache *analysisCache
+	if bits := nilCache.analysis(common.Hash{}, codes[0]); !bytes.Equal(bits, codeBitmap(codes[0])) {
+		t.Errorf("nil cache analysis mismatch: have %x", bits)
+	}
+}
+
+// BenchmarkJumpdestCache measures entering a large contract from a new EVM,
+// as done for every transaction, with and without the shared analysis cache.
+func BenchmarkJumpdestCache(b *testing.B) {
+	// Jump over ~24KB of PUSH32 instructions, to a JUMPDEST at the end
+	code := []byte{byte(PUSH2), 0, 0, byte(JUMP)}
+	for len(code) < 24000 {
+		code = append(code, append([]byte{byte(PUSH32)}, make([]byte, 32)...)...)
+	}
+	code[1], code[2] = byte(len(code)>>8), byte(len(code))
+	code = append(code, byte(JUMPDEST))
+
+	var (
+		address    = common.BytesToAddress([]byte("contract"))
+		statedb, _ = state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		vmctx      = BlockContext{
+			CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+			Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+			BlockNumber: big.NewInt(0),
+		}
+	)
+	statedb.SetCode(address, code)
+
+	bench := func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			env := NewEVM(vmctx, TxContext{}, statedb, params.AllEthashProtocolChanges, Config{})
+			if _, _, err := env.Call(AccountRef(common.Address{}), address, nil, 100000, new(big.Int)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	defer func(cache *analysisCache) { jumpdestCache = cache }(jumpdestCache)
+
+	jumpdestCache = nil
+	b.Run("uncached", bench)
+	jumpdestCache = newAnalysisCache(jumpdestCacheSize)
+	b.Run("cached", bench)
+}
@@ -20,7 +20,9 @@ import (
 	"crypto/sha256"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"math/big"
+	"reflect"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
@@ -101,6 +103,33 @@ var PrecompiledContractsYoloV2 = map[common.Address]PrecompiledContract{
 	common.BytesToAddress([]byte{18}): &bls12381MapG2{},
 }
 
+// PrecompiledContractsByName contains all the pre-compiled contracts that chain
+// configurations may schedule at arbitrary addresses, keyed by contract name.
+var PrecompiledContractsByName = map[string]PrecompiledContract{
+	"ecrecover":               &ecrecover{},
+	"sha256":                  &sha256hash{},
+	"ripemd160":               &ripemd160hash{},
+	"identity":                &dataCopy{},
+	"modexp":                  &bigModExp{eip2565: false},
+	"modexpEIP2565":           &bigModExp{eip2565: true},
+	"bn256AddByzantium":       &bn256AddByzantium{},
+	"bn256AddIstanbul":        &bn256AddIstanbul{},
+	"bn256ScalarMulByzantium": &bn256ScalarMulByzantium{},
+	"bn256ScalarMulIstanbul":  &bn256ScalarMulIstanbul{},
+	"bn256PairingByzantium":   &bn256PairingByzantium{},
+	"bn256PairingIstanbul":    &bn256PairingIstanbul{},
+	"blake2f":                 &blake2F{},
+	"bls12381G1Add":           &bls12381G1Add{},
+	"bls12381G1Mul":           &bls12381G1Mul{},
+	"bls12381G1MultiExp":      &bls12381G1MultiExp{},
+	"bls12381G2Add":           &bls12381G2Add{},
+	"bls12381G2Mul":           &bls12381G2Mul{},
+	"bls12381G2MultiExp":      &bls12381G2MultiExp{},
+	"bls12381Pairing":         &bls12381Pairing{},
+	"bls12381MapG1":           &bls12381MapG1{},
+	"bls12381MapG2":           &bls12381MapG2{},
+}
+
 var (
 	PrecompiledAddressesYoloV2    []common.Address
 	PrecompiledAddressesIstanbul  []common.Address
@@ -113,7 +142,7 @@ func init() {
 		PrecompiledAddressesHomestead = append(PrecompiledAddressesHomestead, k)
 	}
 	for k := range PrecompiledContractsByzantium {
-		PrecompiledAddressesHomestead = append(PrecompiledAddressesByzantium, k)
+		PrecompiledAddressesByzantium = append(PrecompiledAddressesByzantium, k)
 	}
 	for k := range PrecompiledContractsIstanbul {
 		PrecompiledAddressesIstanbul = append(PrecompiledAddressesIstanbul, k)
@@ -123,6 +152,29 @@ func init() {
 	}
 }
 
+// ActivePrecompiles returns the addresses of the precompiles enabled with the
+// given chain rules.
+func ActivePrecompiles(rules params.Rules) []common.Address {
+	if len(rules.Precompiles) > 0 {
+		precompiles := activePrecompiles(rules)
+		addresses := make([]common.Address, 0, len(precompiles))
+		for addr := range precompiles {
+			addresses = append(addresses, addr)
+		}
+		return addresses
+	}
+	switch {
+	case rules.IsYoloV2:
+		return PrecompiledAddressesYoloV2
+	case rules.IsIstanbul:
+		return PrecompiledAddressesIstanbul
+	case rules.IsByzantium:
+		return PrecompiledAddressesByzantium
+	default:
+		return PrecompiledAddressesHomestead
+	}
+}
+
 // RunPrecompiledContract runs and evaluates the output of a precompiled contract.
 // It returns
 // - the returned bytes,
@@ -138,6 +190,62 @@ func RunPrecompiledContract(p PrecompiledContract, input []byte, suppliedGas uin
 	return output, suppliedGas, err
 }
 
+// activePrecompiles returns the precompiled contracts enabled with the given
+// chain rules: the fork's default set, overridden by the precompiles scheduled
+// in the chain configuration.
+func activePrecompiles(rules params.Rules) map[common.Address]PrecompiledContract {
+	var precompiles map[common.Address]PrecompiledContract
+	switch {
+	case rules.IsYoloV2:
+		precompiles = PrecompiledContractsYoloV2
+	case rules.IsIstanbul:
+		precompiles = PrecompiledContractsIstanbul
+	case rules.IsByzantium:
+		precompiles = PrecompiledContractsByzantium
+	default:
+		precompiles = PrecompiledContractsHomestead
+	}
+	if len(rules.Precompiles) == 0 {
+		return precompiles
+	}
+	active := make(map[common.Address]PrecompiledContract, len(precompiles)+len(rules.Precompiles))
+	for addr, p := range precompiles {
+		active[addr] = p
+	}
+	for addr, name := range rules.Precompiles {
+		if p, ok := PrecompiledContractsByName[name]; ok {
+			active[addr] = p
+		}
+	}
+	return active
+}
+
+// ActivePrecompileNames returns the names of the precompiles enabled with the
+// given chain rules, keyed by address.
+func ActivePrecompileNames(rules params.Rules) map[common.Address]string {
+	names := make(map[common.Address]string)
+	for addr, p := range activePrecompiles(rules) {
+		for name, known := range PrecompiledContractsByName {
+			if reflect.DeepEqual(p, known) {
+				names[addr] = name
+				break
+			}
+		}
+	}
+	return names
+}
+
+// CheckPrecompiles returns an error if the chain configuration schedules a
+// precompiled contract unknown to the EVM.
+func CheckPrecompiles(config *params.ChainConfig) error {
+	for _, p := range config.Precompiles {
+		if _, ok := PrecompiledContractsByName[p.Contract]; !ok {
+			return fmt.Errorf("unknown precompile %q at %x", p.Contract, p.Address)
+		}
+	}
+	return nil
+}
+
 // ECRECOVER implemented as a native contract.
 type ecrecover struct{}
 
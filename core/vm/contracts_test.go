@@ -21,10 +21,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"testing"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 // precompiledTest defines the input/output pairs for precompiled contract tests.
@@ -333,6 +335,50 @@ func TestPrecompiledBLS12381PairingFail(t *testing.T)    { testJsonFail("blsPair
 func TestPrecompiledBLS12381MapG1Fail(t *testing.T)      { testJsonFail("blsMapG1", "11", t) }
 func TestPrecompiledBLS12381MapG2Fail(t *testing.T)      { testJsonFail("blsMapG2", "12", t) }
 
+// Tests that the chain configuration can schedule precompiles on top of and
+// in place of the fork's default set.
+func TestConfigPrecompiles(t *testing.T) {
+	config := *params.TestChainConfig
+	config.Precompiles = []params.PrecompileActivation{
+		{Address: common.BytesToAddress([]byte{6}), Contract: "bn256AddByzantium", Block: big.NewInt(10)},
+		{Address: common.BytesToAddress([]byte{10}), Contract: "bls12381G1Add", Block: big.NewInt(10)},
+	}
+	for _, tt := range []struct {
+		number   int64
+		repriced string
+		bls      bool
+	}{
+		{9, "bn256AddIstanbul", false},
+		{10, "bn256AddByzantium", true},
+	} {
+		rules := config.Rules(big.NewInt(tt.number))
+		names := ActivePrecompileNames(rules)
+		if names[common.BytesToAddress([]byte{6})] != tt.repriced {
+			t.Errorf("block %d: precompile 0x06 mismatch: have %q, want %q", tt.number, names[common.BytesToAddress([]byte{6})], tt.repriced)
+		}
+		if names[common.BytesToAddress([]byte{9})] != "blake2f" {
+			t.Errorf("block %d: default precompile 0x09 missing", tt.number)
+		}
+		if len(ActivePrecompiles(rules)) != len(names) {
+			t.Errorf("block %d: address count mismatch: have %d, want %d", tt.number, len(ActivePrecompiles(rules)), len(names))
+		}
+		evm := NewEVM(BlockContext{BlockNumber: big.NewInt(tt.number)}, TxContext{}, nil, &config, Config{})
+		p, ok := evm.precompile(common.BytesToAddress([]byte{10}))
+		if ok != tt.bls {
+			t.Fatalf("block %d: bls precompile active mismatch: have %v, want %v", tt.number, ok, tt.bls)
+		}
+		if ok {
+			if _, isBLS := p.(*bls12381G1Add); !isBLS {
+				t.Errorf("block %d: wrong precompile at 0x0a: %T", tt.number, p)
+			}
+		}
+	}
+	config.Precompiles = append(config.Precompiles, params.PrecompileActivation{Address: common.Address{0xff}, Contract: "unknown", Block: big.NewInt(0)})
+	if err := CheckPrecompiles(&config); err == nil {
+		t.Error("unknown precompile accepted")
+	}
+}
+
 func loadJson(name string) ([]precompiledTest, error) {
 	data, err := ioutil.ReadFile(fmt.Sprintf("testdata/precompiles/%v.json", name))
 	if err != nil {
@@ -30,6 +30,17 @@ var activators = map[int]func(*JumpTable){
 	1884: enable1884,
 	1344: enable1344,
 	2315: enable2315,
+	3529: enable3529,
+	3855: enable3855,
+}
+
+// ruleActivators enable the parts of EIPs living outside of the jump table,
+// like the refund quotient or the validation of new contract code, when they
+// are activated as extra EIPs rather than through the chain config.
+var ruleActivators = map[int]func(*params.Rules){
+	3529: func(rules *params.Rules) { rules.IsEIP3529 = true },
+	3541: func(rules *params.Rules) { rules.IsEIP3541 = true },
+	3855: func(rules *params.Rules) { rules.IsEIP3855 = true },
 }
 
 // EnableEIP enables the given EIP on the config.
@@ -38,6 +49,9 @@ var activators = map[int]func(*JumpTable){
 func EnableEIP(eipNum int, jt *JumpTable) error {
 	enablerFn, ok := activators[eipNum]
 	if !ok {
+		if _, ok := ruleActivators[eipNum]; ok {
+			return nil // Nothing to change in the jump table
+		}
 		return fmt.Errorf("undefined eip %d", eipNum)
 	}
 	enablerFn(jt)
@@ -46,6 +60,9 @@ func EnableEIP(eipNum int, jt *JumpTable) error {
 
 func ValidEip(eipNum int) bool {
 	_, ok := activators[eipNum]
+	if !ok {
+		_, ok = ruleActivators[eipNum]
+	}
 	return ok
 }
 func ActivateableEips() []string {
@@ -53,6 +70,11 @@ func ActivateableEips() []string {
 	for k := range activators {
 		nums = append(nums, fmt.Sprintf("%d", k))
 	}
+	for k := range ruleActivators {
+		if _, ok := activators[k]; !ok {
+			nums = append(nums, fmt.Sprintf("%d", k))
+		}
+	}
 	sort.Strings(nums)
 	return nums
 }
@@ -173,3 +195,30 @@ func enable2929(jt *JumpTable) {
 	jt[SELFDESTRUCT].constantGas = params.SelfdestructGasEIP150
 	jt[SELFDESTRUCT].dynamicGas = gasSelfdestructEIP2929
 }
+
+// enable3529 enables "EIP-3529: Reduction in refunds":
+// - Removes refunds for selfdestructs
+// - Reduces refunds for SSTORE
+// - Reduces max refunds to 20% gas
+// The refund cap is applied in the state transition, via the chain rules.
+func enable3529(jt *JumpTable) {
+	jt[SSTORE].dynamicGas = gasSStoreEIP3529
+	jt[SELFDESTRUCT].dynamicGas = gasSelfdestructEIP3529
+}
+
+// enable3855 applies EIP-3855 (PUSH0 opcode)
+func enable3855(jt *JumpTable) {
+	// New opcode
+	jt[PUSH0] = &operation{
+		execute:     opPush0,
+		constantGas: GasQuickStep,
+		minStack:    minStack(0, 1),
+		maxStack:    maxStack(0, 1),
+	}
+}
+
+// opPush0 implements the PUSH0 opcode
+func opPush0(pc *uint64, interpreter *EVMInterpreter, callContext *callCtx) ([]byte, error) {
+	callContext.stack.push(new(uint256.Int))
+	return nil, nil
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/holiman/uint256"
 )
@@ -45,31 +46,11 @@ type (
 // ActivePrecompiles returns the addresses of the precompiles enabled with the current
 // configuration
 func (evm *EVM) ActivePrecompiles() []common.Address {
-	switch {
-	case evm.chainRules.IsYoloV2:
-		return PrecompiledAddressesYoloV2
-	case evm.chainRules.IsIstanbul:
-		return PrecompiledAddressesIstanbul
-	case evm.chainRules.IsByzantium:
-		return PrecompiledAddressesByzantium
-	default:
-		return PrecompiledAddressesHomestead
-	}
+	return ActivePrecompiles(evm.chainRules)
 }
 
 func (evm *EVM) precompile(addr common.Address) (PrecompiledContract, bool) {
-	var precompiles map[common.Address]PrecompiledContract
-	switch {
-	case evm.chainRules.IsYoloV2:
-		precompiles = PrecompiledContractsYoloV2
-	case evm.chainRules.IsIstanbul:
-		precompiles = PrecompiledContractsIstanbul
-	case evm.chainRules.IsByzantium:
-		precompiles = PrecompiledContractsByzantium
-	default:
-		precompiles = PrecompiledContractsHomestead
-	}
-	p, ok := precompiles[addr]
+	p, ok := evm.precompiles[addr]
 	return p, ok
 }
 
@@ -108,6 +89,7 @@ type BlockContext struct {
 	BlockNumber *big.Int       // Provides information for NUMBER
 	Time        *big.Int       // Provides information for TIME
 	Difficulty  *big.Int       // Provides information for DIFFICULTY
+	BaseFee     *big.Int       // EIP-1559 base fee of the block, nil before the fork
 }
 
 // TxContext provides the EVM with information about a transaction.
@@ -140,6 +122,8 @@ type EVM struct {
 	chainConfig *params.ChainConfig
 	// chain rules contains the chain rules for the current epoch
 	chainRules params.Rules
+	// precompiles contains the precompiled contracts enabled with the chain rules
+	precompiles map[common.Address]PrecompiledContract
 	// virtual machine configuration options used to initialise the
 	// evm.
 	vmConfig Config
@@ -168,6 +152,13 @@ func NewEVM(blockCtx BlockContext, txCtx TxContext, statedb StateDB, chainConfig
 		chainRules:   chainConfig.Rules(blockCtx.BlockNumber),
 		interpreters: make([]Interpreter, 0, 1),
 	}
+	// Extra EIPs may change rules outside of the jump table too
+	for _, eip := range vmConfig.ExtraEips {
+		if enablerFn, ok := ruleActivators[eip]; ok {
+			enablerFn(&evm.chainRules)
+		}
+	}
+	evm.precompiles = activePrecompiles(evm.chainRules)
 
 	if chainConfig.IsEWASM(blockCtx.BlockNumber) {
 		// to be implemented by EVM-C and Wagon PRs.
@@ -185,9 +176,19 @@ func NewEVM(blockCtx BlockContext, txCtx TxContext, statedb StateDB, chainConfig
 		panic("No supported ewasm interpreter yet.")
 	}
 
-	// vmConfig.EVMInterpreter will be used by EVM-C, it won't be checked here
-	// as we always want to have the built-in EVM as the failover option.
-	evm.interpreters = append(evm.interpreters, NewEVMInterpreter(evm, vmConfig))
+	// vmConfig.EVMInterpreter is loaded as an EVM-C VM, the built-in EVM is
+	// always kept as the failover option. Callers are expected to validate the
+	// configuration with LoadEVMC up front, falling back is an error.
+	native := NewEVMInterpreter(evm, vmConfig)
+	if vmConfig.EVMInterpreter != "" {
+		instance, err := LoadEVMC(vmConfig.EVMInterpreter)
+		if err != nil {
+			log.Error("EVMC VM unavailable, falling back to the built-in interpreter", "config", vmConfig.EVMInterpreter, "err", err)
+		} else {
+			evm.interpreters = append(evm.interpreters, NewEVMC(instance, evm, native, vmConfig))
+		}
+	}
+	evm.interpreters = append(evm.interpreters, native)
 	evm.interpreter = evm.interpreters[0]
 
 	return evm
@@ -478,6 +479,10 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 
 	ret, err := run(evm, contract, nil, false)
 
+	// Reject code starting with 0xEF if EIP-3541 is enabled.
+	if err == nil && len(ret) >= 1 && ret[0] == 0xEF && evm.chainRules.IsEIP3541 {
+		err = ErrInvalidCode
+	}
 	// check whether the max code size has been exceeded
 	maxCodeSizeExceeded := evm.chainRules.IsEIP158 && len(ret) > params.MaxCodeSize
 	// if the contract creation ran successfully and no errors were returned
@@ -531,3 +536,10 @@ func (evm *EVM) Create2(caller ContractRef, code []byte, gas uint64, endowment *
 
 // ChainConfig returns the environment's chain configuration
 func (evm *EVM) ChainConfig() *params.ChainConfig { return evm.chainConfig }
+
+// ChainRules returns the rules the environment executes with, including the
+// ones activated through the extra EIPs of the interpreter configuration.
+func (evm *EVM) ChainRules() params.Rules { return evm.chainRules }
+
+// Config returns the interpreter configuration of the environment.
+func (evm *EVM) Config() Config { return evm.vmConfig }
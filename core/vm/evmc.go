@@ -0,0 +1,509 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm/evmc"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// evmcInstance is the part of an EVMC VM used by the host.
+type evmcInstance interface {
+	Execute(ctx evmc.HostContext, rev evmc.Revision, kind evmc.CallKind, static bool, depth int, gas int64,
+		destination evmc.Address, sender evmc.Address, input []byte, value evmc.Hash, code []byte,
+		create2Salt evmc.Hash) (output []byte, gasLeft int64, err error)
+}
+
+var (
+	evmcLock      sync.Mutex
+	evmcInstances = make(map[string]*evmc.VM) // Loaded VMs, by configuration
+	evmcErrors    = make(map[string]error)    // Failed VM loads, by configuration
+)
+
+// LoadEVMC loads the external EVM described by config, the path of an EVMC
+// shared library followed by comma separated options (e.g. "libevmone.so,O=0").
+// VMs are loaded only once and shared by all EVMs using the same config.
+func LoadEVMC(config string) (*evmc.VM, error) {
+	evmcLock.Lock()
+	defer evmcLock.Unlock()
+
+	if instance, ok := evmcInstances[config]; ok {
+		return instance, nil
+	}
+	if err, ok := evmcErrors[config]; ok {
+		return nil, err
+	}
+	instance, err := evmc.LoadAndConfigure(config)
+	if err == nil && !instance.HasCapability(evmc.CapabilityEVM1) {
+		instance.Destroy()
+		err = fmt.Errorf("EVMC VM %s doesn't support EVM1", config)
+	}
+	if err != nil {
+		log.Error("Failed to load EVMC VM", "config", config, "err", err)
+		evmcErrors[config] = err
+		return nil, err
+	}
+	log.Info("Loaded EVMC VM", "name", instance.Name(), "version", instance.Version(), "config", config)
+	evmcInstances[config] = instance
+	return instance, nil
+}
+
+// EVMC is an Interpreter executing the EVM bytecode with an external VM loaded
+// through the EVMC interface. The state, nested calls and precompiles remain
+// with the host, so the gas accounting across calls is the one of geth.
+//
+// Code which the external VM can't execute faithfully, e.g. because the chain
+// rules don't match an EVMC revision or a tracer is in use, is left to the
+// built-in interpreter.
+type EVMC struct {
+	instance evmcInstance
+	env      *EVM
+	native   *EVMInterpreter // Built-in interpreter for the delegated executions
+
+	revision  evmc.Revision // Revision matching the chain rules
+	supported bool          // Whether the chain rules match a revision
+	readOnly  bool          // Whether to throw on stateful modifications
+
+	differential bool                  // Whether to cross-check executions with the built-in interpreter
+	comparing    bool                  // Whether a cross-checked external execution is running
+	reference    bool                  // Whether a cross-checked native execution is running
+	report       func(*evmcDivergence) // Callback for the divergences found
+}
+
+// NewEVMC returns an interpreter running code on the given external VM, with
+// native as the interpreter for the code it can't run.
+func NewEVMC(instance *evmc.VM, env *EVM, native *EVMInterpreter, cfg Config) *EVMC {
+	return newEVMC(instance, env, native, cfg)
+}
+
+func newEVMC(instance evmcInstance, env *EVM, native *EVMInterpreter, cfg Config) *EVMC {
+	revision, supported := evmcRevision(env.chainRules)
+	return &EVMC{
+		instance:     instance,
+		env:          env,
+		native:       native,
+		revision:     revision,
+		supported:    supported && !cfg.Debug && len(cfg.ExtraEips) == 0,
+		differential: cfg.EVMCDifferential,
+		report:       logEVMCDivergence,
+	}
+}
+
+// evmcRevision returns the EVMC revision matching the given chain rules, and
+// whether the rules are fully covered by it. Individually scheduled EIPs are
+// not part of any revision. YOLOv2 is run as Berlin, without subroutines.
+func evmcRevision(rules params.Rules) (evmc.Revision, bool) {
+	if rules.IsEIP3529 || rules.IsEIP3541 || rules.IsEIP3855 {
+		return 0, false
+	}
+	// Chains adopting EIP-160 without the rest of Spurious Dragon
+	if rules.IsEIP160 && !rules.IsEIP158 {
+		return 0, false
+	}
+	switch {
+	case rules.IsYoloV2:
+		return evmc.Berlin, true
+	case rules.IsIstanbul:
+		return evmc.Istanbul, true
+	case rules.IsPetersburg:
+		return evmc.Petersburg, true
+	case rules.IsConstantinople:
+		return evmc.Constantinople, true
+	case rules.IsByzantium:
+		return evmc.Byzantium, true
+	case rules.IsEIP158:
+		return evmc.SpuriousDragon, true
+	case rules.IsEIP150:
+		return evmc.TangerineWhistle, true
+	case rules.IsHomestead:
+		return evmc.Homestead, true
+	default:
+		return evmc.Frontier, true
+	}
+}
+
+// CanRun tells if the contract can be run by the external VM.
+func (evm *EVMC) CanRun(code []byte) bool {
+	return evm.supported
+}
+
+// Run executes the contract with the external VM, unless it has to be
+// delegated to the built-in interpreter.
+func (evm *EVMC) Run(contract *Contract, input []byte, readOnly bool) ([]byte, error) {
+	if evm.reference || contract.Gas > math.MaxInt64 {
+		return evm.native.Run(contract, input, readOnly)
+	}
+	if evm.differential && !evm.comparing {
+		return evm.runDifferential(contract, input, readOnly)
+	}
+	return evm.execute(contract, input, readOnly)
+}
+
+// execute runs the contract with the external VM.
+func (evm *EVMC) execute(contract *Contract, input []byte, readOnly bool) ([]byte, error) {
+	// Increment the call depth which is restricted to 1024
+	evm.env.depth++
+	defer func() { evm.env.depth-- }()
+
+	// Make sure the readOnly is only set if we aren't in readOnly yet.
+	// This makes also sure that the readOnly flag isn't removed for child calls.
+	if readOnly && !evm.readOnly {
+		evm.readOnly = true
+		defer func() { evm.readOnly = false }()
+	}
+	// Don't bother with the execution if there's no code.
+	if len(contract.Code) == 0 {
+		return nil, nil
+	}
+	// The contract doesn't tell whether it's the init code of a new account,
+	// but only accounts being created run code without having any.
+	kind := evmc.Call
+	if evm.env.StateDB.GetCodeSize(contract.Address()) == 0 {
+		kind = evmc.Create
+	}
+	output, gasLeft, err := evm.instance.Execute(&evmcHost{env: evm.env, contract: contract}, evm.revision, kind,
+		evm.readOnly, evm.env.depth-1, int64(contract.Gas), evmc.Address(contract.Address()),
+		evmc.Address(contract.Caller()), input, evmc.Hash(bigToHash(contract.Value())), contract.Code, evmc.Hash{})
+
+	if gasLeft < 0 {
+		gasLeft = 0
+	}
+	contract.Gas = uint64(gasLeft)
+
+	if err == nil {
+		return output, nil
+	}
+	evmcErr, ok := err.(evmc.Error)
+	if !ok || evmcErr.IsInternalError() {
+		panic(fmt.Sprintf("EVMC VM internal error: %v", err))
+	}
+	switch evmcErr {
+	case evmc.Revert:
+		return output, ErrExecutionReverted
+	case evmc.OutOfGas:
+		return nil, ErrOutOfGas
+	case evmc.CallDepthExceeded:
+		return nil, ErrDepth
+	case evmc.StaticModeViolation:
+		return nil, ErrWriteProtection
+	case evmc.BadJumpDestination:
+		return nil, ErrInvalidJump
+	default:
+		return nil, fmt.Errorf("evmc: %v", evmcErr)
+	}
+}
+
+// evmcResult is the outcome of a cross-checked execution.
+type evmcResult struct {
+	output []byte
+	gas    uint64 // Gas left after the execution
+	refund uint64 // Refund counter after the execution
+	err    error
+}
+
+// evmcDivergence describes an execution in which the external VM and the
+// built-in interpreter disagree.
+type evmcDivergence struct {
+	address  common.Address
+	input    []byte
+	external evmcResult
+	native   evmcResult
+}
+
+func logEVMCDivergence(d *evmcDivergence) {
+	log.Error("EVMC divergence", "address", d.address, "input", common.Bytes2Hex(d.input),
+		"err", d.external.err, "nativeErr", d.native.err, "gas", d.external.gas, "nativeGas", d.native.gas,
+		"refund", d.external.refund, "nativeRefund", d.native.refund,
+		"output", common.Bytes2Hex(d.external.output), "nativeOutput", common.Bytes2Hex(d.native.output))
+}
+
+// runDifferential executes the contract with both the external VM and the
+// built-in interpreter, reporting any difference between the two. The state
+// changes of the external execution are reverted, the result of the built-in
+// interpreter is kept.
+func (evm *EVMC) runDifferential(contract *Contract, input []byte, readOnly bool) ([]byte, error) {
+	snapshot := evm.env.StateDB.Snapshot()
+
+	external := *contract
+	evm.comparing = true
+	ret, err := evm.execute(&external, input, readOnly)
+	evm.comparing = false
+	externalRes := evmcResult{output: ret, gas: external.Gas, refund: evm.env.StateDB.GetRefund(), err: err}
+
+	evm.env.StateDB.RevertToSnapshot(snapshot)
+
+	evm.reference = true
+	ret, err = evm.native.Run(contract, input, readOnly)
+	evm.reference = false
+	nativeRes := evmcResult{output: ret, gas: contract.Gas, refund: evm.env.StateDB.GetRefund(), err: err}
+
+	if !evmcResultsMatch(&externalRes, &nativeRes) {
+		evm.report(&evmcDivergence{
+			address:  contract.Address(),
+			input:    common.CopyBytes(input),
+			external: externalRes,
+			native:   nativeRes,
+		})
+	}
+	return ret, err
+}
+
+// evmcResultsMatch returns whether the results of two executions are the
+// same. Errors are only compared by kind, as their details differ between
+// implementations.
+func evmcResultsMatch(a, b *evmcResult) bool {
+	if (a.err == nil) != (b.err == nil) || (a.err == ErrExecutionReverted) != (b.err == ErrExecutionReverted) {
+		return false
+	}
+	return a.gas == b.gas && a.refund == b.refund && bytes.Equal(a.output, b.output)
+}
+
+// evmcHost gives the external VM access to the state of the EVM running the
+// given contract.
+type evmcHost struct {
+	env      *EVM
+	contract *Contract
+}
+
+func (host *evmcHost) AccountExists(addr evmc.Address) bool {
+	if host.env.chainRules.IsEIP158 {
+		return !host.env.StateDB.Empty(common.Address(addr))
+	}
+	return host.env.StateDB.Exist(common.Address(addr))
+}
+
+func (host *evmcHost) GetStorage(addr evmc.Address, key evmc.Hash) evmc.Hash {
+	return evmc.Hash(host.env.StateDB.GetState(common.Address(addr), common.Hash(key)))
+}
+
+// SetStorage writes the storage slot and applies the gas refunds, which are
+// up to the host in EVMC. The returned status allows the VM to charge the
+// storage costs.
+func (host *evmcHost) SetStorage(evmcAddr evmc.Address, evmcKey evmc.Hash, evmcValue evmc.Hash) evmc.StorageStatus {
+	var (
+		db      = host.env.StateDB
+		rules   = host.env.chainRules
+		addr    = common.Address(evmcAddr)
+		key     = common.Hash(evmcKey)
+		value   = common.Hash(evmcValue)
+		current = db.GetState(addr, key)
+	)
+	if current == value {
+		return evmc.StorageUnchanged
+	}
+	db.SetState(addr, key, value)
+
+	// The legacy gas metering only takes into consideration the current state
+	if !rules.IsIstanbul && (rules.IsPetersburg || !rules.IsConstantinople) {
+		switch {
+		case current == (common.Hash{}):
+			return evmc.StorageAdded
+		case value == (common.Hash{}):
+			db.AddRefund(params.SstoreRefundGas)
+			return evmc.StorageDeleted
+		default:
+			return evmc.StorageModified
+		}
+	}
+	// Net gas metering (EIP-1283, EIP-2200 and EIP-2929)
+	var clearRefund, resetClearRefund, resetRefund uint64
+	switch {
+	case rules.IsYoloV2:
+		clearRefund = params.SstoreClearsScheduleRefundEIP2200
+		resetClearRefund = params.SstoreSetGasEIP2200 - WarmStorageReadCostEIP2929
+		resetRefund = (params.SstoreResetGasEIP2200 - ColdSloadCostEIP2929) - WarmStorageReadCostEIP2929
+	case rules.IsIstanbul:
+		clearRefund = params.SstoreClearsScheduleRefundEIP2200
+		resetClearRefund = params.SstoreSetGasEIP2200 - params.SloadGasEIP2200
+		resetRefund = params.SstoreResetGasEIP2200 - params.SloadGasEIP2200
+	default:
+		clearRefund = params.NetSstoreClearRefund
+		resetClearRefund = params.NetSstoreResetClearRefund
+		resetRefund = params.NetSstoreResetRefund
+	}
+	original := db.GetCommittedState(addr, key)
+	if original == current {
+		if original == (common.Hash{}) { // create slot
+			return evmc.StorageAdded
+		}
+		if value == (common.Hash{}) { // delete slot
+			db.AddRefund(clearRefund)
+			return evmc.StorageDeleted
+		}
+		return evmc.StorageModified
+	}
+	if original != (common.Hash{}) {
+		if current == (common.Hash{}) { // recreate slot
+			db.SubRefund(clearRefund)
+		} else if value == (common.Hash{}) { // delete slot
+			db.AddRefund(clearRefund)
+		}
+	}
+	if original == value {
+		if original == (common.Hash{}) { // reset to original inexistent slot
+			db.AddRefund(resetClearRefund)
+		} else { // reset to original existing slot
+			db.AddRefund(resetRefund)
+		}
+	}
+	return evmc.StorageModifiedAgain
+}
+
+func (host *evmcHost) GetBalance(addr evmc.Address) evmc.Hash {
+	return evmc.Hash(bigToHash(host.env.StateDB.GetBalance(common.Address(addr))))
+}
+
+func (host *evmcHost) GetCodeSize(addr evmc.Address) int {
+	return host.env.StateDB.GetCodeSize(common.Address(addr))
+}
+
+func (host *evmcHost) GetCodeHash(addr evmc.Address) evmc.Hash {
+	if host.env.StateDB.Empty(common.Address(addr)) {
+		return evmc.Hash{}
+	}
+	return evmc.Hash(host.env.StateDB.GetCodeHash(common.Address(addr)))
+}
+
+func (host *evmcHost) GetCode(addr evmc.Address) []byte {
+	return host.env.StateDB.GetCode(common.Address(addr))
+}
+
+func (host *evmcHost) Selfdestruct(evmcAddr evmc.Address, evmcBeneficiary evmc.Address) {
+	var (
+		db   = host.env.StateDB
+		addr = common.Address(evmcAddr)
+	)
+	if !host.env.chainRules.IsEIP3529 && !db.HasSuicided(addr) {
+		db.AddRefund(params.SelfdestructRefundGas)
+	}
+	db.AddBalance(common.Address(evmcBeneficiary), db.GetBalance(addr))
+	db.Suicide(addr)
+}
+
+func (host *evmcHost) GetTxContext() evmc.TxContext {
+	ctx := host.env.Context
+	return evmc.TxContext{
+		GasPrice:   evmc.Hash(bigToHash(host.env.GasPrice)),
+		Origin:     evmc.Address(host.env.Origin),
+		Coinbase:   evmc.Address(ctx.Coinbase),
+		Number:     ctx.BlockNumber.Int64(),
+		Timestamp:  ctx.Time.Int64(),
+		GasLimit:   int64(ctx.GasLimit),
+		Difficulty: evmc.Hash(bigToHash(ctx.Difficulty)),
+		ChainID:    evmc.Hash(bigToHash(host.env.chainRules.ChainID)),
+	}
+}
+
+func (host *evmcHost) GetBlockHash(number int64) evmc.Hash {
+	return evmc.Hash(host.env.Context.GetHash(uint64(number)))
+}
+
+func (host *evmcHost) EmitLog(addr evmc.Address, evmcTopics []evmc.Hash, data []byte) {
+	topics := make([]common.Hash, len(evmcTopics))
+	for i, topic := range evmcTopics {
+		topics[i] = common.Hash(topic)
+	}
+	host.env.StateDB.AddLog(&types.Log{
+		Address: common.Address(addr),
+		Topics:  topics,
+		Data:    data,
+		// This is a non-consensus field, but assigned here because
+		// core/state doesn't know the current block number.
+		BlockNumber: host.env.Context.BlockNumber.Uint64(),
+	})
+}
+
+// Call executes a nested call or contract creation of the running contract.
+func (host *evmcHost) Call(kind evmc.CallKind, destination evmc.Address, sender evmc.Address, value evmc.Hash,
+	input []byte, gas int64, depth int, static bool, salt evmc.Hash) (output []byte, gasLeft int64, createAddr evmc.Address, err error) {
+
+	var (
+		env       = host.env
+		addr      = common.Address(destination)
+		endowment = new(big.Int).SetBytes(value[:])
+		gasU      = uint64(gas)
+		leftOver  uint64
+	)
+	switch kind {
+	case evmc.Call:
+		if static {
+			output, leftOver, err = env.StaticCall(host.contract, addr, input, gasU)
+		} else {
+			output, leftOver, err = env.Call(host.contract, addr, input, gasU, endowment)
+		}
+	case evmc.DelegateCall:
+		output, leftOver, err = env.DelegateCall(host.contract, addr, input, gasU)
+	case evmc.CallCode:
+		output, leftOver, err = env.CallCode(host.contract, addr, input, gasU, endowment)
+	case evmc.Create, evmc.Create2:
+		var created common.Address
+		if kind == evmc.Create {
+			output, created, leftOver, err = env.Create(host.contract, input, gasU, endowment)
+		} else {
+			output, created, leftOver, err = env.Create2(host.contract, input, gasU, endowment, new(uint256.Int).SetBytes(salt[:]))
+		}
+		createAddr = evmc.Address(created)
+		// Only the revert reason of a failed creation is returned
+		if err != ErrExecutionReverted {
+			output = nil
+		}
+	default:
+		panic(fmt.Sprintf("EVMC: unknown call kind %d", kind))
+	}
+	switch {
+	case err == nil:
+	case err == ErrExecutionReverted:
+		err = evmc.Revert
+	default:
+		err = evmc.Failure
+	}
+	return output, int64(leftOver), createAddr, err
+}
+
+func (host *evmcHost) AccessAccount(addr evmc.Address) evmc.AccessStatus {
+	if host.env.StateDB.AddressInAccessList(common.Address(addr)) {
+		return evmc.WarmAccess
+	}
+	host.env.StateDB.AddAddressToAccessList(common.Address(addr))
+	return evmc.ColdAccess
+}
+
+func (host *evmcHost) AccessStorage(addr evmc.Address, key evmc.Hash) evmc.AccessStatus {
+	if _, slotOk := host.env.StateDB.SlotInAccessList(common.Address(addr), common.Hash(key)); slotOk {
+		return evmc.WarmAccess
+	}
+	host.env.StateDB.AddSlotToAccessList(common.Address(addr), common.Hash(key))
+	return evmc.ColdAccess
+}
+
+// bigToHash converts the integer to a 256-bit word, treating nil as zero.
+func bigToHash(b *big.Int) common.Hash {
+	if b == nil {
+		return common.Hash{}
+	}
+	return common.BigToHash(b)
+}
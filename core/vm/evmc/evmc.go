@@ -0,0 +1,264 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build cgo,!windows
+
+package evmc
+
+/*
+#cgo linux LDFLAGS: -ldl
+
+#include <dlfcn.h>
+#include <stdlib.h>
+#include <string.h>
+
+#include "evmc.h"
+
+extern const struct evmc_host_interface evmc_go_host;
+
+static void* evmc_go_dlopen(const char* filename)
+{
+	return dlopen(filename, RTLD_LAZY);
+}
+
+static struct evmc_vm* evmc_go_create(void* create_fn)
+{
+	return ((evmc_create_fn)create_fn)();
+}
+
+static void evmc_go_destroy(struct evmc_vm* vm)
+{
+	vm->destroy(vm);
+}
+
+static evmc_capabilities_flagset evmc_go_get_capabilities(struct evmc_vm* vm)
+{
+	return vm->get_capabilities(vm);
+}
+
+static enum evmc_set_option_result evmc_go_set_option(struct evmc_vm* vm, const char* name, const char* value)
+{
+	if (vm->set_option == NULL)
+		return EVMC_SET_OPTION_INVALID_NAME;
+	return vm->set_option(vm, name, value);
+}
+
+static struct evmc_result evmc_go_execute(struct evmc_vm* vm, uintptr_t context_index, enum evmc_revision rev,
+	enum evmc_call_kind kind, uint32_t flags, int32_t depth, int64_t gas,
+	const evmc_address* destination, const evmc_address* sender,
+	const uint8_t* input_data, size_t input_size, const evmc_uint256be* value,
+	const uint8_t* code, size_t code_size, const evmc_bytes32* create2_salt)
+{
+	struct evmc_message msg;
+	memset(&msg, 0, sizeof(msg));
+	msg.kind = kind;
+	msg.flags = flags;
+	msg.depth = depth;
+	msg.gas = gas;
+	msg.destination = *destination;
+	msg.sender = *sender;
+	msg.input_data = input_data;
+	msg.input_size = input_size;
+	msg.value = *value;
+	msg.create2_salt = *create2_salt;
+
+	struct evmc_host_context* context = (struct evmc_host_context*)context_index;
+	return vm->execute(vm, &evmc_go_host, context, rev, &msg, code, code_size);
+}
+
+static void evmc_go_release_result(struct evmc_result* result)
+{
+	if (result->release != NULL)
+		result->release(result);
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unsafe"
+)
+
+// VM is an EVMC VM instance loaded from a shared library.
+type VM struct {
+	handle *C.struct_evmc_vm
+}
+
+// Load loads the EVMC VM from the given shared library and creates an instance
+// of it. The create function is looked up by the name derived from the file
+// name (e.g. evmc_create_evmone for libevmone.so), falling back to the generic
+// evmc_create.
+func Load(filename string) (*VM, error) {
+	cfilename := C.CString(filename)
+	defer C.free(unsafe.Pointer(cfilename))
+
+	lib := C.evmc_go_dlopen(cfilename)
+	if lib == nil {
+		return nil, fmt.Errorf("failed to load %s: %s", filename, C.GoString(C.dlerror()))
+	}
+	var createFn unsafe.Pointer
+	for _, name := range []string{createFnName(filename), "evmc_create"} {
+		cname := C.CString(name)
+		createFn = C.dlsym(lib, cname)
+		C.free(unsafe.Pointer(cname))
+		if createFn != nil {
+			break
+		}
+	}
+	if createFn == nil {
+		return nil, fmt.Errorf("EVMC create function not found in %s", filename)
+	}
+	handle := C.evmc_go_create(createFn)
+	if handle == nil {
+		return nil, fmt.Errorf("failed to create EVMC VM from %s", filename)
+	}
+	if version := int(handle.abi_version); version != C.EVMC_ABI_VERSION {
+		C.evmc_go_destroy(handle)
+		return nil, fmt.Errorf("EVMC ABI version %d of %s doesn't match the supported version %d", version, filename, C.EVMC_ABI_VERSION)
+	}
+	return &VM{handle: handle}, nil
+}
+
+// LoadAndConfigure loads the EVMC VM described by the given configuration,
+// consisting of the path of the shared library followed by comma separated
+// options, e.g. "libevmone.so,O=0".
+func LoadAndConfigure(config string) (*VM, error) {
+	parts := strings.Split(config, ",")
+	vm, err := Load(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	for _, option := range parts[1:] {
+		name, value := option, ""
+		if i := strings.IndexByte(option, '='); i >= 0 {
+			name, value = option[:i], option[i+1:]
+		}
+		if err := vm.SetOption(name, value); err != nil {
+			vm.Destroy()
+			return nil, err
+		}
+	}
+	return vm, nil
+}
+
+// createFnName returns the name of the create function of the VM implemented
+// by the given shared library.
+func createFnName(filename string) string {
+	name := filepath.Base(filename)
+	name = strings.TrimPrefix(name, "lib")
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		name = name[:i]
+	}
+	return "evmc_create_" + strings.Replace(name, "-", "_", -1)
+}
+
+// Destroy destroys the VM instance.
+func (vm *VM) Destroy() {
+	C.evmc_go_destroy(vm.handle)
+}
+
+// Name returns the name of the VM implementation.
+func (vm *VM) Name() string {
+	return C.GoString(vm.handle.name)
+}
+
+// Version returns the version of the VM implementation.
+func (vm *VM) Version() string {
+	return C.GoString(vm.handle.version)
+}
+
+// HasCapability returns whether the VM supports the given capability.
+func (vm *VM) HasCapability(capability Capability) bool {
+	return uint32(C.evmc_go_get_capabilities(vm.handle))&uint32(capability) != 0
+}
+
+// SetOption sets an implementation specific option of the VM.
+func (vm *VM) SetOption(name string, value string) error {
+	cname, cvalue := C.CString(name), C.CString(value)
+	defer C.free(unsafe.Pointer(cname))
+	defer C.free(unsafe.Pointer(cvalue))
+
+	switch C.evmc_go_set_option(vm.handle, cname, cvalue) {
+	case C.EVMC_SET_OPTION_SUCCESS:
+		return nil
+	case C.EVMC_SET_OPTION_INVALID_NAME:
+		return fmt.Errorf("EVMC option '%s' is not supported", name)
+	default:
+		return fmt.Errorf("invalid value '%s' for EVMC option '%s'", value, name)
+	}
+}
+
+// Execute executes the given code in the VM, using ctx to access the state.
+func (vm *VM) Execute(ctx HostContext, rev Revision, kind CallKind, static bool, depth int, gas int64,
+	destination Address, sender Address, input []byte, value Hash, code []byte, create2Salt Hash) (output []byte, gasLeft int64, err error) {
+
+	if len(code) == 0 {
+		return nil, gas, errors.New("empty code")
+	}
+	flags := C.uint32_t(0)
+	if static {
+		flags |= C.EVMC_STATIC
+	}
+	index := addHostContext(ctx)
+	defer removeHostContext(index)
+
+	var inputPtr *C.uint8_t
+	if len(input) > 0 {
+		inputPtr = (*C.uint8_t)(unsafe.Pointer(&input[0]))
+	}
+	evmcDestination, evmcSender := evmcAddress(destination), evmcAddress(sender)
+	evmcValue, evmcSalt := evmcBytes32(value), evmcBytes32(create2Salt)
+
+	result := C.evmc_go_execute(vm.handle, C.uintptr_t(index), C.enum_evmc_revision(rev), C.enum_evmc_call_kind(kind), flags,
+		C.int32_t(depth), C.int64_t(gas), &evmcDestination, &evmcSender, inputPtr, C.size_t(len(input)),
+		&evmcValue, (*C.uint8_t)(unsafe.Pointer(&code[0])), C.size_t(len(code)), &evmcSalt)
+	defer C.evmc_go_release_result(&result)
+
+	if result.output_size > 0 {
+		output = C.GoBytes(unsafe.Pointer(result.output_data), C.int(result.output_size))
+	}
+	gasLeft = int64(result.gas_left)
+	if result.status_code != C.EVMC_SUCCESS {
+		err = Error(result.status_code)
+	}
+	return output, gasLeft, err
+}
+
+func evmcAddress(address Address) C.evmc_address {
+	return *(*C.evmc_address)(unsafe.Pointer(&address))
+}
+
+func evmcBytes32(in Hash) C.evmc_bytes32 {
+	return *(*C.evmc_bytes32)(unsafe.Pointer(&in))
+}
+
+func goAddress(in C.evmc_address) Address {
+	return *(*Address)(unsafe.Pointer(&in))
+}
+
+func goHash(in C.evmc_bytes32) Hash {
+	return *(*Hash)(unsafe.Pointer(&in))
+}
+
+func goByteSlice(data *C.uint8_t, size C.size_t) []byte {
+	if size == 0 {
+		return []byte{}
+	}
+	return C.GoBytes(unsafe.Pointer(data), C.int(size))
+}
@@ -0,0 +1,158 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build cgo,!windows
+
+package evmc
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// testHostContext is a HostContext recording the storage, logs and calls of
+// the executed code.
+type testHostContext struct {
+	storage map[Hash]Hash
+	logs    [][]Hash
+	calls   []Address
+}
+
+func (host *testHostContext) AccountExists(addr Address) bool { return false }
+func (host *testHostContext) GetStorage(addr Address, key Hash) Hash {
+	return host.storage[key]
+}
+func (host *testHostContext) SetStorage(addr Address, key Hash, value Hash) StorageStatus {
+	host.storage[key] = value
+	return StorageAdded
+}
+func (host *testHostContext) GetBalance(addr Address) Hash                   { return Hash{} }
+func (host *testHostContext) GetCodeSize(addr Address) int                   { return 0 }
+func (host *testHostContext) GetCodeHash(addr Address) Hash                  { return Hash{} }
+func (host *testHostContext) GetCode(addr Address) []byte                    { return nil }
+func (host *testHostContext) Selfdestruct(addr Address, beneficiary Address) {}
+func (host *testHostContext) GetTxContext() TxContext {
+	return TxContext{Number: 0x0102}
+}
+func (host *testHostContext) GetBlockHash(number int64) Hash { return Hash{} }
+func (host *testHostContext) EmitLog(addr Address, topics []Hash, data []byte) {
+	host.logs = append(host.logs, topics)
+}
+func (host *testHostContext) Call(kind CallKind, destination Address, sender Address, value Hash, input []byte, gas int64, depth int,
+	static bool, salt Hash) (output []byte, gasLeft int64, createAddr Address, err error) {
+	host.calls = append(host.calls, destination)
+	return append([]byte("re:"), input...), gas, Address{}, nil
+}
+func (host *testHostContext) AccessAccount(addr Address) AccessStatus           { return ColdAccess }
+func (host *testHostContext) AccessStorage(addr Address, key Hash) AccessStatus { return ColdAccess }
+
+// loadExampleVM compiles the example VM into a shared library and loads it.
+func loadExampleVM(t *testing.T, options string) *VM {
+	dir, err := ioutil.TempDir("", "evmc-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	lib := filepath.Join(dir, "libexample-vm.so")
+	cc := os.Getenv("CC")
+	if cc == "" {
+		cc = "cc"
+	}
+	if out, err := exec.Command(cc, "-shared", "-fPIC", "-o", lib, "testdata/example_vm.c").CombinedOutput(); err != nil {
+		t.Skipf("failed to build example VM: %v\n%s", err, out)
+	}
+	vm, err := LoadAndConfigure(lib + options)
+	if err != nil {
+		t.Fatalf("failed to load example VM: %v", err)
+	}
+	return vm
+}
+
+func TestLoad(t *testing.T) {
+	if _, err := Load("/nonexistent/libevm.so"); err == nil {
+		t.Fatal("loaded nonexistent VM")
+	}
+	vm := loadExampleVM(t, ",verbose=1")
+	defer vm.Destroy()
+
+	if vm.Name() != "example_vm" || vm.Version() != "0.0.0" {
+		t.Errorf("VM mismatch: have %s %s, want example_vm 0.0.0", vm.Name(), vm.Version())
+	}
+	if !vm.HasCapability(CapabilityEVM1) || vm.HasCapability(CapabilityEWASM) {
+		t.Error("capabilities mismatch")
+	}
+	if err := vm.SetOption("verbose", "2"); err == nil {
+		t.Error("invalid option value accepted")
+	}
+	if err := vm.SetOption("unknown", "1"); err == nil {
+		t.Error("unknown option accepted")
+	}
+}
+
+func TestCreateFnName(t *testing.T) {
+	tests := map[string]string{
+		"libevmone.so":              "evmc_create_evmone",
+		"/usr/lib/libexample-vm.so": "evmc_create_example_vm",
+		"evmone.1.dylib":            "evmc_create_evmone",
+	}
+	for filename, want := range tests {
+		if have := createFnName(filename); have != want {
+			t.Errorf("%s: create function mismatch: have %s, want %s", filename, have, want)
+		}
+	}
+}
+
+func TestExecute(t *testing.T) {
+	vm := loadExampleVM(t, "")
+	defer vm.Destroy()
+
+	host := &testHostContext{storage: make(map[Hash]Hash)}
+	input := []byte("input")
+	output, gasLeft, err := vm.Execute(host, Berlin, Call, false, 0, 1000, Address{1}, Address{2}, input, Hash{}, []byte{0x60}, Hash{})
+	if err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	if gasLeft != 900 {
+		t.Errorf("gas left mismatch: have %d, want 900", gasLeft)
+	}
+	var stored Hash
+	copy(stored[:], input)
+	want := append(append(stored[:], "re:hi"...), 0, 0, 0, 0, 0, 0, 0x01, 0x02)
+	if !bytes.Equal(output, want) {
+		t.Errorf("output mismatch: have %x, want %x", output, want)
+	}
+	if host.storage[Hash{}] != stored {
+		t.Errorf("storage mismatch: have %x, want %x", host.storage[Hash{}], stored)
+	}
+	if len(host.logs) != 1 || len(host.logs[0]) != 1 || host.logs[0][0] != (Hash{31: 0x60}) {
+		t.Errorf("logs mismatch: have %x", host.logs)
+	}
+	if len(host.calls) != 1 || host.calls[0] != (Address{19: 0x42}) {
+		t.Errorf("calls mismatch: have %x", host.calls)
+	}
+	// Check that failures are reported with the remaining gas
+	if _, gasLeft, err = vm.Execute(host, Berlin, Call, false, 0, 1000, Address{1}, Address{2}, nil, Hash{}, []byte{0xfd}, Hash{}); err != Revert {
+		t.Errorf("error mismatch: have %v, want %v", err, Revert)
+	}
+	if gasLeft != 900 {
+		t.Errorf("gas left mismatch after revert: have %d, want 900", gasLeft)
+	}
+}
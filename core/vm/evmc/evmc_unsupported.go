@@ -0,0 +1,59 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build !cgo windows
+
+package evmc
+
+import "errors"
+
+// errUnsupported is returned when loading a VM on a build without support for
+// shared libraries.
+var errUnsupported = errors.New("EVMC is not supported by this build")
+
+// VM is an EVMC VM instance loaded from a shared library.
+type VM struct{}
+
+// Load is not supported on this build and always fails.
+func Load(filename string) (*VM, error) {
+	return nil, errUnsupported
+}
+
+// LoadAndConfigure is not supported on this build and always fails.
+func LoadAndConfigure(config string) (*VM, error) {
+	return nil, errUnsupported
+}
+
+// Destroy destroys the VM instance.
+func (vm *VM) Destroy() {}
+
+// Name returns the name of the VM implementation.
+func (vm *VM) Name() string { return "" }
+
+// Version returns the version of the VM implementation.
+func (vm *VM) Version() string { return "" }
+
+// HasCapability returns whether the VM supports the given capability.
+func (vm *VM) HasCapability(capability Capability) bool { return false }
+
+// SetOption sets an implementation specific option of the VM.
+func (vm *VM) SetOption(name string, value string) error { return errUnsupported }
+
+// Execute executes the given code in the VM, using ctx to access the state.
+func (vm *VM) Execute(ctx HostContext, rev Revision, kind CallKind, static bool, depth int, gas int64,
+	destination Address, sender Address, input []byte, value Hash, code []byte, create2Salt Hash) (output []byte, gasLeft int64, err error) {
+	return nil, 0, errUnsupported
+}
@@ -0,0 +1,60 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build cgo,!windows
+
+#include <stdlib.h>
+#include <string.h>
+
+#include "evmc.h"
+#include "_cgo_export.h"
+
+// The host interface handed to the VMs, forwarding every method to the Go
+// implementation exported by host.go. The exported functions use generic Go
+// types in their signatures, hence the casts.
+const struct evmc_host_interface evmc_go_host = {
+    (evmc_account_exists_fn)accountExists,
+    (evmc_get_storage_fn)getStorage,
+    (evmc_set_storage_fn)setStorage,
+    (evmc_get_balance_fn)getBalance,
+    (evmc_get_code_size_fn)getCodeSize,
+    (evmc_get_code_hash_fn)getCodeHash,
+    (evmc_copy_code_fn)copyCode,
+    (evmc_selfdestruct_fn)selfdestruct,
+    (evmc_call_fn)call,
+    (evmc_get_tx_context_fn)getTxContext,
+    (evmc_get_block_hash_fn)getBlockHash,
+    (evmc_emit_log_fn)emitLog,
+    (evmc_access_account_fn)accessAccount,
+    (evmc_access_storage_fn)accessStorage,
+};
+
+static void evmc_go_free_result_output(const struct evmc_result* result)
+{
+    free((void*)result->output_data);
+}
+
+// evmc_go_set_result_output copies the output of a nested call into C memory
+// owned by the result, to be freed by the VM through the release function.
+void evmc_go_set_result_output(struct evmc_result* result, const uint8_t* data, size_t size)
+{
+    uint8_t* output = (uint8_t*)malloc(size);
+    memcpy(output, data, size);
+
+    result->output_data = output;
+    result->output_size = size;
+    result->release = evmc_go_free_result_output;
+}
@@ -0,0 +1,202 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build cgo,!windows
+
+package evmc
+
+/*
+#include <stdlib.h>
+#include <string.h>
+
+#include "evmc.h"
+
+void evmc_go_set_result_output(struct evmc_result* result, const uint8_t* data, size_t size);
+*/
+import "C"
+
+import (
+	"sync"
+	"unsafe"
+)
+
+// Host contexts can't be handed to C directly, as Go pointers must not be
+// retained by C code. Instead, they are registered here and identified by an
+// index which is passed through the VM to the host callbacks.
+var (
+	hostContextsMu   sync.Mutex
+	hostContexts     = make(map[uintptr]HostContext)
+	hostContextsNext uintptr
+)
+
+func addHostContext(ctx HostContext) uintptr {
+	hostContextsMu.Lock()
+	defer hostContextsMu.Unlock()
+
+	hostContextsNext++
+	hostContexts[hostContextsNext] = ctx
+	return hostContextsNext
+}
+
+func removeHostContext(index uintptr) {
+	hostContextsMu.Lock()
+	defer hostContextsMu.Unlock()
+
+	delete(hostContexts, index)
+}
+
+func getHostContext(index uintptr) HostContext {
+	hostContextsMu.Lock()
+	defer hostContextsMu.Unlock()
+
+	return hostContexts[index]
+}
+
+//export accountExists
+func accountExists(pCtx unsafe.Pointer, pAddr *C.evmc_address) C.bool {
+	ctx := getHostContext(uintptr(pCtx))
+	return C.bool(ctx.AccountExists(goAddress(*pAddr)))
+}
+
+//export getStorage
+func getStorage(pCtx unsafe.Pointer, pAddr *C.evmc_address, pKey *C.evmc_bytes32) C.evmc_bytes32 {
+	ctx := getHostContext(uintptr(pCtx))
+	return evmcBytes32(ctx.GetStorage(goAddress(*pAddr), goHash(*pKey)))
+}
+
+//export setStorage
+func setStorage(pCtx unsafe.Pointer, pAddr *C.evmc_address, pKey *C.evmc_bytes32, pVal *C.evmc_bytes32) C.enum_evmc_storage_status {
+	ctx := getHostContext(uintptr(pCtx))
+	return C.enum_evmc_storage_status(ctx.SetStorage(goAddress(*pAddr), goHash(*pKey), goHash(*pVal)))
+}
+
+//export getBalance
+func getBalance(pCtx unsafe.Pointer, pAddr *C.evmc_address) C.evmc_uint256be {
+	ctx := getHostContext(uintptr(pCtx))
+	return evmcBytes32(ctx.GetBalance(goAddress(*pAddr)))
+}
+
+//export getCodeSize
+func getCodeSize(pCtx unsafe.Pointer, pAddr *C.evmc_address) C.size_t {
+	ctx := getHostContext(uintptr(pCtx))
+	return C.size_t(ctx.GetCodeSize(goAddress(*pAddr)))
+}
+
+//export getCodeHash
+func getCodeHash(pCtx unsafe.Pointer, pAddr *C.evmc_address) C.evmc_bytes32 {
+	ctx := getHostContext(uintptr(pCtx))
+	return evmcBytes32(ctx.GetCodeHash(goAddress(*pAddr)))
+}
+
+//export copyCode
+func copyCode(pCtx unsafe.Pointer, pAddr *C.evmc_address, offset C.size_t, p *C.uint8_t, size C.size_t) C.size_t {
+	ctx := getHostContext(uintptr(pCtx))
+	code := ctx.GetCode(goAddress(*pAddr))
+
+	length := C.size_t(len(code))
+	if offset >= length {
+		return 0
+	}
+	toCopy := length - offset
+	if toCopy > size {
+		toCopy = size
+	}
+	out := (*[1 << 30]byte)(unsafe.Pointer(p))[:toCopy:toCopy]
+	copy(out, code[offset:])
+	return toCopy
+}
+
+//export selfdestruct
+func selfdestruct(pCtx unsafe.Pointer, pAddr *C.evmc_address, pBeneficiary *C.evmc_address) {
+	ctx := getHostContext(uintptr(pCtx))
+	ctx.Selfdestruct(goAddress(*pAddr), goAddress(*pBeneficiary))
+}
+
+//export getTxContext
+func getTxContext(pCtx unsafe.Pointer) C.struct_evmc_tx_context {
+	ctx := getHostContext(uintptr(pCtx))
+	txContext := ctx.GetTxContext()
+
+	return C.struct_evmc_tx_context{
+		tx_gas_price:     evmcBytes32(txContext.GasPrice),
+		tx_origin:        evmcAddress(txContext.Origin),
+		block_coinbase:   evmcAddress(txContext.Coinbase),
+		block_number:     C.int64_t(txContext.Number),
+		block_timestamp:  C.int64_t(txContext.Timestamp),
+		block_gas_limit:  C.int64_t(txContext.GasLimit),
+		block_difficulty: evmcBytes32(txContext.Difficulty),
+		chain_id:         evmcBytes32(txContext.ChainID),
+	}
+}
+
+//export getBlockHash
+func getBlockHash(pCtx unsafe.Pointer, number int64) C.evmc_bytes32 {
+	ctx := getHostContext(uintptr(pCtx))
+	return evmcBytes32(ctx.GetBlockHash(number))
+}
+
+//export emitLog
+func emitLog(pCtx unsafe.Pointer, pAddr *C.evmc_address, pData unsafe.Pointer, dataSize C.size_t, pTopics unsafe.Pointer, topicsCount C.size_t) {
+	ctx := getHostContext(uintptr(pCtx))
+
+	data := C.GoBytes(pData, C.int(dataSize))
+	tData := C.GoBytes(pTopics, C.int(topicsCount*32))
+
+	nTopics := int(topicsCount)
+	topics := make([]Hash, nTopics)
+	for i := 0; i < nTopics; i++ {
+		copy(topics[i][:], tData[32*i:32*(i+1)])
+	}
+	ctx.EmitLog(goAddress(*pAddr), topics, data)
+}
+
+//export call
+func call(pCtx unsafe.Pointer, msg *C.struct_evmc_message) C.struct_evmc_result {
+	ctx := getHostContext(uintptr(pCtx))
+
+	kind := CallKind(msg.kind)
+	output, gasLeft, createAddr, err := ctx.Call(kind, goAddress(msg.destination), goAddress(msg.sender), goHash(msg.value),
+		goByteSlice(msg.input_data, msg.input_size), int64(msg.gas), int(msg.depth), msg.flags != 0, goHash(msg.create2_salt))
+
+	statusCode := C.enum_evmc_status_code(0)
+	if err != nil {
+		statusCode = C.enum_evmc_status_code(Failure)
+		if evmcErr, ok := err.(Error); ok {
+			statusCode = C.enum_evmc_status_code(evmcErr)
+		}
+	}
+	result := C.struct_evmc_result{
+		status_code:    statusCode,
+		gas_left:       C.int64_t(gasLeft),
+		create_address: evmcAddress(createAddr),
+	}
+	if len(output) > 0 {
+		C.evmc_go_set_result_output(&result, (*C.uint8_t)(unsafe.Pointer(&output[0])), C.size_t(len(output)))
+	}
+	return result
+}
+
+//export accessAccount
+func accessAccount(pCtx unsafe.Pointer, pAddr *C.evmc_address) C.enum_evmc_access_status {
+	ctx := getHostContext(uintptr(pCtx))
+	return C.enum_evmc_access_status(ctx.AccessAccount(goAddress(*pAddr)))
+}
+
+//export accessStorage
+func accessStorage(pCtx unsafe.Pointer, pAddr *C.evmc_address, pKey *C.evmc_bytes32) C.enum_evmc_access_status {
+	ctx := getHostContext(uintptr(pCtx))
+	return C.enum_evmc_access_status(ctx.AccessStorage(goAddress(*pAddr), goHash(*pKey)))
+}
@@ -0,0 +1,123 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// A minimal EVMC VM used to test the host bindings. It doesn't interpret the
+// code, but exercises the host interface instead:
+//
+//   - the first 32 bytes of the input are stored in slot zero of the callee
+//   - a log is emitted with the code's first byte as topic
+//   - address 0x42 is called with the input "hi"
+//   - the output is the stored value, followed by the output of the nested
+//     call and the block number
+//
+// Code starting with 0xfd reverts. Every execution costs 100 gas.
+
+#include <stdlib.h>
+#include <string.h>
+
+#include "../evmc.h"
+
+static void destroy(struct evmc_vm* vm)
+{
+    (void)vm;
+}
+
+static evmc_capabilities_flagset get_capabilities(struct evmc_vm* vm)
+{
+    (void)vm;
+    return EVMC_CAPABILITY_EVM1;
+}
+
+static enum evmc_set_option_result set_option(struct evmc_vm* vm, char const* name, char const* value)
+{
+    (void)vm;
+    if (strcmp(name, "verbose") != 0)
+        return EVMC_SET_OPTION_INVALID_NAME;
+    if (strcmp(value, "0") != 0 && strcmp(value, "1") != 0)
+        return EVMC_SET_OPTION_INVALID_VALUE;
+    return EVMC_SET_OPTION_SUCCESS;
+}
+
+static void free_result_output(const struct evmc_result* result)
+{
+    free((void*)result->output_data);
+}
+
+static struct evmc_result execute(struct evmc_vm* vm, const struct evmc_host_interface* host,
+                                  struct evmc_host_context* context, enum evmc_revision rev,
+                                  const struct evmc_message* msg, const uint8_t* code, size_t code_size)
+{
+    (void)vm;
+    (void)rev;
+
+    struct evmc_result result;
+    memset(&result, 0, sizeof(result));
+    result.gas_left = msg->gas - 100;
+
+    if (code_size > 0 && code[0] == 0xfd)
+    {
+        result.status_code = EVMC_REVERT;
+        return result;
+    }
+    evmc_bytes32 key, value;
+    memset(&key, 0, sizeof(key));
+    memset(&value, 0, sizeof(value));
+    memcpy(value.bytes, msg->input_data, msg->input_size < 32 ? msg->input_size : 32);
+    host->set_storage(context, &msg->destination, &key, &value);
+    evmc_bytes32 stored = host->get_storage(context, &msg->destination, &key);
+
+    evmc_bytes32 topic;
+    memset(&topic, 0, sizeof(topic));
+    topic.bytes[31] = code[0];
+    host->emit_log(context, &msg->destination, msg->input_data, msg->input_size, &topic, 1);
+
+    struct evmc_message call;
+    memset(&call, 0, sizeof(call));
+    call.kind = EVMC_CALL;
+    call.depth = msg->depth + 1;
+    call.gas = 1000;
+    call.destination.bytes[19] = 0x42;
+    call.sender = msg->destination;
+    call.input_data = (const uint8_t*)"hi";
+    call.input_size = 2;
+    struct evmc_result nested = host->call(context, &call);
+
+    struct evmc_tx_context tx = host->get_tx_context(context);
+
+    size_t size = 32 + nested.output_size + 8;
+    uint8_t* output = (uint8_t*)malloc(size);
+    memcpy(output, stored.bytes, 32);
+    if (nested.output_size > 0)
+        memcpy(output + 32, nested.output_data, nested.output_size);
+    for (int i = 0; i < 8; i++)
+        output[32 + nested.output_size + i] = (uint8_t)(tx.block_number >> (56 - 8 * i));
+    if (nested.release != NULL)
+        nested.release(&nested);
+
+    result.status_code = EVMC_SUCCESS;
+    result.output_data = output;
+    result.output_size = size;
+    result.release = free_result_output;
+    return result;
+}
+
+struct evmc_vm* evmc_create_example_vm(void)
+{
+    static struct evmc_vm instance = {
+        EVMC_ABI_VERSION, "example_vm", "0.0.0", destroy, execute, get_capabilities, set_option,
+    };
+    return &instance;
+}
@@ -0,0 +1,181 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package evmc implements the host side of the EVMC interface, allowing EVM
+// implementations built as EVMC shared libraries (e.g. evmone) to be loaded
+// and executed against a host provided state.
+//
+// The package mirrors the API of the upstream EVMC Go bindings, so that the
+// two can be used interchangeably.
+package evmc
+
+import "fmt"
+
+// Address is the 160-bit address of an account.
+type Address [20]byte
+
+// Hash is a 256-bit word, used for hashes, storage keys/values and big-endian
+// encoded integers.
+type Hash [32]byte
+
+// Error is an EVMC status code other than success.
+type Error int32
+
+// The values of the EVMC status codes, as defined by enum evmc_status_code.
+const (
+	Failure                = Error(1)
+	Revert                 = Error(2)
+	OutOfGas               = Error(3)
+	InvalidInstruction     = Error(4)
+	UndefinedInstruction   = Error(5)
+	StackOverflow          = Error(6)
+	StackUnderflow         = Error(7)
+	BadJumpDestination     = Error(8)
+	InvalidMemoryAccess    = Error(9)
+	CallDepthExceeded      = Error(10)
+	StaticModeViolation    = Error(11)
+	PrecompileFailure      = Error(12)
+	ContractValidationFail = Error(13)
+	ArgumentOutOfRange     = Error(14)
+	WasmUnreachableInstr   = Error(15)
+	WasmTrap               = Error(16)
+	InternalError          = Error(-1)
+	Rejected               = Error(-2)
+	OutOfMemory            = Error(-3)
+)
+
+var errorNames = map[Error]string{
+	Failure:                "failure",
+	Revert:                 "revert",
+	OutOfGas:               "out of gas",
+	InvalidInstruction:     "invalid instruction",
+	UndefinedInstruction:   "undefined instruction",
+	StackOverflow:          "stack overflow",
+	StackUnderflow:         "stack underflow",
+	BadJumpDestination:     "bad jump destination",
+	InvalidMemoryAccess:    "invalid memory access",
+	CallDepthExceeded:      "call depth exceeded",
+	StaticModeViolation:    "static mode violation",
+	PrecompileFailure:      "precompile failure",
+	ContractValidationFail: "contract validation failure",
+	ArgumentOutOfRange:     "argument out of range",
+	WasmUnreachableInstr:   "wasm unreachable instruction",
+	WasmTrap:               "wasm trap",
+	InternalError:          "internal error",
+	Rejected:               "rejected",
+	OutOfMemory:            "out of memory",
+}
+
+// Error implements the error interface.
+func (err Error) Error() string {
+	if name, ok := errorNames[err]; ok {
+		return name
+	}
+	return fmt.Sprintf("status code %d", int32(err))
+}
+
+// IsInternalError returns whether the error signals a failure of the VM itself
+// rather than of the executed code.
+func (err Error) IsInternalError() bool {
+	return err < 0
+}
+
+// Revision is the Ethereum specification revision executed by the VM.
+type Revision int32
+
+const (
+	Frontier         Revision = 0
+	Homestead        Revision = 1
+	TangerineWhistle Revision = 2
+	SpuriousDragon   Revision = 3
+	Byzantium        Revision = 4
+	Constantinople   Revision = 5
+	Petersburg       Revision = 6
+	Istanbul         Revision = 7
+	Berlin           Revision = 8
+)
+
+// CallKind is the kind of a call-like instruction.
+type CallKind int
+
+const (
+	Call         CallKind = 0
+	DelegateCall CallKind = 1
+	CallCode     CallKind = 2
+	Create       CallKind = 3
+	Create2      CallKind = 4
+)
+
+// Capability is a feature supported by a VM.
+type Capability uint32
+
+const (
+	CapabilityEVM1  Capability = 1 << 0
+	CapabilityEWASM Capability = 1 << 1
+)
+
+// StorageStatus is the effect of a storage write, used by the VM to charge
+// the storage gas costs.
+type StorageStatus int
+
+const (
+	StorageUnchanged     StorageStatus = 0
+	StorageModified      StorageStatus = 1
+	StorageModifiedAgain StorageStatus = 2
+	StorageAdded         StorageStatus = 3
+	StorageDeleted       StorageStatus = 4
+)
+
+// AccessStatus is the EIP-2929 access status of an account or storage slot.
+type AccessStatus int
+
+const (
+	ColdAccess AccessStatus = 0
+	WarmAccess AccessStatus = 1
+)
+
+// TxContext contains the transaction and block information accessible to
+// the executed code.
+type TxContext struct {
+	GasPrice   Hash
+	Origin     Address
+	Coinbase   Address
+	Number     int64
+	Timestamp  int64
+	GasLimit   int64
+	Difficulty Hash
+	ChainID    Hash
+}
+
+// HostContext is the interface the host implements to give the VM access to
+// the state and to nested calls.
+type HostContext interface {
+	AccountExists(addr Address) bool
+	GetStorage(addr Address, key Hash) Hash
+	SetStorage(addr Address, key Hash, value Hash) StorageStatus
+	GetBalance(addr Address) Hash
+	GetCodeSize(addr Address) int
+	GetCodeHash(addr Address) Hash
+	GetCode(addr Address) []byte
+	Selfdestruct(addr Address, beneficiary Address)
+	GetTxContext() TxContext
+	GetBlockHash(number int64) Hash
+	EmitLog(addr Address, topics []Hash, data []byte)
+	Call(kind CallKind, destination Address, sender Address, value Hash, input []byte, gas int64, depth int,
+		static bool, salt Hash) (output []byte, gasLeft int64, createAddr Address, err error)
+	AccessAccount(addr Address) AccessStatus
+	AccessStorage(addr Address, key Hash) AccessStatus
+}
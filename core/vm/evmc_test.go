@@ -0,0 +1,221 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm/evmc"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// testEVMCInstance is an external VM storing the input in the first storage
+// slot and returning a fixed result.
+type testEVMCInstance struct {
+	output []byte
+	gas    int64 // Gas used by the execution
+	err    error
+	calls  int
+}
+
+func (vm *testEVMCInstance) Execute(ctx evmc.HostContext, rev evmc.Revision, kind evmc.CallKind, static bool, depth int, gas int64,
+	destination evmc.Address, sender evmc.Address, input []byte, value evmc.Hash, code []byte,
+	create2Salt evmc.Hash) ([]byte, int64, error) {
+
+	vm.calls++
+	var val evmc.Hash
+	copy(val[:], input)
+	ctx.SetStorage(destination, evmc.Hash{}, val)
+	return vm.output, gas - vm.gas, vm.err
+}
+
+// newTestEVMC creates an EVM running the given code at a test address, with
+// the external VM preceding the built-in interpreter.
+func newTestEVMC(instance evmcInstance, code []byte, cfg Config) (*EVM, *EVMC, common.Address) {
+	address := common.BytesToAddress([]byte("contract"))
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.CreateAccount(address)
+	statedb.SetCode(address, code)
+	statedb.Finalise(true)
+
+	vmctx := BlockContext{
+		CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+		Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+		BlockNumber: big.NewInt(0),
+	}
+	env := NewEVM(vmctx, TxContext{}, statedb, params.AllEthashProtocolChanges, cfg)
+	ext := newEVMC(instance, env, env.interpreters[0].(*EVMInterpreter), cfg)
+	env.interpreters = append([]Interpreter{ext}, env.interpreters...)
+	env.interpreter = ext
+
+	return env, ext, address
+}
+
+// Code returning the 32 byte word 0x2a, using 18 gas.
+var evmcTestCode = hexutil.MustDecode("0x602a60005260206000f3")
+
+func TestEVMCExecute(t *testing.T) {
+	instance := &testEVMCInstance{output: []byte{1, 2, 3}, gas: 100}
+	env, _, address := newTestEVMC(instance, evmcTestCode, Config{})
+
+	ret, gas, err := env.Call(AccountRef(common.Address{}), address, []byte{0xff}, 1000, new(big.Int))
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if !bytes.Equal(ret, instance.output) {
+		t.Errorf("output mismatch: have %x, want %x", ret, instance.output)
+	}
+	if gas != 900 {
+		t.Errorf("gas left mismatch: have %d, want 900", gas)
+	}
+	if have := env.StateDB.GetState(address, common.Hash{}); have != (common.Hash{0xff}) {
+		t.Errorf("storage mismatch: have %x, want %x", have, common.Hash{0xff})
+	}
+	// Check that reverts are reported as such, and other errors consume all gas
+	instance.err = evmc.Revert
+	if _, gas, err = env.Call(AccountRef(common.Address{}), address, nil, 1000, new(big.Int)); err != ErrExecutionReverted {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrExecutionReverted)
+	}
+	if gas != 900 {
+		t.Errorf("gas left mismatch after revert: have %d, want 900", gas)
+	}
+	instance.err = evmc.StackUnderflow
+	if _, gas, err = env.Call(AccountRef(common.Address{}), address, nil, 1000, new(big.Int)); err == nil {
+		t.Error("failure not reported")
+	}
+	if gas != 0 {
+		t.Errorf("gas left mismatch after failure: have %d, want 0", gas)
+	}
+}
+
+func TestEVMCUnsupported(t *testing.T) {
+	instance := &testEVMCInstance{}
+	env, _, address := newTestEVMC(instance, evmcTestCode, Config{ExtraEips: []int{2315}})
+
+	ret, _, err := env.Call(AccountRef(common.Address{}), address, nil, 1000, new(big.Int))
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if instance.calls != 0 {
+		t.Errorf("external VM used with extra EIPs")
+	}
+	if want := common.LeftPadBytes([]byte{0x2a}, 32); !bytes.Equal(ret, want) {
+		t.Errorf("output mismatch: have %x, want %x", ret, want)
+	}
+}
+
+func TestEVMCDifferential(t *testing.T) {
+	tests := []struct {
+		output   []byte
+		gas      int64
+		err      error
+		diverges bool
+	}{
+		{common.LeftPadBytes([]byte{0x2a}, 32), 18, nil, false},
+		{common.LeftPadBytes([]byte{0x2b}, 32), 18, nil, true},
+		{common.LeftPadBytes([]byte{0x2a}, 32), 19, nil, true},
+		{common.LeftPadBytes([]byte{0x2a}, 32), 18, evmc.Revert, true},
+	}
+	for i, tt := range tests {
+		instance := &testEVMCInstance{output: tt.output, gas: tt.gas, err: tt.err}
+		env, ext, address := newTestEVMC(instance, evmcTestCode, Config{EVMCDifferential: true})
+
+		var divergences []*evmcDivergence
+		ext.report = func(d *evmcDivergence) { divergences = append(divergences, d) }
+
+		ret, gas, err := env.Call(AccountRef(common.Address{}), address, []byte{0xff}, 1000, new(big.Int))
+		if err != nil {
+			t.Fatalf("test %d: call failed: %v", i, err)
+		}
+		if instance.calls != 1 {
+			t.Errorf("test %d: external VM calls mismatch: have %d, want 1", i, instance.calls)
+		}
+		// The result of the built-in interpreter must be kept
+		if want := common.LeftPadBytes([]byte{0x2a}, 32); !bytes.Equal(ret, want) || gas != 1000-18 {
+			t.Errorf("test %d: result mismatch: have %x/%d, want %x/%d", i, ret, gas, want, 1000-18)
+		}
+		if have := env.StateDB.GetState(address, common.Hash{}); have != (common.Hash{}) {
+			t.Errorf("test %d: external state change kept: %x", i, have)
+		}
+		if diverged := len(divergences) > 0; diverged != tt.diverges {
+			t.Errorf("test %d: divergence mismatch: have %v, want %v", i, diverged, tt.diverges)
+		}
+	}
+}
+
+func TestEVMCSetStorage(t *testing.T) {
+	tests := []struct {
+		original byte
+		values   []byte
+		statuses []evmc.StorageStatus
+		refund   uint64
+	}{
+		{0, []byte{0, 0}, []evmc.StorageStatus{evmc.StorageUnchanged, evmc.StorageUnchanged}, 0},
+		{0, []byte{1, 0}, []evmc.StorageStatus{evmc.StorageAdded, evmc.StorageModifiedAgain}, 19200},
+		{0, []byte{1, 2}, []evmc.StorageStatus{evmc.StorageAdded, evmc.StorageModifiedAgain}, 0},
+		{1, []byte{0, 0}, []evmc.StorageStatus{evmc.StorageDeleted, evmc.StorageUnchanged}, 15000},
+		{1, []byte{0, 1}, []evmc.StorageStatus{evmc.StorageDeleted, evmc.StorageModifiedAgain}, 4200},
+		{1, []byte{2, 0}, []evmc.StorageStatus{evmc.StorageModified, evmc.StorageModifiedAgain}, 15000},
+		{1, []byte{2, 1}, []evmc.StorageStatus{evmc.StorageModified, evmc.StorageModifiedAgain}, 4200},
+		{1, []byte{2, 3}, []evmc.StorageStatus{evmc.StorageModified, evmc.StorageModifiedAgain}, 0},
+		{1, []byte{0, 1, 0}, []evmc.StorageStatus{evmc.StorageDeleted, evmc.StorageModifiedAgain, evmc.StorageDeleted}, 19200},
+	}
+	for i, tt := range tests {
+		env, _, address := newTestEVMC(&testEVMCInstance{}, evmcTestCode, Config{})
+		env.StateDB.SetState(address, common.Hash{}, common.Hash{31: tt.original})
+		env.StateDB.(*state.StateDB).Finalise(true)
+
+		host := &evmcHost{env: env}
+		for j, value := range tt.values {
+			if status := host.SetStorage(evmc.Address(address), evmc.Hash{}, evmc.Hash{31: value}); status != tt.statuses[j] {
+				t.Errorf("test %d, write %d: status mismatch: have %v, want %v", i, j, status, tt.statuses[j])
+			}
+		}
+		if refund := env.StateDB.GetRefund(); refund != tt.refund {
+			t.Errorf("test %d: refund mismatch: have %d, want %d", i, refund, tt.refund)
+		}
+	}
+}
+
+func TestEVMCRevision(t *testing.T) {
+	tests := []struct {
+		rules     params.Rules
+		revision  evmc.Revision
+		supported bool
+	}{
+		{params.Rules{}, evmc.Frontier, true},
+		{params.Rules{IsHomestead: true, IsEIP150: true}, evmc.TangerineWhistle, true},
+		{params.Rules{IsHomestead: true, IsEIP150: true, IsEIP155: true, IsEIP158: true}, evmc.SpuriousDragon, true},
+		{params.Rules{IsHomestead: true, IsEIP150: true, IsEIP160: true}, 0, false},
+		{params.Rules{IsByzantium: true, IsConstantinople: true, IsPetersburg: true}, evmc.Petersburg, true},
+		{params.Rules{IsIstanbul: true, IsYoloV2: true}, evmc.Berlin, true},
+		{params.Rules{IsIstanbul: true, IsYoloV2: true, IsEIP3529: true}, 0, false},
+	}
+	for i, tt := range tests {
+		revision, supported := evmcRevision(tt.rules)
+		if supported != tt.supported || (supported && revision != tt.revision) {
+			t.Errorf("test %d: revision mismatch: have %v/%v, want %v/%v", i, revision, supported, tt.revision, tt.supported)
+		}
+	}
+}
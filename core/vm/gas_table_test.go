@@ -105,3 +105,50 @@ func TestEIP2200(t *testing.T) {
 		}
 	}
 }
+
+var eip3529Tests = []struct {
+	original byte
+	input    string
+	used     uint64
+	refund   uint64
+}{
+	{1, "0x60006000556000600055", 5112, 4800},   // 1 -> 0 -> 0
+	{0, "0x60016000556000600055", 22212, 19900}, // 0 -> 1 -> 0
+	{1, "0x60006000556001600055", 5112, 2800},   // 1 -> 0 -> 1
+	{1, "0x60026000556000600055", 5112, 4800},   // 1 -> 2 -> 0
+	{1, "0x6000ff", 7603, 0},                    // selfdestruct
+}
+
+func TestEIP3529(t *testing.T) {
+	for i, tt := range eip3529Tests {
+		address := common.BytesToAddress([]byte("contract"))
+
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		statedb.CreateAccount(address)
+		statedb.SetCode(address, hexutil.MustDecode(tt.input))
+		statedb.SetState(address, common.Hash{}, common.BytesToHash([]byte{tt.original}))
+		statedb.Finalise(true) // Push the state into the "original" slot
+		statedb.AddAddressToAccessList(address)
+
+		vmctx := BlockContext{
+			CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+			Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+			BlockNumber: new(big.Int),
+		}
+		vmenv := NewEVM(vmctx, TxContext{}, statedb, params.AllEthashProtocolChanges, Config{ExtraEips: []int{2929, 3529}})
+
+		_, gas, err := vmenv.Call(AccountRef(common.Address{}), address, nil, math.MaxUint64, new(big.Int))
+		if err != nil {
+			t.Errorf("test %d: execution failed: %v", i, err)
+		}
+		if used := math.MaxUint64 - gas; used != tt.used {
+			t.Errorf("test %d: gas used mismatch: have %v, want %v", i, used, tt.used)
+		}
+		if refund := vmenv.StateDB.GetRefund(); refund != tt.refund {
+			t.Errorf("test %d: gas refund mismatch: have %v, want %v", i, refund, tt.refund)
+		}
+		if !vmenv.ChainRules().IsEIP3529 {
+			t.Errorf("test %d: extra eip not reflected in the chain rules", i)
+		}
+	}
+}
@@ -20,14 +20,14 @@ func (s StructLog) MarshalJSON() ([]byte, error) {
 		Op            OpCode                      `json:"op"`
 		Gas           math.HexOrDecimal64         `json:"gas"`
 		GasCost       math.HexOrDecimal64         `json:"gasCost"`
-		Memory        hexutil.Bytes               `json:"memory"`
+		Memory        hexutil.Bytes               `json:"memory,omitempty"`
 		MemorySize    int                         `json:"memSize"`
 		Stack         []*math.HexOrDecimal256     `json:"stack"`
 		ReturnStack   []math.HexOrDecimal64       `json:"returnStack"`
 		ReturnData    hexutil.Bytes               `json:"returnData"`
 		Storage       map[common.Hash]common.Hash `json:"-"`
 		Depth         int                         `json:"depth"`
-		RefundCounter uint64                      `json:"refund"`
+		RefundCounter math.HexOrDecimal64         `json:"refund"`
 		Err           error                       `json:"-"`
 		OpName        string                      `json:"opName"`
 		ErrorString   string                      `json:"error"`
@@ -54,7 +54,7 @@ func (s StructLog) MarshalJSON() ([]byte, error) {
 	enc.ReturnData = s.ReturnData
 	enc.Storage = s.Storage
 	enc.Depth = s.Depth
-	enc.RefundCounter = s.RefundCounter
+	enc.RefundCounter = math.HexOrDecimal64(s.RefundCounter)
 	enc.Err = s.Err
 	enc.OpName = s.OpName()
 	enc.ErrorString = s.ErrorString()
@@ -68,14 +68,14 @@ func (s *StructLog) UnmarshalJSON(input []byte) error {
 		Op            *OpCode                     `json:"op"`
 		Gas           *math.HexOrDecimal64        `json:"gas"`
 		GasCost       *math.HexOrDecimal64        `json:"gasCost"`
-		Memory        *hexutil.Bytes              `json:"memory"`
+		Memory        *hexutil.Bytes              `json:"memory,omitempty"`
 		MemorySize    *int                        `json:"memSize"`
 		Stack         []*math.HexOrDecimal256     `json:"stack"`
 		ReturnStack   []math.HexOrDecimal64       `json:"returnStack"`
 		ReturnData    *hexutil.Bytes              `json:"returnData"`
 		Storage       map[common.Hash]common.Hash `json:"-"`
 		Depth         *int                        `json:"depth"`
-		RefundCounter *uint64                     `json:"refund"`
+		RefundCounter *math.HexOrDecimal64        `json:"refund"`
 		Err           error                       `json:"-"`
 	}
 	var dec StructLog
@@ -122,7 +122,7 @@ func (s *StructLog) UnmarshalJSON(input []byte) error {
 		s.Depth = *dec.Depth
 	}
 	if dec.RefundCounter != nil {
-		s.RefundCounter = *dec.RefundCounter
+		s.RefundCounter = uint64(*dec.RefundCounter)
 	}
 	if dec.Err != nil {
 		s.Err = dec.Err
@@ -31,11 +31,13 @@ type Config struct {
 	Tracer                  Tracer // Opcode logger
 	NoRecursion             bool   // Disables call, callcode, delegate call and create
 	EnablePreimageRecording bool   // Enables recording of SHA3/keccak preimages
+	NoBaseFee               bool   // Forces the EIP-1559 baseFee to 0 (needed for 0 price calls)
 
 	JumpTable [256]*operation // EVM instruction table, automatically populated if unset
 
 	EWASMInterpreter string // External EWASM interpreter options
 	EVMInterpreter   string // External EVM interpreter options
+	EVMCDifferential bool   // Cross-checks the external EVM interpreter against the built-in one
 
 	ExtraEips []int // Additional EIPS that are to be enabled
 }
@@ -118,6 +120,18 @@ func NewEVMInterpreter(evm *EVM, cfg Config) *EVMInterpreter {
 		default:
 			jt = frontierInstructionSet
 		}
+		// Apply the individually scheduled EIPs on top of the fork's instruction set.
+		// The operations are shared between all instruction sets, so they need
+		// to be copied before being modified.
+		if evm.chainRules.IsEIP3529 || evm.chainRules.IsEIP3855 || len(cfg.ExtraEips) > 0 {
+			jt = copyJumpTable(jt)
+		}
+		if evm.chainRules.IsEIP3529 {
+			enable3529(&jt)
+		}
+		if evm.chainRules.IsEIP3855 {
+			enable3855(&jt)
+		}
 		for i, eip := range cfg.ExtraEips {
 			if err := EnableEIP(eip, &jt); err != nil {
 				// Disable it, so caller can check if it's activated or not
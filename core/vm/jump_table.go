@@ -1023,3 +1023,16 @@ func newFrontierInstructionSet() JumpTable {
 		},
 	}
 }
+
+// copyJumpTable returns a deep copy of the given jump table, so that the
+// operations can be modified without affecting the shared instruction sets.
+func copyJumpTable(source JumpTable) JumpTable {
+	var dest JumpTable
+	for i, op := range source {
+		if op != nil {
+			opCopy := *op
+			dest[i] = &opCopy
+		}
+	}
+	return dest
+}
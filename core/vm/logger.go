@@ -67,7 +67,7 @@ type StructLog struct {
 	Op            OpCode                      `json:"op"`
 	Gas           uint64                      `json:"gas"`
 	GasCost       uint64                      `json:"gasCost"`
-	Memory        []byte                      `json:"memory"`
+	Memory        []byte                      `json:"memory,omitempty"`
 	MemorySize    int                         `json:"memSize"`
 	Stack         []*big.Int                  `json:"stack"`
 	ReturnStack   []uint32                    `json:"returnStack"`
@@ -80,14 +80,15 @@ type StructLog struct {
 
 // overrides for gencodec
 type structLogMarshaling struct {
-	Stack       []*math.HexOrDecimal256
-	ReturnStack []math.HexOrDecimal64
-	Gas         math.HexOrDecimal64
-	GasCost     math.HexOrDecimal64
-	Memory      hexutil.Bytes
-	ReturnData  hexutil.Bytes
-	OpName      string `json:"opName"` // adds call to OpName() in MarshalJSON
-	ErrorString string `json:"error"`  // adds call to ErrorString() in MarshalJSON
+	Stack         []*math.HexOrDecimal256
+	ReturnStack   []math.HexOrDecimal64
+	Gas           math.HexOrDecimal64
+	GasCost       math.HexOrDecimal64
+	Memory        hexutil.Bytes
+	ReturnData    hexutil.Bytes
+	RefundCounter math.HexOrDecimal64
+	OpName        string `json:"opName"` // adds call to OpName() in MarshalJSON
+	ErrorString   string `json:"error"`  // adds call to ErrorString() in MarshalJSON
 }
 
 // OpName formats the operand name in a human-readable format.
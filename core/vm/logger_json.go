@@ -23,24 +23,51 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
 )
 
+// JSONLogger is an EVM tracer printing the execution steps in the EIP-3155
+// standard trace format, one JSON object per line.
 type JSONLogger struct {
 	encoder *json.Encoder
 	cfg     *LogConfig
+
+	awaitRoot bool         // Whether the summary waits for the post state root
+	summary   *jsonSummary // Summary of the execution waiting for the state root
+}
+
+// jsonSummary is the EIP-3155 summary of an execution, printed after its steps.
+type jsonSummary struct {
+	StateRoot *common.Hash        `json:"stateRoot,omitempty"`
+	Output    hexutil.Bytes       `json:"output"`
+	GasUsed   math.HexOrDecimal64 `json:"gasUsed"`
+	Pass      bool                `json:"pass"`
+	Time      time.Duration       `json:"time"`
+	Fork      string              `json:"fork,omitempty"`
+	Err       string              `json:"error,omitempty"`
 }
 
 // NewJSONLogger creates a new EVM tracer that prints execution steps as JSON objects
 // into the provided stream.
 func NewJSONLogger(cfg *LogConfig, writer io.Writer) *JSONLogger {
-	l := &JSONLogger{json.NewEncoder(writer), cfg}
+	l := &JSONLogger{encoder: json.NewEncoder(writer), cfg: cfg}
 	if l.cfg == nil {
 		l.cfg = &LogConfig{}
 	}
 	return l
 }
 
+// NewJSONLoggerWithStateRoot creates a JSON logger whose execution summary also
+// carries the post state root. As the root is only known once the caller has
+// finalized the transaction, the summary is held back until it is passed in
+// through CaptureStateRoot.
+func NewJSONLoggerWithStateRoot(cfg *LogConfig, writer io.Writer) *JSONLogger {
+	l := NewJSONLogger(cfg, writer)
+	l.awaitRoot = true
+	return l
+}
+
 func (l *JSONLogger) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
 	return nil
 }
@@ -81,16 +108,30 @@ func (l *JSONLogger) CaptureFault(env *EVM, pc uint64, op OpCode, gas, cost uint
 	return nil
 }
 
-// CaptureEnd is triggered at end of execution.
+// CaptureEnd outputs the EIP-3155 summary of the execution, or holds it back
+// until the state root is known if the logger awaits it.
 func (l *JSONLogger) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error {
-	type endLog struct {
-		Output  string              `json:"output"`
-		GasUsed math.HexOrDecimal64 `json:"gasUsed"`
-		Time    time.Duration       `json:"time"`
-		Err     string              `json:"error,omitempty"`
-	}
+	summary := &jsonSummary{Output: output, GasUsed: math.HexOrDecimal64(gasUsed), Pass: err == nil, Time: t}
 	if err != nil {
-		return l.encoder.Encode(endLog{common.Bytes2Hex(output), math.HexOrDecimal64(gasUsed), t, err.Error()})
+		summary.Err = err.Error()
+	}
+	if l.awaitRoot {
+		l.summary = summary
+		return nil
+	}
+	return l.encoder.Encode(summary)
+}
+
+// CaptureStateRoot completes the summary of the last execution with the post
+// state root and the fork it ran on, if known, and outputs it. If nothing was
+// executed, the summary only reports the state root.
+func (l *JSONLogger) CaptureStateRoot(root common.Hash, fork string) error {
+	summary := l.summary
+	if summary == nil {
+		summary = new(jsonSummary)
 	}
-	return l.encoder.Encode(endLog{common.Bytes2Hex(output), math.HexOrDecimal64(gasUsed), t, ""})
+	l.summary = nil
+
+	summary.StateRoot, summary.Fork = &root, fork
+	return l.encoder.Encode(summary)
 }
@@ -17,6 +17,7 @@
 package vm
 
 import (
+	"bytes"
 	"math/big"
 	"testing"
 
@@ -70,3 +71,24 @@ func TestStoreCapture(t *testing.T) {
 		t.Errorf("expected %x, got %x", exp, logger.storage[contract.Address()][index])
 	}
 }
+
+func TestJSONLoggerFormat(t *testing.T) {
+	var (
+		out      bytes.Buffer
+		env      = NewEVM(BlockContext{}, TxContext{}, &dummyStatedb{}, params.TestChainConfig, Config{})
+		logger   = NewJSONLoggerWithStateRoot(&LogConfig{DisableMemory: true}, &out)
+		stack    = newstack()
+		contract = NewContract(&dummyContractRef{}, &dummyContractRef{}, new(big.Int), 0)
+	)
+	stack.push(uint256.NewInt().SetUint64(0x2a))
+	logger.CaptureState(env, 1, POP, 10, 2, NewMemory(), stack, newReturnStack(), []byte{1}, contract, 1, nil)
+	logger.CaptureEnd([]byte{2}, 0x10, 0, nil)
+	logger.CaptureStateRoot(common.Hash{0xff}, "Berlin")
+
+	want := `{"pc":1,"op":80,"gas":"0xa","gasCost":"0x2","memSize":0,"stack":["0x2a"],"returnStack":[],"returnData":"0x01","depth":1,"refund":"0x539","opName":"POP","error":""}
+{"stateRoot":"0xff00000000000000000000000000000000000000000000000000000000000000","output":"0x02","gasUsed":"0x10","pass":true,"time":0,"fork":"Berlin"}
+`
+	if have := out.String(); have != want {
+		t.Errorf("output mismatch:\nhave %s\nwant %s", have, want)
+	}
+}
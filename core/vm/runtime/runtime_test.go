@@ -124,6 +124,68 @@ func TestCall(t *testing.T) {
 	}
 }
 
+// TestEIP3855 tests that PUSH0 is only available once EIP-3855 is activated,
+// either through the chain config or as an extra eip.
+func TestEIP3855(t *testing.T) {
+	code := []byte{
+		byte(vm.PUSH1), 10,
+		byte(vm.PUSH0),
+		byte(vm.MSTORE),
+		byte(vm.PUSH1), 32,
+		byte(vm.PUSH0),
+		byte(vm.RETURN),
+	}
+	if _, _, err := Execute(code, nil, nil); err == nil {
+		t.Fatal("expected PUSH0 to be invalid before EIP-3855")
+	}
+	config := *params.AllEthashProtocolChanges
+	config.EIP3855Block = new(big.Int)
+
+	for i, cfg := range []*Config{
+		{ChainConfig: &config},
+		{EVMConfig: vm.Config{ExtraEips: []int{3855}}},
+	} {
+		ret, _, err := Execute(code, nil, cfg)
+		if err != nil {
+			t.Fatalf("test %d: didn't expect error: %v", i, err)
+		}
+		if num := new(big.Int).SetBytes(ret); num.Cmp(big.NewInt(10)) != 0 {
+			t.Errorf("test %d: expected 10, got %v", i, num)
+		}
+	}
+}
+
+// TestEIP3541 tests that new contract code starting with the 0xEF byte is
+// rejected once EIP-3541 is activated.
+func TestEIP3541(t *testing.T) {
+	initcode := []byte{
+		byte(vm.PUSH1), 0xEF,
+		byte(vm.PUSH1), 0,
+		byte(vm.MSTORE8),
+		byte(vm.PUSH1), 1,
+		byte(vm.PUSH1), 0,
+		byte(vm.RETURN),
+	}
+	if _, _, _, err := Create(initcode, &Config{GasLimit: 100000}); err != nil {
+		t.Fatalf("didn't expect error before EIP-3541: %v", err)
+	}
+	config := *params.AllEthashProtocolChanges
+	config.EIP3541Block = new(big.Int)
+
+	for i, cfg := range []*Config{
+		{ChainConfig: &config, GasLimit: 100000},
+		{EVMConfig: vm.Config{ExtraEips: []int{3541}}, GasLimit: 100000},
+	} {
+		_, _, gas, err := Create(initcode, cfg)
+		if err != vm.ErrInvalidCode {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, vm.ErrInvalidCode)
+		}
+		if gas != 0 {
+			t.Errorf("test %d: expected all gas to be consumed, %d left", i, gas)
+		}
+	}
+}
+
 func BenchmarkCall(b *testing.B) {
 	var definition = `[{"constant":true,"inputs":[],"name":"seller","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"abort","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"value","outputs":[{"name":"","type":"uint256"}],"type":"function"},{"constant":false,"inputs":[],"name":"refund","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"buyer","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmReceived","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"state","outputs":[{"name":"","type":"uint8"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmPurchase","outputs":[],"type":"function"},{"inputs":[],"type":"constructor"},{"anonymous":false,"inputs":[],"name":"Aborted","type":"event"},{"anonymous":false,"inputs":[],"name":"PurchaseConfirmed","type":"event"},{"anonymous":false,"inputs":[],"name":"ItemReceived","type":"event"},{"anonymous":false,"inputs":[],"name":"Refunded","type":"event"}]`
 
@@ -33,8 +33,11 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/stateless"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/miner"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethereum/go-ethereum/trie"
@@ -75,6 +78,36 @@ func (api *PublicEthereumAPI) ChainId() hexutil.Uint64 {
 	return (hexutil.Uint64)(chainID.Uint64())
 }
 
+// PublicNetworkStatsAPI provides an API to access historical network difficulty
+// and hashrate statistics.
+type PublicNetworkStatsAPI struct {
+	e *Ethereum
+}
+
+// NewPublicNetworkStatsAPI creates a new network statistics API.
+func NewPublicNetworkStatsAPI(e *Ethereum) *PublicNetworkStatsAPI {
+	return &PublicNetworkStatsAPI{e}
+}
+
+// NetworkStats returns the average difficulty, block time and estimated network
+// hashrate of the canonical blocks in the range [from, to], aggregated into
+// consecutive windows of the given number of blocks. Windows are aligned to the
+// sections of the network statistics index.
+func (api *PublicNetworkStatsAPI) NetworkStats(from, to rpc.BlockNumber, window *hexutil.Uint64) ([]*NetworkStats, error) {
+	head := rpc.BlockNumber(api.e.blockchain.CurrentHeader().Number.Uint64())
+	if from < 0 {
+		from = head
+	}
+	if to < 0 {
+		to = head
+	}
+	size := params.ChainStatsBlocks
+	if window != nil {
+		size = uint64(*window)
+	}
+	return api.e.networkStats(uint64(from), uint64(to), size)
+}
+
 // PublicMinerAPI provides an API to control the miner.
 // It offers only methods that operate on data that pose no security risk when it is publicly accessible.
 type PublicMinerAPI struct {
@@ -154,6 +187,160 @@ func (api *PrivateMinerAPI) GetHashrate() uint64 {
 	return api.e.miner.HashRate()
 }
 
+// SetGasLimit sets the gas ceiling the miner never raises the block gas limit above.
+func (api *PrivateMinerAPI) SetGasLimit(gasLimit hexutil.Uint64) (bool, error) {
+	if err := api.e.Miner().SetGasCeil(uint64(gasLimit)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetGasTarget sets the gas target the miner moves the block gas limit towards.
+func (api *PrivateMinerAPI) SetGasTarget(gasTarget hexutil.Uint64) (bool, error) {
+	if err := api.e.Miner().SetGasFloor(uint64(gasTarget)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetBuildStats returns statistics about the most recently assembled mining
+// blocks, newest first. If count is nil, all retained entries are returned.
+func (api *PrivateMinerAPI) GetBuildStats(count *int) []*miner.BuildStats {
+	if count == nil {
+		return api.e.Miner().BuildStats(0)
+	}
+	return api.e.Miner().BuildStats(*count)
+}
+
+// PrivateDevAPI provides private RPC methods to control instantly sealed
+// development chains, mining blocks on demand and manipulating time and state.
+// The blocks sealed to apply state modifications or to include impersonated
+// transactions cannot be re-executed, so tracing them is refused.
+type PrivateDevAPI struct {
+	e   *Ethereum
+	dev *devChain
+}
+
+// NewPrivateDevAPI creates a new RPC service which controls the development
+// chain of this node.
+func NewPrivateDevAPI(e *Ethereum) *PrivateDevAPI {
+	return &PrivateDevAPI{e: e, dev: e.devChain}
+}
+
+// Mine seals the given number of blocks (1 if omitted) with the pending
+// transactions, returning their hashes.
+func (api *PrivateDevAPI) Mine(blocks *hexutil.Uint64) ([]common.Hash, error) {
+	count := uint64(1)
+	if blocks != nil {
+		count = uint64(*blocks)
+	}
+	if count > maxDevMineBlocks {
+		return nil, fmt.Errorf("too many blocks requested: %d > %d", count, maxDevMineBlocks)
+	}
+	return api.dev.mine(int(count))
+}
+
+// SetAutomine toggles whether a block is sealed for every new transaction.
+func (api *PrivateDevAPI) SetAutomine(enabled bool) bool {
+	api.dev.setAutomine(enabled)
+	return true
+}
+
+// SetNextBlockTimestamp sets the timestamp of the next block. Subsequent blocks
+// continue counting time from there.
+func (api *PrivateDevAPI) SetNextBlockTimestamp(timestamp hexutil.Uint64) (bool, error) {
+	if err := api.dev.setNextBlockTimestamp(uint64(timestamp)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// IncreaseTime moves the clock of future blocks ahead by the given number of
+// seconds, returning the total offset from the wall clock.
+func (api *PrivateDevAPI) IncreaseTime(seconds hexutil.Uint64) int64 {
+	return api.dev.increaseTime(uint64(seconds))
+}
+
+// Snapshot records the current head of the chain, returning an id to revert to.
+func (api *PrivateDevAPI) Snapshot() hexutil.Uint64 {
+	return hexutil.Uint64(api.dev.snapshot())
+}
+
+// Revert rewinds the chain to the given snapshot, discarding the snapshot and
+// all the ones taken after it.
+func (api *PrivateDevAPI) Revert(id hexutil.Uint64) (bool, error) {
+	if err := api.dev.revert(uint64(id)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetBalance sets the balance of an account, sealing a block to apply it.
+func (api *PrivateDevAPI) SetBalance(address common.Address, balance hexutil.Big) (common.Hash, error) {
+	return api.dev.modify(func(statedb *state.StateDB) {
+		statedb.SetBalance(address, balance.ToInt())
+	})
+}
+
+// SetNonce sets the nonce of an account, sealing a block to apply it.
+func (api *PrivateDevAPI) SetNonce(address common.Address, nonce hexutil.Uint64) (common.Hash, error) {
+	return api.dev.modify(func(statedb *state.StateDB) {
+		statedb.SetNonce(address, uint64(nonce))
+	})
+}
+
+// SetCode sets the code of an account, sealing a block to apply it.
+func (api *PrivateDevAPI) SetCode(address common.Address, code hexutil.Bytes) (common.Hash, error) {
+	return api.dev.modify(func(statedb *state.StateDB) {
+		statedb.SetCode(address, code)
+	})
+}
+
+// SetStorageAt sets a storage slot of an account, sealing a block to apply it.
+// Note, the storage of otherwise empty accounts is cleared as per EIP-161.
+func (api *PrivateDevAPI) SetStorageAt(address common.Address, slot common.Hash, value common.Hash) (common.Hash, error) {
+	return api.dev.modify(func(statedb *state.StateDB) {
+		statedb.SetState(address, slot, value)
+	})
+}
+
+// ImpersonateAccount allows sending transactions on behalf of the given account
+// through SendTransaction, without access to its key.
+func (api *PrivateDevAPI) ImpersonateAccount(address common.Address) bool {
+	api.dev.impersonate(address, true)
+	return true
+}
+
+// StopImpersonatingAccount revokes a previous ImpersonateAccount.
+func (api *PrivateDevAPI) StopImpersonatingAccount(address common.Address) bool {
+	api.dev.impersonate(address, false)
+	return true
+}
+
+// SendTransaction sends an unsigned transaction on behalf of an impersonated
+// account, returning its hash. The transaction bypasses the transaction pool
+// and is included in the next sealed block. As it carries no signature, its
+// sender cannot be recovered when the transaction is retrieved later on, and
+// the block including it cannot be traced.
+func (api *PrivateDevAPI) SendTransaction(ctx context.Context, args ethapi.SendTxArgs) (common.Hash, error) {
+	if args.Nonce == nil {
+		nonce, err := api.dev.impersonatedNonce(args.From)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		args.Nonce = (*hexutil.Uint64)(&nonce)
+	}
+	filled, err := ethapi.NewPublicTransactionPoolAPI(api.e.APIBackend, new(ethapi.AddrLocker)).FillTransaction(ctx, args)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	tx := filled.Tx
+	if err := api.dev.sendImpersonated(args.From, tx); err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
 // PrivateAdminAPI is the collection of Ethereum full node-related APIs
 // exposed over the private admin endpoint.
 type PrivateAdminAPI struct {
@@ -264,6 +451,21 @@ func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
 	return true, nil
 }
 
+// TxPolicy returns the admission policy currently enforced by the transaction
+// pool, or nil if none is configured.
+func (api *PrivateAdminAPI) TxPolicy() *core.TxPolicy {
+	return api.eth.TxPool().Policy()
+}
+
+// ReloadTxPolicy re-reads the transaction pool admission policy from its
+// configured file and drops pooled transactions that no longer satisfy it.
+func (api *PrivateAdminAPI) ReloadTxPolicy() (bool, error) {
+	if err := api.eth.TxPool().ReloadPolicy(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // PublicDebugAPI is the collection of Ethereum full node APIs exposed
 // over the public debugging endpoint.
 type PublicDebugAPI struct {
@@ -301,6 +503,46 @@ func (api *PublicDebugAPI) DumpBlock(blockNr rpc.BlockNumber) (state.Dump, error
 	return stateDb.RawDump(false, false, true), nil
 }
 
+// SideBlocks retrieves the blocks in the inclusive range [from, to] that were
+// seen by the local node but did not end up in the canonical chain.
+func (api *PublicDebugAPI) SideBlocks(from, to rpc.BlockNumber) ([]*SideBlockInfo, error) {
+	if api.eth.sideTracker == nil {
+		return nil, errSideBlocksDisabled
+	}
+	head := rpc.BlockNumber(api.eth.blockchain.CurrentBlock().NumberU64())
+	if from < 0 {
+		from = head
+	}
+	if to < 0 {
+		to = head
+	}
+	if from > to {
+		return nil, fmt.Errorf("invalid range: from %d > to %d", from, to)
+	}
+	if to-from >= maxSideChainRange {
+		return nil, fmt.Errorf("range too large: %d blocks > %d", to-from+1, maxSideChainRange)
+	}
+	return api.eth.sideTracker.sideBlockInfos(uint64(from), uint64(to)), nil
+}
+
+// UncleStats aggregates uncle and side block statistics over windows of the
+// given sizes, each ending at the current chain head.
+func (api *PublicDebugAPI) UncleStats(windows []hexutil.Uint64) ([]*UncleStats, error) {
+	if api.eth.sideTracker == nil {
+		return nil, errSideBlocksDisabled
+	}
+	for _, window := range windows {
+		if window == 0 || window > maxSideChainRange {
+			return nil, fmt.Errorf("invalid window %d, must be between 1 and %d", window, maxSideChainRange)
+		}
+	}
+	stats := make([]*UncleStats, len(windows))
+	for i, window := range windows {
+		stats[i] = api.eth.sideTracker.uncleStats(uint64(window))
+	}
+	return stats, nil
+}
+
 // PrivateDebugAPI is the collection of Ethereum full node APIs exposed over
 // the private debugging endpoint.
 type PrivateDebugAPI struct {
@@ -535,3 +777,23 @@ func (api *PrivateDebugAPI) getModifiedAccounts(startBlock, endBlock *types.Bloc
 	}
 	return dirty, nil
 }
+
+// GetBlockWitness returns the RLP encoded witness of a block, containing the
+// block itself along with the trie nodes, contract code and ancestor headers
+// needed to re-execute it without a database. The state of the parent block
+// must be available.
+func (api *PrivateDebugAPI) GetBlockWitness(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	block, err := api.eth.APIBackend.BlockByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.New("block not found")
+	}
+	chain := api.eth.BlockChain()
+	witness, err := stateless.Record(chain, chain.Engine(), chain.StateCache(), block)
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(witness)
+}
@@ -0,0 +1,946 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Trace types which can be requested when replaying transactions.
+const (
+	traceTypeTrace     = "trace"     // Flat list of the calls made
+	traceTypeStateDiff = "stateDiff" // Changes made to the state
+	traceTypeVMTrace   = "vmTrace"   // Full trace of the executed instructions
+)
+
+// errGenesisTrace is returned when attempting to trace the genesis block, which
+// has no transactions and pays no rewards.
+var errGenesisTrace = errors.New("genesis is not traceable")
+
+// errTraceIndexDisabled is returned when filtering traces without the call trace
+// index enabled.
+var errTraceIndexDisabled = errors.New("call trace index disabled")
+
+// maxTraceFilterRange is the maximum number of blocks a trace filter without
+// address criteria may span, as it lists the traces of every block.
+const maxTraceFilterRange = 10000
+
+// TraceFilterArgs are the criteria of the traces to retrieve from the call
+// trace index. Traces match if they were made from any of the from addresses
+// to any of the to addresses, an empty list matching all addresses.
+type TraceFilterArgs struct {
+	FromBlock   *rpc.BlockNumber `json:"fromBlock"`   // First block to search, the oldest indexed one if nil
+	ToBlock     *rpc.BlockNumber `json:"toBlock"`     // Last block to search, the newest indexed one if nil
+	FromAddress []common.Address `json:"fromAddress"` // Senders of the calls
+	ToAddress   []common.Address `json:"toAddress"`   // Recipients of the calls, created contracts or reward authors
+	After       uint64           `json:"after"`       // Number of matching traces to skip
+	Count       *uint64          `json:"count"`       // Maximum number of traces to return, unlimited if nil
+}
+
+// ParityTrace is a single call, contract creation, self-destruct or reward of
+// a transaction or block, in the flat trace format of OpenEthereum.
+type ParityTrace struct {
+	Action       interface{}        // One of the parity*Action types
+	Result       interface{}        // One of the parity*Result types, nil if failed or none
+	Error        string             // Failure of the call, empty if successful
+	Revert       *ethapi.RevertData // Decoded revert data of a reverted call, nil otherwise
+	Subtraces    int                // Number of calls made directly by this call
+	TraceAddress []int              // Path of the call within the call tree
+	Type         string             // Type of the action: call, create, suicide or reward
+
+	BlockHash           *common.Hash // Block of the trace, nil if not localized
+	BlockNumber         uint64       // Number of the block of the trace
+	TransactionHash     *common.Hash // Transaction of the trace, nil for rewards
+	TransactionPosition *uint64      // Index of the transaction, nil for rewards
+}
+
+// MarshalJSON marshals the trace as OpenEthereum does, omitting the result of
+// failed calls and the block details of traces not localized in a block.
+func (t *ParityTrace) MarshalJSON() ([]byte, error) {
+	enc := map[string]interface{}{
+		"action":       t.Action,
+		"subtraces":    t.Subtraces,
+		"traceAddress": t.TraceAddress,
+		"type":         t.Type,
+	}
+	if t.Error != "" {
+		enc["error"] = t.Error
+		if t.Revert != nil {
+			enc["revert"] = t.Revert
+		}
+	} else {
+		enc["result"] = t.Result
+	}
+	if t.BlockHash != nil {
+		enc["blockHash"] = t.BlockHash
+		enc["blockNumber"] = t.BlockNumber
+		enc["transactionHash"] = t.TransactionHash
+		enc["transactionPosition"] = t.TransactionPosition
+	}
+	return json.Marshal(enc)
+}
+
+// parityCallAction is the action of a message call.
+type parityCallAction struct {
+	CallType string         `json:"callType"`
+	From     common.Address `json:"from"`
+	Gas      hexutil.Uint64 `json:"gas"`
+	Input    hexutil.Bytes  `json:"input"`
+	To       common.Address `json:"to"`
+	Value    *hexutil.Big   `json:"value"`
+}
+
+// parityCallResult is the result of a successful message call.
+type parityCallResult struct {
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+	Output  hexutil.Bytes  `json:"output"`
+}
+
+// parityCreateAction is the action of a contract creation.
+type parityCreateAction struct {
+	From  common.Address `json:"from"`
+	Gas   hexutil.Uint64 `json:"gas"`
+	Init  hexutil.Bytes  `json:"init"`
+	Value *hexutil.Big   `json:"value"`
+}
+
+// parityCreateResult is the result of a successful contract creation.
+type parityCreateResult struct {
+	Address common.Address `json:"address"`
+	Code    hexutil.Bytes  `json:"code"`
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+}
+
+// paritySuicideAction is the action of a contract self-destruct.
+type paritySuicideAction struct {
+	Address       common.Address `json:"address"`
+	Balance       *hexutil.Big   `json:"balance"`
+	RefundAddress common.Address `json:"refundAddress"`
+}
+
+// parityRewardAction is the action of a block or uncle reward.
+type parityRewardAction struct {
+	Author     common.Address `json:"author"`
+	RewardType string         `json:"rewardType"`
+	Value      *hexutil.Big   `json:"value"`
+}
+
+// parityAccountDiff is the changes made to a single account, where each field
+// is "=" if unchanged, {"+": new} if created, {"-": old} if deleted, or else
+// {"*": {"from": old, "to": new}}.
+type parityAccountDiff struct {
+	Balance interface{}                 `json:"balance"`
+	Code    interface{}                 `json:"code"`
+	Nonce   interface{}                 `json:"nonce"`
+	Storage map[common.Hash]interface{} `json:"storage"`
+}
+
+// TraceResults is the result of replaying a transaction with the requested
+// trace types, the ones not requested being empty.
+type TraceResults struct {
+	Output          hexutil.Bytes                         `json:"output"`
+	StateDiff       map[common.Address]*parityAccountDiff `json:"stateDiff"`
+	Trace           []*ParityTrace                        `json:"trace"`
+	VMTrace         json.RawMessage                       `json:"vmTrace"`
+	TransactionHash *common.Hash                          `json:"transactionHash,omitempty"`
+}
+
+// tracedCall is a call in the output of the native call tracer.
+type tracedCall struct {
+	Type    string         `json:"type"`
+	From    common.Address `json:"from"`
+	To      common.Address `json:"to"`
+	Value   *hexutil.Big   `json:"value"`
+	Gas     hexutil.Uint64 `json:"gas"`
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+	Input   hexutil.Bytes  `json:"input"`
+	Output  hexutil.Bytes  `json:"output"`
+	Error   string         `json:"error"`
+	Calls   []*tracedCall  `json:"calls"`
+}
+
+// tracedAccount is an account in the diff mode output of the native prestate
+// tracer, with the unchanged fields omitted.
+type tracedAccount struct {
+	Balance *hexutil.Big                `json:"balance"`
+	Nonce   uint64                      `json:"nonce"`
+	Code    hexutil.Bytes               `json:"code"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+// empty reports whether the account didn't exist in the state.
+func (acc *tracedAccount) empty() bool {
+	return (acc.Balance == nil || acc.Balance.ToInt().Sign() == 0) && acc.Nonce == 0 && len(acc.Code) == 0 && len(acc.Storage) == 0
+}
+
+// tracedDiff is the diff mode output of the native prestate tracer.
+type tracedDiff struct {
+	Pre  map[common.Address]*tracedAccount `json:"pre"`
+	Post map[common.Address]*tracedAccount `json:"post"`
+}
+
+// traceTypes is the set of trace types requested for a replay.
+type traceTypes struct {
+	trace     bool
+	stateDiff bool
+	vmTrace   bool
+}
+
+// parseTraceTypes validates the requested trace types.
+func parseTraceTypes(types []string) (traceTypes, error) {
+	var res traceTypes
+	for _, typ := range types {
+		switch typ {
+		case traceTypeTrace:
+			res.trace = true
+		case traceTypeStateDiff:
+			res.stateDiff = true
+		case traceTypeVMTrace:
+			res.vmTrace = true
+		default:
+			return res, fmt.Errorf("unknown trace type %q", typ)
+		}
+	}
+	return res, nil
+}
+
+// replayResult is the outcome of replaying a single message.
+type replayResult struct {
+	output    []byte
+	call      *tracedCall // Call tree, if traces were requested
+	stateDiff *tracedDiff // State changes, if requested
+	vmTrace   json.RawMessage
+}
+
+// PrivateTraceAPI is the collection of OpenEthereum compatible tracing APIs,
+// replaying transactions with the native call, prestate and VM tracers.
+type PrivateTraceAPI struct {
+	eth   *Ethereum
+	debug *PrivateDebugAPI
+}
+
+// NewPrivateTraceAPI creates a new API definition for the OpenEthereum
+// compatible tracing methods of the Ethereum service.
+func NewPrivateTraceAPI(eth *Ethereum) *PrivateTraceAPI {
+	return &PrivateTraceAPI{eth: eth, debug: NewPrivateDebugAPI(eth)}
+}
+
+// Block returns the flat traces of all the transactions within a block, along
+// with the rewards paid to the miners if the chain is proof-of-work.
+func (api *PrivateTraceAPI) Block(ctx context.Context, number rpc.BlockNumber) ([]*ParityTrace, error) {
+	block, err := api.blockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	results, err := api.replayBlock(ctx, block, traceTypes{trace: true})
+	if err != nil {
+		return nil, err
+	}
+	var calls []*rawdb.CallTrace
+	for i, res := range results {
+		calls = flattenCalls(res.call, uint64(i), nil, calls)
+	}
+	traces := localize(calls, block, api.eth.APIBackend.RPCSelectorDB())
+	return append(traces, api.rewards(block)...), nil
+}
+
+// Transaction returns the flat traces of all the calls made by a transaction.
+func (api *PrivateTraceAPI) Transaction(ctx context.Context, hash common.Hash) ([]*ParityTrace, error) {
+	block, index, res, err := api.replayTransaction(ctx, hash, traceTypes{trace: true})
+	if err != nil {
+		return nil, err
+	}
+	return localize(flattenCalls(res.call, uint64(index), nil, nil), block, api.eth.APIBackend.RPCSelectorDB()), nil
+}
+
+// Get returns the trace of the call at the given trace address within a
+// transaction, or nil if there is no such call.
+func (api *PrivateTraceAPI) Get(ctx context.Context, hash common.Hash, indices []hexutil.Uint64) (*ParityTrace, error) {
+	traces, err := api.Transaction(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	for _, trace := range traces {
+		if len(trace.TraceAddress) != len(indices) {
+			continue
+		}
+		match := true
+		for i, index := range indices {
+			if trace.TraceAddress[i] != int(index) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return trace, nil
+		}
+	}
+	return nil, nil
+}
+
+// ReplayTransaction replays a transaction, returning the requested traces.
+func (api *PrivateTraceAPI) ReplayTransaction(ctx context.Context, hash common.Hash, types []string) (*TraceResults, error) {
+	modes, err := parseTraceTypes(types)
+	if err != nil {
+		return nil, err
+	}
+	_, _, res, err := api.replayTransaction(ctx, hash, modes)
+	if err != nil {
+		return nil, err
+	}
+	return res.results(api.eth.APIBackend.RPCSelectorDB()), nil
+}
+
+// ReplayBlockTransactions replays all the transactions within a block,
+// returning the requested traces of each.
+func (api *PrivateTraceAPI) ReplayBlockTransactions(ctx context.Context, number rpc.BlockNumber, types []string) ([]*TraceResults, error) {
+	modes, err := parseTraceTypes(types)
+	if err != nil {
+		return nil, err
+	}
+	block, err := api.blockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	replays, err := api.replayBlock(ctx, block, modes)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*TraceResults, len(replays))
+	for i, res := range replays {
+		hash := block.Transactions()[i].Hash()
+
+		results[i] = res.results(api.eth.APIBackend.RPCSelectorDB())
+		results[i].TransactionHash = &hash
+	}
+	return results, nil
+}
+
+// Filter returns the traces matching the given criteria, served from the call
+// trace index without re-executing any transactions.
+func (api *PrivateTraceAPI) Filter(ctx context.Context, args TraceFilterArgs) ([]*ParityTrace, error) {
+	if api.eth.callTraceIndexer == nil {
+		return nil, errTraceIndexDisabled
+	}
+	db := api.eth.ChainDb()
+	tail, head := rawdb.ReadCallTraceTail(db), rawdb.ReadCallTraceHead(db)
+	if tail == nil || head == nil || *head < *tail {
+		return nil, errors.New("no blocks indexed yet")
+	}
+	from, to := *tail, *head
+	if args.FromBlock != nil {
+		from = api.resolveBlockNumber(*args.FromBlock)
+	}
+	if args.ToBlock != nil && *args.ToBlock != rpc.LatestBlockNumber && *args.ToBlock != rpc.PendingBlockNumber {
+		to = api.resolveBlockNumber(*args.ToBlock)
+	}
+	if from > to {
+		return nil, fmt.Errorf("invalid range: from %d > to %d", from, to)
+	}
+	if from < *tail || to > *head {
+		return nil, fmt.Errorf("blocks outside of indexed range #%d-#%d", *tail, *head)
+	}
+	// Only look into the blocks touching the requested addresses if any
+	var numbers []uint64
+	switch {
+	case len(args.FromAddress) > 0:
+		numbers = callTraceBlocks(db, args.FromAddress, from, to)
+	case len(args.ToAddress) > 0:
+		numbers = callTraceBlocks(db, args.ToAddress, from, to)
+	default:
+		if to-from >= maxTraceFilterRange {
+			return nil, fmt.Errorf("range too large: %d blocks > %d, filter by address instead", to-from+1, maxTraceFilterRange)
+		}
+		for number := from; number <= to; number++ {
+			numbers = append(numbers, number)
+		}
+	}
+	var (
+		skip    = args.After
+		matches = []*ParityTrace{}
+	)
+	for _, number := range numbers {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		traces := rawdb.ReadCallTraces(db, number)
+		if traces == nil {
+			continue
+		}
+		block := api.eth.blockchain.GetBlock(traces.Hash, number)
+		if block == nil {
+			return nil, fmt.Errorf("block #%d not found", number)
+		}
+		var calls []*rawdb.CallTrace
+		for _, call := range traces.Traces {
+			if args.matches(&call.From, call.To) {
+				calls = append(calls, call)
+			}
+		}
+		candidates := localize(calls, block, api.eth.APIBackend.RPCSelectorDB())
+		for _, reward := range api.rewards(block) {
+			if args.matches(nil, reward.Action.(*parityRewardAction).Author) {
+				candidates = append(candidates, reward)
+			}
+		}
+		for _, trace := range candidates {
+			if skip > 0 {
+				skip--
+				continue
+			}
+			if args.Count != nil && uint64(len(matches)) >= *args.Count {
+				return matches, nil
+			}
+			matches = append(matches, trace)
+		}
+	}
+	return matches, nil
+}
+
+// TraceIndexStatus is the progress of the call trace index.
+type TraceIndexStatus struct {
+	Tail      *hexutil.Uint64 `json:"tail"`                // Oldest indexed block, nil if none
+	Head      *hexutil.Uint64 `json:"head"`                // Newest indexed block, nil if none
+	Lag       hexutil.Uint64  `json:"lag"`                 // Number of canonical blocks not yet indexed
+	Failed    hexutil.Uint64  `json:"failed"`              // Number of blocks indexed without traces since startup
+	LastBlock *hexutil.Uint64 `json:"lastBlock,omitempty"` // Last block indexed without traces, nil if none
+	LastError string          `json:"lastError,omitempty"` // Failure tracing the last block indexed without traces
+}
+
+// IndexStatus returns the progress of the call trace index, along with the
+// blocks which failed to be traced and were indexed without traces.
+func (api *PrivateTraceAPI) IndexStatus() (*TraceIndexStatus, error) {
+	if api.eth.callTraceIndexer == nil {
+		return nil, errTraceIndexDisabled
+	}
+	var (
+		db     = api.eth.ChainDb()
+		status = new(TraceIndexStatus)
+		head   = api.eth.blockchain.CurrentBlock().NumberU64()
+	)
+	if tail := rawdb.ReadCallTraceTail(db); tail != nil {
+		status.Tail = (*hexutil.Uint64)(tail)
+	}
+	if indexed := rawdb.ReadCallTraceHead(db); indexed != nil {
+		status.Head = (*hexutil.Uint64)(indexed)
+		if *indexed < head {
+			status.Lag = hexutil.Uint64(head - *indexed)
+		}
+	} else {
+		status.Lag = hexutil.Uint64(head)
+	}
+	failed, last, err := api.eth.callTraceIndexer.status()
+	status.Failed = hexutil.Uint64(failed)
+	if err != nil {
+		status.LastBlock = (*hexutil.Uint64)(&last)
+		status.LastError = err.Error()
+	}
+	return status, nil
+}
+
+// Call executes a call on top of the given block's state without creating a
+// transaction, returning the requested traces.
+func (api *PrivateTraceAPI) Call(ctx context.Context, args ethapi.CallArgs, types []string, blockNrOrHash *rpc.BlockNumberOrHash) (*TraceResults, error) {
+	modes, err := parseTraceTypes(types)
+	if err != nil {
+		return nil, err
+	}
+	if blockNrOrHash == nil {
+		latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &latest
+	}
+	statedb, header, err := api.eth.APIBackend.StateAndHeaderByNumberOrHash(ctx, *blockNrOrHash)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	msg, err := args.ToMessage(api.eth.APIBackend.RPCGasCap(), header.BaseFee)
+	if err != nil {
+		return nil, err
+	}
+	vmctx := core.NewEVMBlockContext(header, api.eth.blockchain, nil)
+
+	res, err := api.replay(ctx, msg, vmctx, statedb, modes)
+	if err != nil {
+		return nil, err
+	}
+	return res.results(api.eth.APIBackend.RPCSelectorDB()), nil
+}
+
+// resolveBlockNumber converts a block number into an absolute one.
+func (api *PrivateTraceAPI) resolveBlockNumber(number rpc.BlockNumber) uint64 {
+	switch number {
+	case rpc.LatestBlockNumber, rpc.PendingBlockNumber:
+		return api.eth.blockchain.CurrentBlock().NumberU64()
+	case rpc.EarliestBlockNumber:
+		return 0
+	}
+	return uint64(number)
+}
+
+// blockByNumber retrieves a block by number, failing if it's unknown.
+func (api *PrivateTraceAPI) blockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	block, err := api.eth.APIBackend.BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	return block, nil
+}
+
+// replayTransaction replays a transaction on top of the state it was executed
+// on, returning the block it's in and its index within.
+func (api *PrivateTraceAPI) replayTransaction(ctx context.Context, hash common.Hash, modes traceTypes) (*types.Block, int, *replayResult, error) {
+	tx, blockHash, _, index := rawdb.ReadTransaction(api.eth.ChainDb(), hash)
+	if tx == nil {
+		return nil, 0, nil, fmt.Errorf("transaction %#x not found", hash)
+	}
+	block := api.eth.blockchain.GetBlockByHash(blockHash)
+	if block == nil {
+		return nil, 0, nil, fmt.Errorf("block %#x not found", blockHash)
+	}
+	msg, vmctx, statedb, err := api.debug.computeTxEnv(block, int(index), defaultTraceReexec)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	res, err := api.replay(ctx, msg, vmctx, statedb, modes)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return block, int(index), res, nil
+}
+
+// replayBlock replays all the transactions of a block one after the other on
+// top of its parent's state.
+func (api *PrivateTraceAPI) replayBlock(ctx context.Context, block *types.Block, modes traceTypes) ([]*replayResult, error) {
+	if block.NumberU64() == 0 {
+		return nil, errGenesisTrace
+	}
+	parent := api.eth.blockchain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent %#x not found", block.ParentHash())
+	}
+	statedb, err := api.debug.computeStateDB(parent, defaultTraceReexec)
+	if err != nil {
+		return nil, err
+	}
+	return api.traceBlock(ctx, block, statedb, modes)
+}
+
+// traceBlock replays all the transactions of a block one after the other on
+// top of the given parent state, leaving the state at the end of the last
+// transaction. The block rewards are not applied.
+func (api *PrivateTraceAPI) traceBlock(ctx context.Context, block *types.Block, statedb *state.StateDB, modes traceTypes) ([]*replayResult, error) {
+	if err := checkReproducible(api.eth.ChainDb(), block); err != nil {
+		return nil, err
+	}
+	var (
+		err      error
+		config   = api.eth.blockchain.Config()
+		signer   = types.MakeSigner(config, block.Number())
+		blockCtx = core.NewEVMBlockContext(block.Header(), api.eth.blockchain, nil)
+		results  = make([]*replayResult, len(block.Transactions()))
+	)
+	// Apply the irregular state change of the DAO fork, just like block processing
+	if config.DAOForkSupport && config.DAOForkBlock != nil && config.DAOForkBlock.Cmp(block.Number()) == 0 {
+		misc.ApplyDAOHardFork(statedb)
+	}
+	for i, tx := range block.Transactions() {
+		msg, _ := tx.AsMessage(signer, block.BaseFee())
+		statedb.Prepare(tx.Hash(), block.Hash(), i)
+
+		if results[i], err = api.replay(ctx, msg, blockCtx, statedb, modes); err != nil {
+			return nil, fmt.Errorf("transaction %#x failed: %v", tx.Hash(), err)
+		}
+		// Only delete empty objects if EIP158/161 (a.k.a Spurious Dragon) is in effect
+		statedb.Finalise(config.IsEIP158(block.Number()))
+	}
+	return results, nil
+}
+
+// replay executes a message on top of the given state, running the tracers of
+// the requested trace types alongside.
+func (api *PrivateTraceAPI) replay(ctx context.Context, msg core.Message, vmctx vm.BlockContext, statedb *state.StateDB, modes traceTypes) (*replayResult, error) {
+	var (
+		callTracer, diffTracer, vmTracer tracers.ResultTracer
+		mux                              tracers.MuxTracer
+		err                              error
+	)
+	if modes.trace {
+		if callTracer, err = tracers.NewResultTracer("callTracer", nil); err != nil {
+			return nil, err
+		}
+		mux = append(mux, callTracer)
+	}
+	if modes.stateDiff {
+		if diffTracer, err = tracers.NewResultTracer("prestateTracer", json.RawMessage(`{"diffMode": true}`)); err != nil {
+			return nil, err
+		}
+		mux = append(mux, diffTracer)
+	}
+	if modes.vmTrace {
+		if vmTracer, err = tracers.NewResultTracer("vmTracer", nil); err != nil {
+			return nil, err
+		}
+		mux = append(mux, vmTracer)
+	}
+	// Handle timeouts and RPC cancellations
+	deadlineCtx, cancel := context.WithTimeout(ctx, defaultTraceTimeout)
+	defer cancel()
+	go func() {
+		<-deadlineCtx.Done()
+		for _, tracer := range mux {
+			tracer.(tracers.ResultTracer).Stop(errors.New("execution timeout"))
+		}
+	}()
+	vmenv := vm.NewEVM(vmctx, core.NewEVMTxContext(msg), statedb, api.eth.blockchain.Config(), vm.Config{Debug: len(mux) > 0, Tracer: mux, NoBaseFee: true})
+
+	result, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(msg.Gas()))
+	if err != nil {
+		return nil, fmt.Errorf("tracing failed: %v", err)
+	}
+	res := &replayResult{output: result.ReturnData}
+	if callTracer != nil {
+		blob, err := callTracer.GetResult()
+		if err != nil {
+			return nil, err
+		}
+		res.call = new(tracedCall)
+		if err := json.Unmarshal(blob, res.call); err != nil {
+			return nil, err
+		}
+	}
+	if diffTracer != nil {
+		blob, err := diffTracer.GetResult()
+		if err != nil {
+			return nil, err
+		}
+		res.stateDiff = new(tracedDiff)
+		if err := json.Unmarshal(blob, res.stateDiff); err != nil {
+			return nil, err
+		}
+	}
+	if vmTracer != nil {
+		if res.vmTrace, err = vmTracer.GetResult(); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// results assembles the OpenEthereum replay results of a message.
+func (res *replayResult) results(db ethapi.SelectorDB) *TraceResults {
+	results := &TraceResults{
+		Output:  res.output,
+		Trace:   []*ParityTrace{},
+		VMTrace: res.vmTrace,
+	}
+	if res.output == nil {
+		results.Output = []byte{}
+	}
+	if res.call != nil {
+		for _, call := range flattenCalls(res.call, 0, nil, nil) {
+			results.Trace = append(results.Trace, newParityTrace(call, db))
+		}
+	}
+	if res.stateDiff != nil {
+		results.StateDiff = parityStateDiff(res.stateDiff)
+	}
+	return results
+}
+
+// localize converts the call traces of a block into OpenEthereum traces along
+// with the block and transaction details.
+func localize(calls []*rawdb.CallTrace, block *types.Block, db ethapi.SelectorDB) []*ParityTrace {
+	hash := block.Hash()
+
+	traces := make([]*ParityTrace, len(calls))
+	for i, call := range calls {
+		var (
+			txHash   = block.Transactions()[call.TxIndex].Hash()
+			position = call.TxIndex
+		)
+		traces[i] = newParityTrace(call, db)
+		traces[i].BlockHash, traces[i].BlockNumber = &hash, block.NumberU64()
+		traces[i].TransactionHash, traces[i].TransactionPosition = &txHash, &position
+	}
+	return traces
+}
+
+// rewards returns the traces of the miner rewards paid by a block, if the
+// chain is proof-of-work.
+func (api *PrivateTraceAPI) rewards(block *types.Block) []*ParityTrace {
+	if _, ok := api.eth.engine.(*ethash.Ethash); !ok || block.NumberU64() == 0 {
+		return nil
+	}
+	var (
+		hash   = block.Hash()
+		traces []*ParityTrace
+	)
+	for _, reward := range ethash.BlockRewards(api.eth.blockchain.Config(), block.Header(), block.Uncles()) {
+		traces = append(traces, &ParityTrace{
+			Action: &parityRewardAction{
+				Author:     reward.Beneficiary,
+				RewardType: reward.Type,
+				Value:      (*hexutil.Big)(reward.Value),
+			},
+			TraceAddress: []int{},
+			Type:         "reward",
+			BlockHash:    &hash,
+			BlockNumber:  block.NumberU64(),
+		})
+	}
+	return traces
+}
+
+// matches checks whether a trace from and to the given addresses matches the
+// filter. Rewards have no sender.
+func (args *TraceFilterArgs) matches(from *common.Address, to common.Address) bool {
+	if len(args.FromAddress) > 0 {
+		if from == nil || !containsAddress(args.FromAddress, *from) {
+			return false
+		}
+	}
+	return len(args.ToAddress) == 0 || containsAddress(args.ToAddress, to)
+}
+
+// containsAddress checks whether addr is in the list of addresses.
+func containsAddress(addrs []common.Address, addr common.Address) bool {
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// callTraceBlocks returns the numbers of the indexed blocks in the inclusive
+// range [from, to] touching any of the given addresses, in ascending order.
+func callTraceBlocks(db ethdb.Iteratee, addrs []common.Address, from, to uint64) []uint64 {
+	seen := make(map[uint64]bool)
+	for _, addr := range addrs {
+		for _, number := range rawdb.ReadCallTraceBlocks(db, addr, from, to) {
+			seen[number] = true
+		}
+	}
+	numbers := make([]uint64, 0, len(seen))
+	for number := range seen {
+		numbers = append(numbers, number)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+	return numbers
+}
+
+// flattenCalls appends the call trace of a call made by the transaction at the
+// given index and the traces of all its subcalls to traces in depth first order.
+func flattenCalls(call *tracedCall, txIndex uint64, address []uint64, traces []*rawdb.CallTrace) []*rawdb.CallTrace {
+	trace := &rawdb.CallTrace{
+		TxIndex:      txIndex,
+		TraceAddress: address,
+		Subtraces:    uint64(len(call.Calls)),
+		Type:         call.Type,
+		From:         call.From,
+		To:           call.To,
+		Value:        new(big.Int),
+		Gas:          uint64(call.Gas),
+		GasUsed:      uint64(call.GasUsed),
+		Input:        call.Input,
+		Output:       call.Output,
+		Error:        call.Error,
+	}
+	if call.Value != nil {
+		trace.Value = call.Value.ToInt()
+	}
+	traces = append(traces, trace)
+	for i, sub := range call.Calls {
+		traces = flattenCalls(sub, txIndex, append(append([]uint64{}, address...), uint64(i)), traces)
+	}
+	return traces
+}
+
+// newParityTrace converts a call trace into an OpenEthereum trace without the
+// block and transaction details, decoding the revert data of reverted calls
+// with the given 4byte database if any.
+func newParityTrace(call *rawdb.CallTrace, db ethapi.SelectorDB) *ParityTrace {
+	trace := &ParityTrace{
+		Error:        parityError(call.Error),
+		Subtraces:    int(call.Subtraces),
+		TraceAddress: make([]int, len(call.TraceAddress)),
+	}
+	if call.Error == vm.ErrExecutionReverted.Error() && len(call.Output) > 0 {
+		trace.Revert = ethapi.DecodeRevert(call.Output, nil, db)
+	}
+	for i, index := range call.TraceAddress {
+		trace.TraceAddress[i] = int(index)
+	}
+	var (
+		value  = (*hexutil.Big)(call.Value)
+		input  = hexutil.Bytes(call.Input)
+		output = hexutil.Bytes(call.Output)
+	)
+	if value == nil {
+		value = new(hexutil.Big)
+	}
+	if input == nil {
+		input = []byte{}
+	}
+	if output == nil {
+		output = []byte{}
+	}
+	switch call.Type {
+	case "CREATE", "CREATE2":
+		trace.Type = "create"
+		trace.Action = &parityCreateAction{From: call.From, Gas: hexutil.Uint64(call.Gas), Init: input, Value: value}
+		trace.Result = &parityCreateResult{Address: call.To, Code: output, GasUsed: hexutil.Uint64(call.GasUsed)}
+
+	case "SELFDESTRUCT":
+		trace.Type = "suicide"
+		trace.Action = &paritySuicideAction{Address: call.From, Balance: value, RefundAddress: call.To}
+
+	default:
+		trace.Type = "call"
+		trace.Action = &parityCallAction{
+			CallType: strings.ToLower(call.Type),
+			From:     call.From,
+			Gas:      hexutil.Uint64(call.Gas),
+			Input:    input,
+			To:       call.To,
+			Value:    value,
+		}
+		trace.Result = &parityCallResult{GasUsed: hexutil.Uint64(call.GasUsed), Output: output}
+	}
+	return trace
+}
+
+// parityError converts an EVM error into its OpenEthereum counterpart.
+func parityError(err string) string {
+	switch {
+	case err == vm.ErrExecutionReverted.Error():
+		return "Reverted"
+	case err == vm.ErrOutOfGas.Error(), err == vm.ErrCodeStoreOutOfGas.Error(), err == vm.ErrGasUintOverflow.Error():
+		return "Out of gas"
+	case err == vm.ErrInvalidJump.Error():
+		return "Bad jump destination"
+	case err == vm.ErrWriteProtection.Error():
+		return "Mutable Call In Static Context"
+	case strings.HasPrefix(err, "invalid opcode"):
+		return "Bad instruction"
+	case strings.HasPrefix(err, "stack underflow"):
+		return "Stack underflow"
+	case strings.HasPrefix(err, "stack limit reached"):
+		return "Out of stack"
+	}
+	return err
+}
+
+// parityStateDiff converts the pre and post states of the modified accounts
+// into OpenEthereum's state diff format.
+func parityStateDiff(diff *tracedDiff) map[common.Address]*parityAccountDiff {
+	res := make(map[common.Address]*parityAccountDiff)
+	for addr := range diff.Pre {
+		res[addr] = nil
+	}
+	for addr := range diff.Post {
+		res[addr] = nil
+	}
+	for addr := range res {
+		// Accounts touched for the first time are born, just like created ones
+		pre, post := diff.Pre[addr], diff.Post[addr]
+		created, deleted := pre == nil || pre.empty(), post == nil
+		if created && deleted {
+			delete(res, addr)
+			continue
+		}
+		if created {
+			pre = &tracedAccount{}
+		}
+		if deleted {
+			post = &tracedAccount{}
+		}
+		// Unchanged fields are omitted from the post state
+		var (
+			preBalance, postBalance = pre.Balance, post.Balance
+			preNonce, postNonce     = hexutil.Uint64(pre.Nonce), hexutil.Uint64(post.Nonce)
+			preCode, postCode       = pre.Code, post.Code
+		)
+		if preBalance == nil {
+			preBalance = new(hexutil.Big)
+		}
+		if postBalance == nil && !created {
+			postBalance = preBalance
+		} else if postBalance == nil {
+			postBalance = new(hexutil.Big)
+		}
+		if preCode == nil {
+			preCode = []byte{}
+		}
+		if postCode == nil {
+			postCode = []byte{}
+		}
+		account := &parityAccountDiff{
+			Balance: parityDiff(preBalance, postBalance, created, deleted, post.Balance != nil),
+			Nonce:   parityDiff(preNonce, postNonce, created, deleted, post.Nonce != 0),
+			Code:    parityDiff(preCode, postCode, created, deleted, post.Code != nil),
+			Storage: make(map[common.Hash]interface{}),
+		}
+		for key, val := range pre.Storage {
+			account.Storage[key] = parityDiff(val, post.Storage[key], created, deleted, true)
+		}
+		for key, val := range post.Storage {
+			if _, ok := pre.Storage[key]; !ok {
+				account.Storage[key] = parityDiff(common.Hash{}, val, created, deleted, true)
+			}
+		}
+		res[addr] = account
+	}
+	return res
+}
+
+// parityDiff formats a single changed value in OpenEthereum's state diff format.
+func parityDiff(from, to interface{}, created, deleted, changed bool) interface{} {
+	switch {
+	case created:
+		return map[string]interface{}{"+": to}
+	case deleted:
+		return map[string]interface{}{"-": from}
+	case !changed:
+		return "="
+	default:
+		return map[string]interface{}{"*": map[string]interface{}{"from": from, "to": to}}
+	}
+}
@@ -0,0 +1,274 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	// traceCallee stores 1 into slot 0 and returns 42.
+	traceCallee = common.HexToAddress("0xbb")
+	traceCode   = common.FromHex("600160005560" + "2a60005260206000f3")
+
+	// traceCaller calls traceCallee and returns its output.
+	traceCaller     = common.HexToAddress("0xaa")
+	traceCallerCode = common.FromHex("60206000600060006000" + "73" + strings.Repeat("00", 19) + "bb" + "5af15060206000f3")
+)
+
+// newTestTraceAPI creates a chain of two blocks, the first one calling a
+// contract which calls another and the second one transferring value and
+// including an uncle, returning a trace API on top.
+func newTestTraceAPI(t *testing.T) (*PrivateTraceAPI, types.Blocks) {
+	return newTestTraceAPIWithConfig(t, params.TestChainConfig)
+}
+
+// newTestTraceAPIWithConfig creates the test chain of newTestTraceAPI with the
+// given chain configuration.
+func newTestTraceAPIWithConfig(t *testing.T, config *params.ChainConfig) (*PrivateTraceAPI, types.Blocks) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender = crypto.PubkeyToAddress(key.PublicKey)
+		signer = types.HomesteadSigner{}
+		db     = rawdb.NewMemoryDatabase()
+		engine = ethash.NewFaker()
+		gspec  = &core.Genesis{
+			Config: config,
+			Alloc: core.GenesisAlloc{
+				sender:      {Balance: big.NewInt(params.Ether)},
+				traceCaller: {Code: traceCallerCode, Balance: new(big.Int)},
+				traceCallee: {Code: traceCode, Balance: new(big.Int)},
+			},
+		}
+		genesis = gspec.MustCommit(db)
+	)
+	chain, err := core.NewBlockChain(db, nil, gspec.Config, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	uncles, _ := core.GenerateChain(gspec.Config, genesis, engine, db, 1, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(common.Address{0x03})
+	})
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, engine, db, 2, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(common.Address{0x01})
+		switch i {
+		case 0:
+			tx, _ := types.SignTx(types.NewTransaction(0, traceCaller, big.NewInt(0), 100000, big.NewInt(1), nil), signer, key)
+			b.AddTx(tx)
+		case 1:
+			tx, _ := types.SignTx(types.NewTransaction(1, common.Address{0x02}, big.NewInt(1000), params.TxGas, big.NewInt(1), nil), signer, key)
+			b.AddTx(tx)
+			b.AddUncle(uncles[0].Header())
+		}
+	})
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	ethConfig := DefaultConfig
+	eth := &Ethereum{
+		config:     &ethConfig,
+		chainDb:    db,
+		blockchain: chain,
+		engine:     engine,
+	}
+	eth.APIBackend = &EthAPIBackend{eth: eth}
+	return NewPrivateTraceAPI(eth), blocks
+}
+
+// Tests that block traces contain the flattened calls of all transactions and
+// the block and uncle rewards.
+func TestTraceBlock(t *testing.T) {
+	api, blocks := newTestTraceAPI(t)
+
+	traces, err := api.Block(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	if len(traces) != 3 {
+		t.Fatalf("trace count mismatch: have %d, want %d", len(traces), 3)
+	}
+	if action, ok := traces[0].Action.(*parityCallAction); !ok || action.To != traceCaller || action.CallType != "call" {
+		t.Errorf("outer call mismatch: %+v", traces[0].Action)
+	}
+	if traces[0].Subtraces != 1 || len(traces[0].TraceAddress) != 0 {
+		t.Errorf("outer call position mismatch: subtraces %d, address %v", traces[0].Subtraces, traces[0].TraceAddress)
+	}
+	if action, ok := traces[1].Action.(*parityCallAction); !ok || action.From != traceCaller || action.To != traceCallee {
+		t.Errorf("inner call mismatch: %+v", traces[1].Action)
+	}
+	if !reflect.DeepEqual(traces[1].TraceAddress, []int{0}) {
+		t.Errorf("inner call address mismatch: have %v, want [0]", traces[1].TraceAddress)
+	}
+	want := common.LeftPadBytes([]byte{0x2a}, 32)
+	for i := 0; i < 2; i++ {
+		if res, ok := traces[i].Result.(*parityCallResult); !ok || !reflect.DeepEqual([]byte(res.Output), want) {
+			t.Errorf("call %d result mismatch: %+v", i, traces[i].Result)
+		}
+		if *traces[i].BlockHash != blocks[0].Hash() || *traces[i].TransactionHash != blocks[0].Transactions()[0].Hash() {
+			t.Errorf("call %d not localized", i)
+		}
+	}
+	if action, ok := traces[2].Action.(*parityRewardAction); !ok || action.Author != blocks[0].Coinbase() || action.RewardType != ethash.RewardBlock {
+		t.Errorf("block reward mismatch: %+v", traces[2].Action)
+	}
+	// Rewards have no transaction, neither a result
+	blob, err := json.Marshal(traces[2])
+	if err != nil {
+		t.Fatalf("failed to marshal reward trace: %v", err)
+	}
+	var enc map[string]interface{}
+	if err := json.Unmarshal(blob, &enc); err != nil {
+		t.Fatalf("failed to unmarshal reward trace: %v", err)
+	}
+	if res, ok := enc["result"]; !ok || res != nil || enc["transactionHash"] != nil || enc["type"] != "reward" {
+		t.Errorf("reward trace encoding mismatch: %s", blob)
+	}
+	// The second block pays the uncle as well
+	traces, err = api.Block(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	if len(traces) != 3 {
+		t.Fatalf("trace count mismatch: have %d, want %d", len(traces), 3)
+	}
+	var (
+		block = traces[1].Action.(*parityRewardAction)
+		uncle = traces[2].Action.(*parityRewardAction)
+	)
+	if want := new(big.Int).Add(ethash.ConstantinopleBlockReward, new(big.Int).Div(ethash.ConstantinopleBlockReward, big.NewInt(32))); block.Value.ToInt().Cmp(want) != 0 {
+		t.Errorf("block reward mismatch: have %v, want %v", block.Value, want)
+	}
+	if want := new(big.Int).Div(new(big.Int).Mul(ethash.ConstantinopleBlockReward, big.NewInt(7)), big.NewInt(8)); uncle.Value.ToInt().Cmp(want) != 0 || uncle.Author != (common.Address{0x03}) || uncle.RewardType != ethash.RewardUncle {
+		t.Errorf("uncle reward mismatch: %+v", uncle)
+	}
+	if _, err := api.Block(context.Background(), 0); err != errGenesisTrace {
+		t.Errorf("genesis trace error mismatch: have %v, want %v", err, errGenesisTrace)
+	}
+}
+
+// Tests that single calls of transactions can be retrieved by trace address.
+func TestTraceGet(t *testing.T) {
+	api, blocks := newTestTraceAPI(t)
+	hash := blocks[0].Transactions()[0].Hash()
+
+	trace, err := api.Get(context.Background(), hash, []hexutil.Uint64{0})
+	if err != nil {
+		t.Fatalf("failed to retrieve trace: %v", err)
+	}
+	if trace == nil || trace.Action.(*parityCallAction).To != traceCallee {
+		t.Fatalf("inner call mismatch: %+v", trace)
+	}
+	if trace, _ := api.Get(context.Background(), hash, []hexutil.Uint64{1}); trace != nil {
+		t.Errorf("unexpected trace at missing address: %+v", trace)
+	}
+}
+
+// Tests that replayed transactions report the requested state diffs and VM
+// traces.
+func TestTraceReplayTransaction(t *testing.T) {
+	api, blocks := newTestTraceAPI(t)
+	hash := blocks[0].Transactions()[0].Hash()
+
+	if _, err := api.ReplayTransaction(context.Background(), hash, []string{"foo"}); err == nil {
+		t.Errorf("unknown trace type accepted")
+	}
+	res, err := api.ReplayTransaction(context.Background(), hash, []string{"trace", "stateDiff", "vmTrace"})
+	if err != nil {
+		t.Fatalf("failed to replay transaction: %v", err)
+	}
+	if len(res.Trace) != 2 || res.Trace[0].BlockHash != nil {
+		t.Errorf("replayed traces mismatch: %+v", res.Trace)
+	}
+	diff := res.StateDiff[traceCallee]
+	if diff == nil {
+		t.Fatalf("modified contract missing from state diff")
+	}
+	if diff.Balance != "=" || diff.Code != "=" || diff.Nonce != "=" {
+		t.Errorf("unmodified fields reported: %+v", diff)
+	}
+	blob, _ := json.Marshal(diff.Storage[common.Hash{}])
+	if want := `{"*":{"from":"0x0000000000000000000000000000000000000000000000000000000000000000","to":"0x0000000000000000000000000000000000000000000000000000000000000001"}}`; string(blob) != want {
+		t.Errorf("storage diff mismatch: have %s, want %s", blob, want)
+	}
+	if _, ok := res.StateDiff[blocks[0].Coinbase()]; !ok {
+		t.Errorf("miner missing from state diff")
+	}
+	vmTrace := new(struct {
+		Ops []struct {
+			Sub *struct {
+				Ops []json.RawMessage `json:"ops"`
+			} `json:"sub"`
+		} `json:"ops"`
+	})
+	if err := json.Unmarshal(res.VMTrace, vmTrace); err != nil {
+		t.Fatalf("failed to unmarshal VM trace: %v", err)
+	}
+	var subs int
+	for _, op := range vmTrace.Ops {
+		if op.Sub != nil {
+			subs++
+			if len(op.Sub.Ops) != 9 {
+				t.Errorf("inner op count mismatch: have %d, want %d", len(op.Sub.Ops), 9)
+			}
+		}
+	}
+	if subs != 1 {
+		t.Errorf("sub trace count mismatch: have %d, want %d", subs, 1)
+	}
+	// Only the requested traces should be returned
+	res, err = api.ReplayTransaction(context.Background(), hash, nil)
+	if err != nil {
+		t.Fatalf("failed to replay transaction: %v", err)
+	}
+	if res.Trace == nil || len(res.Trace) != 0 || res.StateDiff != nil || res.VMTrace != nil {
+		t.Errorf("unrequested traces returned: %+v", res)
+	}
+}
+
+// Tests that calls can be traced without being included in the chain.
+func TestTraceCall(t *testing.T) {
+	api, _ := newTestTraceAPI(t)
+
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	res, err := api.Call(context.Background(), ethapi.CallArgs{To: &traceCaller}, []string{"trace"}, &latest)
+	if err != nil {
+		t.Fatalf("failed to trace call: %v", err)
+	}
+	if want := common.LeftPadBytes([]byte{0x2a}, 32); !reflect.DeepEqual([]byte(res.Output), want) {
+		t.Errorf("output mismatch: have %x, want %x", res.Output, want)
+	}
+	if len(res.Trace) != 2 {
+		t.Errorf("trace count mismatch: have %d, want %d", len(res.Trace), 2)
+	}
+}
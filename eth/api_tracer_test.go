@@ -0,0 +1,361 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	// blockEnvContract returns the number, timestamp, coinbase, difficulty and
+	// gas limit of the executing block, followed by the hash of block 1.
+	blockEnvContract = common.HexToAddress("0xcc")
+	blockEnvCode     = common.FromHex("43600052" + "42602052" + "41604052" + "44606052" + "45608052" + "60014060a052" + "60c06000f3")
+)
+
+// blockEnvOverrides deploys the block environment contract via state overrides
+// and overrides all the fields of the executing block.
+func blockEnvOverrides() (*ethapi.StateOverride, *ethapi.BlockOverrides) {
+	var (
+		code     = hexutil.Bytes(blockEnvCode)
+		time     = hexutil.Uint64(1000000)
+		gasLimit = hexutil.Uint64(123456)
+		coinbase = common.Address{0xc0}
+		hashes   = map[uint64]common.Hash{1: {0x11}}
+	)
+	return &ethapi.StateOverride{
+		blockEnvContract: ethapi.OverrideAccount{Code: &code},
+	}, &ethapi.BlockOverrides{
+		Number:     (*hexutil.Big)(big.NewInt(100)),
+		Difficulty: (*hexutil.Big)(big.NewInt(12345)),
+		Time:       &time,
+		GasLimit:   &gasLimit,
+		Coinbase:   &coinbase,
+		BlockHash:  &hashes,
+	}
+}
+
+// blockEnvResult is the expected output of the block environment contract when
+// executed with blockEnvOverrides.
+func blockEnvResult() []byte {
+	var out []byte
+	out = append(out, common.LeftPadBytes([]byte{100}, 32)...)
+	out = append(out, common.LeftPadBytes(big.NewInt(1000000).Bytes(), 32)...)
+	out = append(out, common.LeftPadBytes(common.Address{0xc0}.Bytes(), 32)...)
+	out = append(out, common.LeftPadBytes(big.NewInt(12345).Bytes(), 32)...)
+	out = append(out, common.LeftPadBytes(big.NewInt(123456).Bytes(), 32)...)
+	out = append(out, common.Hash{0x11}.Bytes()...)
+	return out
+}
+
+// Tests that debug_traceCall executes the call with the account state and block
+// fields overridden.
+func TestTraceCallOverrides(t *testing.T) {
+	api, _ := newTestTraceAPI(t)
+
+	overrides, blockOverrides := blockEnvOverrides()
+	config := &TraceCallConfig{
+		StateOverrides: overrides,
+		BlockOverrides: blockOverrides,
+	}
+	res, err := api.debug.TraceCall(context.Background(), ethapi.CallArgs{To: &blockEnvContract}, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), config)
+	if err != nil {
+		t.Fatalf("failed to trace call: %v", err)
+	}
+	result, ok := res.(*ethapi.ExecutionResult)
+	if !ok {
+		t.Fatalf("unexpected result type: %T", res)
+	}
+	if result.Failed {
+		t.Fatalf("call failed")
+	}
+	if want := common.Bytes2Hex(blockEnvResult()); result.ReturnValue != want {
+		t.Errorf("return value mismatch:\nhave %s\nwant %s", result.ReturnValue, want)
+	}
+	// Without overrides the contract doesn't exist
+	res, err = api.debug.TraceCall(context.Background(), ethapi.CallArgs{To: &blockEnvContract}, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil)
+	if err != nil {
+		t.Fatalf("failed to trace call: %v", err)
+	}
+	if ret := res.(*ethapi.ExecutionResult).ReturnValue; ret != "" {
+		t.Errorf("unexpected return value without overrides: %s", ret)
+	}
+}
+
+// Tests that eth_call executes the call with the account state and block fields
+// overridden.
+func TestCallOverrides(t *testing.T) {
+	api, _ := newTestTraceAPI(t)
+	chain := ethapi.NewPublicBlockChainAPI(api.eth.APIBackend)
+
+	overrides, blockOverrides := blockEnvOverrides()
+	ret, err := chain.Call(context.Background(), ethapi.CallArgs{To: &blockEnvContract}, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), overrides, blockOverrides)
+	if err != nil {
+		t.Fatalf("failed to execute call: %v", err)
+	}
+	if want := blockEnvResult(); !bytes.Equal(ret, want) {
+		t.Errorf("return value mismatch:\nhave %x\nwant %x", []byte(ret), want)
+	}
+	// The estimation must respect the overridden gas limit
+	gasLimit := hexutil.Uint64(21000)
+	blockOverrides.GasLimit = &gasLimit
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	if _, err := chain.EstimateGas(context.Background(), ethapi.CallArgs{To: &blockEnvContract}, &latest, overrides, blockOverrides); err == nil {
+		t.Errorf("estimation succeeded above the overridden gas limit")
+	}
+}
+
+// Tests that eth_callMany carries the state over between calls and bundles and
+// advances the simulated blocks.
+func TestCallMany(t *testing.T) {
+	api, blocks := newTestTraceAPI(t)
+	chain := ethapi.NewPublicBlockChainAPI(api.eth.APIBackend)
+
+	var (
+		counter      = common.HexToAddress("0xdd")
+		counterCode  = hexutil.Bytes(common.FromHex("60005460010180600055" + "600052" + "60206000a0" + "60206000f3")) // Increments slot 0, logs and returns it
+		reverter     = common.HexToAddress("0xee")
+		reverterCode = hexutil.Bytes(common.FromHex("60006000fd"))
+		envCode      = hexutil.Bytes(blockEnvCode)
+	)
+	bundles := []ethapi.CallBundle{
+		{
+			StateOverrides: &ethapi.StateOverride{
+				counter:  ethapi.OverrideAccount{Code: &counterCode},
+				reverter: ethapi.OverrideAccount{Code: &reverterCode},
+			},
+			Calls: []ethapi.CallArgs{{To: &counter}, {To: &counter}, {To: &reverter}},
+		},
+		{
+			StateOverrides: &ethapi.StateOverride{
+				blockEnvContract: ethapi.OverrideAccount{Code: &envCode},
+			},
+			Calls: []ethapi.CallArgs{{To: &counter}, {To: &blockEnvContract}},
+		},
+	}
+	results, err := chain.CallMany(context.Background(), bundles, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), &ethapi.CallManyConfig{Trace: true})
+	if err != nil {
+		t.Fatalf("failed to execute call bundles: %v", err)
+	}
+	if len(results) != 2 || len(results[0]) != 3 || len(results[1]) != 2 {
+		t.Fatalf("result count mismatch")
+	}
+	// The counter must see the effects of the previous calls
+	for i, res := range []*ethapi.CallManyResult{results[0][0], results[0][1], results[1][0]} {
+		want := common.LeftPadBytes([]byte{byte(i + 1)}, 32)
+		if !bytes.Equal(res.ReturnValue, want) {
+			t.Errorf("counter call %d: return value mismatch: have %x, want %x", i, []byte(res.ReturnValue), want)
+		}
+		if len(res.Logs) != 1 || !bytes.Equal(res.Logs[0].Data, want) {
+			t.Errorf("counter call %d: logs mismatch: %v", i, res.Logs)
+		}
+		if len(res.StructLogs) == 0 {
+			t.Errorf("counter call %d: missing struct logs", i)
+		}
+	}
+	if res := results[0][2]; res.Error != "execution reverted" || len(res.Logs) != 0 {
+		t.Errorf("revert mismatch: error %q, logs %v", res.Error, res.Logs)
+	}
+	// The second bundle must be executed in the next block
+	env := results[1][1].ReturnValue
+	head := blocks[len(blocks)-1]
+	if number := new(big.Int).SetBytes(env[:32]); number.Uint64() != head.NumberU64()+1 {
+		t.Errorf("block number mismatch: have %d, want %d", number, head.NumberU64()+1)
+	}
+	if time := new(big.Int).SetBytes(env[32:64]); time.Uint64() != head.Time()+1 {
+		t.Errorf("block time mismatch: have %d, want %d", time, head.Time()+1)
+	}
+	if coinbase := common.BytesToAddress(env[64:96]); coinbase != head.Coinbase() {
+		t.Errorf("coinbase mismatch: have %x, want %x", coinbase, head.Coinbase())
+	}
+}
+
+// Tests that calls overriding the block number past the EIP-1559 fork execute
+// with a base fee even if the executing block has none.
+func TestCallOverridesEIP1559(t *testing.T) {
+	config := *params.TestChainConfig
+	config.EIP1559Block = big.NewInt(10)
+
+	api, _ := newTestTraceAPIWithConfig(t, &config)
+	chain := ethapi.NewPublicBlockChainAPI(api.eth.APIBackend)
+
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		gas     = hexutil.Uint64(100000)
+		tip     = (*hexutil.Big)(big.NewInt(1))
+		feeCap  = (*hexutil.Big)(new(big.Int).SetUint64(2 * params.InitialBaseFee))
+		latest  = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		args    = ethapi.CallArgs{From: &sender, To: &blockEnvContract, Gas: &gas, MaxFeePerGas: feeCap, MaxPriorityFeePerGas: tip}
+		legacy  = ethapi.CallArgs{From: &sender, To: &blockEnvContract, Gas: &gas, GasPrice: tip}
+		baseFee = (*hexutil.Big)(big.NewInt(1))
+	)
+	overrides, blockOverrides := blockEnvOverrides()
+	if _, err := chain.Call(context.Background(), args, latest, overrides, blockOverrides); err != nil {
+		t.Fatalf("failed to execute call: %v", err)
+	}
+	res, err := api.debug.TraceCall(context.Background(), args, latest, &TraceCallConfig{StateOverrides: overrides, BlockOverrides: blockOverrides})
+	if err != nil {
+		t.Fatalf("failed to trace call: %v", err)
+	}
+	if res.(*ethapi.ExecutionResult).Failed {
+		t.Fatalf("traced call failed")
+	}
+	// The legacy gas price is below the initial base fee unless it is overridden
+	if _, err := chain.Call(context.Background(), legacy, latest, overrides, blockOverrides); err == nil {
+		t.Errorf("call succeeded below the base fee")
+	}
+	blockOverrides.BaseFee = baseFee
+	if _, err := chain.Call(context.Background(), legacy, latest, overrides, blockOverrides); err != nil {
+		t.Errorf("failed to execute call with overridden base fee: %v", err)
+	}
+	// The simulated blocks of call bundles cross the fork too
+	bundles := []ethapi.CallBundle{
+		{StateOverrides: overrides, Calls: []ethapi.CallArgs{args}},
+		{BlockOverrides: &ethapi.BlockOverrides{Number: (*hexutil.Big)(big.NewInt(10))}, Calls: []ethapi.CallArgs{args}},
+	}
+	if _, err := chain.CallMany(context.Background(), bundles, latest, nil); err != nil {
+		t.Fatalf("failed to execute call bundles: %v", err)
+	}
+}
+
+// Tests that the errors of reverted calls are decoded with the ABI of the called
+// contract or the 4byte database.
+func TestRevertDecoding(t *testing.T) {
+	api, _ := newTestTraceAPI(t)
+	chain := ethapi.NewPublicBlockChainAPI(api.eth.APIBackend)
+
+	var (
+		// reverter reverts with its call data
+		reverter     = common.HexToAddress("0xee")
+		reverterCode = hexutil.Bytes(common.FromHex("366000600037" + "366000fd"))
+		overrides    = &ethapi.StateOverride{reverter: ethapi.OverrideAccount{Code: &reverterCode}}
+		latest       = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+
+		errorData  = common.FromHex("08c379a0" + "0000000000000000000000000000000000000000000000000000000000000020" + "0000000000000000000000000000000000000000000000000000000000000004" + "626f6f6d00000000000000000000000000000000000000000000000000000000")
+		panicData  = common.FromHex("4e487b71" + "0000000000000000000000000000000000000000000000000000000000000011")
+		customData = common.FromHex("cf479181" + "0000000000000000000000000000000000000000000000000000000000000001" + "0000000000000000000000000000000000000000000000000000000000000002")
+	)
+	contract, err := abi.JSON(strings.NewReader(`[{"inputs":[{"name":"available","type":"uint256"},{"name":"required","type":"uint256"}],"name":"InsufficientBalance","type":"error"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		data      []byte
+		abi       *abi.ABI
+		selectors bool // Whether the 4byte database knows the custom error
+		err       string
+		signature string
+		args      []ethapi.RevertArgument
+	}{
+		{data: []byte{0x01}, err: "execution reverted"},
+		{data: errorData, err: "execution reverted: boom", signature: "Error(string)",
+			args: []ethapi.RevertArgument{{Name: "arg0", Type: "string", Value: "boom"}}},
+		{data: panicData, err: "execution reverted: arithmetic underflow or overflow", signature: "Panic(uint256)",
+			args: []ethapi.RevertArgument{{Name: "arg0", Type: "uint256", Value: (*hexutil.Big)(big.NewInt(0x11))}}},
+		{data: customData, err: "execution reverted"},
+		{data: customData, abi: &contract, err: "execution reverted: InsufficientBalance(0x1, 0x2)", signature: "InsufficientBalance(uint256,uint256)",
+			args: []ethapi.RevertArgument{{Name: "available", Type: "uint256", Value: (*hexutil.Big)(big.NewInt(1))}, {Name: "required", Type: "uint256", Value: (*hexutil.Big)(big.NewInt(2))}}},
+		{data: customData, selectors: true, err: "execution reverted: InsufficientBalance(0x1, 0x2)", signature: "InsufficientBalance(uint256,uint256)",
+			args: []ethapi.RevertArgument{{Name: "arg0", Type: "uint256", Value: (*hexutil.Big)(big.NewInt(1))}, {Name: "arg1", Type: "uint256", Value: (*hexutil.Big)(big.NewInt(2))}}},
+	}
+	dir, err := ioutil.TempDir("", "eth-revert-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "4byte.json")
+	if err := ioutil.WriteFile(path, []byte(`{"cf479181": "InsufficientBalance(uint256,uint256)"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	selectors, err := ethapi.LoadSelectorDB(path)
+	if err != nil {
+		t.Fatalf("failed to load 4byte database: %v", err)
+	}
+	for i, tt := range tests {
+		api.eth.APIBackend.selectors = nil
+		if tt.selectors {
+			api.eth.APIBackend.selectors = selectors
+		}
+		data := hexutil.Bytes(tt.data)
+		args := ethapi.CallArgs{To: &reverter, Data: &data, ABI: tt.abi}
+
+		_, callErr := chain.Call(context.Background(), args, latest, overrides, nil)
+		_, estimateErr := chain.EstimateGas(context.Background(), args, &latest, overrides, nil)
+		for _, err := range []error{callErr, estimateErr} {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("test %d: error mismatch: have %v, want %s", i, err, tt.err)
+				continue
+			}
+			if data := err.(rpc.DataError).ErrorData(); data != hexutil.Encode(tt.data) {
+				t.Errorf("test %d: error data mismatch: have %v, want %s", i, data, hexutil.Encode(tt.data))
+			}
+			revert := err.(interface{ RevertData() *ethapi.RevertData }).RevertData()
+			if !bytes.Equal(revert.Data, tt.data) || revert.Signature != tt.signature || !reflect.DeepEqual(revert.Args, tt.args) {
+				t.Errorf("test %d: revert data mismatch: have %+v", i, revert)
+			}
+		}
+		// Traces carry the same decoded revert data
+		res, err := api.debug.TraceCall(context.Background(), args, latest, &TraceCallConfig{StateOverrides: overrides})
+		if err != nil {
+			t.Fatalf("test %d: failed to trace call: %v", i, err)
+		}
+		if revert := res.(*ethapi.ExecutionResult).Revert; revert == nil || revert.Signature != tt.signature || !reflect.DeepEqual(revert.Args, tt.args) {
+			t.Errorf("test %d: traced revert data mismatch: have %+v", i, revert)
+		}
+	}
+}
+
+// Tests that eth_createAccessList collects the accounts and slots touched by a
+// call, excluding the sender and the recipient.
+func TestCreateAccessList(t *testing.T) {
+	api, _ := newTestTraceAPI(t)
+	chain := ethapi.NewPublicBlockChainAPI(api.eth.APIBackend)
+
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	res, err := chain.CreateAccessList(context.Background(), ethapi.CallArgs{To: &traceCaller}, &latest)
+	if err != nil {
+		t.Fatalf("failed to create access list: %v", err)
+	}
+	if res.Error != "" {
+		t.Fatalf("call failed: %v", res.Error)
+	}
+	want := types.AccessList{{Address: traceCallee, StorageKeys: []common.Hash{{}}}}
+	if !reflect.DeepEqual(*res.Accesslist, want) {
+		t.Errorf("access list mismatch: have %+v, want %+v", *res.Accesslist, want)
+	}
+	if res.GasUsed == 0 {
+		t.Errorf("missing gas used")
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/clique"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/instaseal"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -74,6 +75,14 @@ type Ethereum struct {
 	bloomIndexer      *core.ChainIndexer             // Bloom indexer operating during block imports
 	closeBloomHandler chan struct{}
 
+	chainStatsIndexer *core.ChainIndexer // Network statistics indexer operating during block imports
+
+	sideTracker *sideChainTracker // Side chain block recorder for uncle analytics, nil if disabled
+
+	callTraceIndexer *callTraceIndexer // Call trace recorder serving trace filters, nil if disabled
+
+	devChain *devChain // Block producer of instantly sealed development chains
+
 	APIBackend *EthAPIBackend
 
 	miner     *miner.Miner
@@ -136,6 +145,7 @@ func New(stack *node.Node, config *Config) (*Ethereum, error) {
 		etherbase:         config.Miner.Etherbase,
 		bloomRequests:     make(chan chan *bloombits.Retrieval),
 		bloomIndexer:      NewBloomIndexer(chainDb, params.BloomBitsBlocks, params.BloomConfirms),
+		chainStatsIndexer: NewChainStatsIndexer(chainDb, params.ChainStatsBlocks, params.ChainStatsConfirms),
 		p2pServer:         stack.Server(),
 	}
 
@@ -154,11 +164,19 @@ func New(stack *node.Node, config *Config) (*Ethereum, error) {
 			rawdb.WriteDatabaseVersion(chainDb, core.BlockChainVersion)
 		}
 	}
+	// Fail early if the external EVM can't be loaded, rather than silently
+	// falling back to the built-in interpreter for every transaction.
+	if config.EVMInterpreter != "" {
+		if _, err := vm.LoadEVMC(config.EVMInterpreter); err != nil {
+			return nil, err
+		}
+	}
 	var (
 		vmConfig = vm.Config{
 			EnablePreimageRecording: config.EnablePreimageRecording,
 			EWASMInterpreter:        config.EWASMInterpreter,
 			EVMInterpreter:          config.EVMInterpreter,
+			EVMCDifferential:        config.EVMDifferential,
 		}
 		cacheConfig = &core.CacheConfig{
 			TrieCleanLimit:      config.TrieCleanCache,
@@ -183,11 +201,27 @@ func New(stack *node.Node, config *Config) (*Ethereum, error) {
 		rawdb.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
 	eth.bloomIndexer.Start(eth.blockchain)
+	eth.chainStatsIndexer.Start(eth.blockchain)
+	if config.SideBlocks {
+		eth.sideTracker = newSideChainTracker(chainDb, eth.blockchain, config.SideBlocksLimit)
+	}
+	if config.TraceIndex {
+		eth.callTraceIndexer = newCallTraceIndexer(eth, config.TraceIndexLimit)
+	}
 
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = stack.ResolvePath(config.TxPool.Journal)
 	}
+	var policy *core.TxPolicy
+	if config.TxPool.Policy != "" {
+		if policy, err = core.LoadTxPolicy(config.TxPool.Policy); err != nil {
+			return nil, fmt.Errorf("failed to load transaction pool policy: %v", err)
+		}
+	}
 	eth.txPool = core.NewTxPool(config.TxPool, chainConfig, eth.blockchain)
+	if policy != nil {
+		eth.txPool.SetPolicy(policy)
+	}
 
 	// Permit the downloader to use the trie cache allowance during fast sync
 	cacheLimit := cacheConfig.TrieCleanLimit + cacheConfig.TrieDirtyLimit + cacheConfig.SnapshotLimit
@@ -201,7 +235,16 @@ func New(stack *node.Node, config *Config) (*Ethereum, error) {
 	eth.miner = miner.New(eth, &config.Miner, chainConfig, eth.EventMux(), eth.engine, eth.isLocalBlock)
 	eth.miner.SetExtra(makeExtraData(config.Miner.ExtraData))
 
-	eth.APIBackend = &EthAPIBackend{stack.Config().ExtRPCEnabled(), eth, nil}
+	if chainConfig.InstaSeal != nil {
+		eth.devChain = newDevChain(eth)
+	}
+
+	eth.APIBackend = &EthAPIBackend{stack.Config().ExtRPCEnabled(), eth, nil, nil}
+	if config.RPCErrorSignatures != "" {
+		if eth.APIBackend.selectors, err = ethapi.LoadSelectorDB(config.RPCErrorSignatures); err != nil {
+			return nil, err
+		}
+	}
 	gpoParams := config.GPO
 	if gpoParams.Default == nil {
 		gpoParams.Default = config.Miner.GasPrice
@@ -246,6 +289,10 @@ func CreateConsensusEngine(stack *node.Node, chainConfig *params.ChainConfig, co
 	if chainConfig.Clique != nil {
 		return clique.New(chainConfig.Clique, db)
 	}
+	// If an instantly sealed development chain is requested, set it up
+	if chainConfig.InstaSeal != nil {
+		return instaseal.New()
+	}
 	// Otherwise assume proof-of-work
 	switch config.PowMode {
 	case ethash.ModeFake:
@@ -281,6 +328,14 @@ func (s *Ethereum) APIs() []rpc.API {
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
 
+	// Append the chain controls if running a development chain
+	if s.devChain != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "dev",
+			Version:   "1.0",
+			Service:   NewPrivateDevAPI(s),
+		})
+	}
 	// Append all the local APIs and return
 	return append(apis, []rpc.API{
 		{
@@ -321,6 +376,15 @@ func (s *Ethereum) APIs() []rpc.API {
 			Namespace: "debug",
 			Version:   "1.0",
 			Service:   NewPrivateDebugAPI(s),
+		}, {
+			Namespace: "trace",
+			Version:   "1.0",
+			Service:   NewPrivateTraceAPI(s),
+		}, {
+			Namespace: "ethash",
+			Version:   "1.0",
+			Service:   NewPublicNetworkStatsAPI(s),
+			Public:    true,
 		}, {
 			Namespace: "net",
 			Version:   "1.0",
@@ -435,6 +499,10 @@ func (s *Ethereum) StartMining(threads int) error {
 		}
 		th.SetThreads(threads)
 	}
+	// Instantly sealed development chains are mined on demand via the dev API
+	if s.devChain != nil {
+		return errors.New("development chain blocks are mined via the dev API")
+	}
 	// If the miner was not running, initialize it
 	if !s.IsMining() {
 		// Propagate the initial price point to the transaction pool
@@ -517,6 +585,21 @@ func (s *Ethereum) Start() error {
 	// Start the bloom bits servicing goroutines
 	s.startBloomHandlers(params.BloomBitsBlocks)
 
+	// Start recording side chain blocks for uncle analytics
+	if s.sideTracker != nil {
+		s.sideTracker.start()
+	}
+
+	// Start recording call traces for trace filters if requested
+	if s.callTraceIndexer != nil {
+		s.callTraceIndexer.start()
+	}
+
+	// Start sealing blocks on demand on development chains
+	if s.devChain != nil {
+		s.devChain.start()
+	}
+
 	// Figure out a max peers count based on the server limits
 	maxPeers := s.p2pServer.MaxPeers
 	if s.config.LightServ > 0 {
@@ -538,7 +621,17 @@ func (s *Ethereum) Stop() error {
 
 	// Then stop everything else.
 	s.bloomIndexer.Close()
+	s.chainStatsIndexer.Close()
 	close(s.closeBloomHandler)
+	if s.sideTracker != nil {
+		s.sideTracker.stop()
+	}
+	if s.callTraceIndexer != nil {
+		s.callTraceIndexer.stop()
+	}
+	if s.devChain != nil {
+		s.devChain.stop()
+	}
 	s.txPool.Stop()
 	s.miner.Stop()
 	s.blockchain.Stop()
@@ -0,0 +1,324 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// callTraceEventChanSize is the size of the channel listening to chain head
+// events. Missed heads are harmless, as every head indexes all the blocks not
+// yet indexed.
+const callTraceEventChanSize = 10
+
+// callTraceIndexer replays the canonical blocks as they are imported, storing
+// their flattened call traces into the database and indexing them by the
+// addresses they touched, so that trace filters can be served without
+// re-executing the chain.
+type callTraceIndexer struct {
+	db     ethdb.Database
+	chain  *core.BlockChain
+	tracer *PrivateTraceAPI
+	limit  uint64 // Number of recent blocks to retain the traces of, 0 for all
+
+	failed    uint64 // Number of blocks indexed without traces since startup
+	lastBlock uint64 // Number of the last block indexed without traces
+	lastErr   error  // Failure tracing the last block indexed without traces
+	lock      sync.RWMutex
+
+	headCh  chan core.ChainHeadEvent
+	headSub event.Subscription
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newCallTraceIndexer creates a call trace indexer on top of the given node,
+// retaining the traces of the given number of recent blocks.
+func newCallTraceIndexer(eth *Ethereum, limit uint64) *callTraceIndexer {
+	return &callTraceIndexer{
+		db:     eth.ChainDb(),
+		chain:  eth.blockchain,
+		tracer: NewPrivateTraceAPI(eth),
+		limit:  limit,
+		headCh: make(chan core.ChainHeadEvent, callTraceEventChanSize),
+		quit:   make(chan struct{}),
+	}
+}
+
+// start subscribes to the chain head events and starts indexing the blocks.
+func (t *callTraceIndexer) start() {
+	t.headSub = t.chain.SubscribeChainHeadEvent(t.headCh)
+
+	t.wg.Add(1)
+	go t.loop()
+}
+
+// stop terminates the indexer, interrupting any ongoing indexing.
+func (t *callTraceIndexer) stop() {
+	close(t.quit)
+	t.wg.Wait()
+}
+
+// loop is the event loop indexing the chain whenever its head changes.
+func (t *callTraceIndexer) loop() {
+	defer t.wg.Done()
+	defer t.headSub.Unsubscribe()
+
+	t.index(t.chain.CurrentBlock().Header())
+	for {
+		select {
+		case ev := <-t.headCh:
+			t.index(ev.Block.Header())
+		case <-t.headSub.Err():
+			return
+		case <-t.quit:
+			return
+		}
+	}
+}
+
+// index brings the call trace index in sync with the given chain head, undoing
+// the blocks reorged out, tracing the new ones and pruning the old ones.
+func (t *callTraceIndexer) index(head *types.Header) {
+	number := head.Number.Uint64()
+	if number == 0 {
+		return
+	}
+	// Start indexing the retained blocks if the index is new, otherwise undo any
+	// blocks which are not canonical anymore
+	tail, next := t.bounds(number)
+	for next > tail {
+		traces := rawdb.ReadCallTraces(t.db, next-1)
+		if next-1 <= number && traces != nil && traces.Hash == t.chain.GetCanonicalHash(next-1) {
+			break
+		}
+		rawdb.DeleteCallTraces(t.db, next-1, traces)
+		next--
+		rawdb.WriteCallTraceHead(t.db, next-1)
+	}
+	var (
+		start   = time.Now()
+		logged  = time.Now()
+		carried *state.StateDB // State carried over from block to block if not available locally
+		proot   common.Hash
+	)
+	for ; next <= number; next++ {
+		select {
+		case <-t.quit:
+			return
+		default:
+		}
+		block := t.chain.GetBlockByNumber(next)
+		if block == nil {
+			log.Error("Missing block to trace", "number", next)
+			return
+		}
+		// Development blocks that cannot be re-executed are indexed without traces
+		if rawdb.HasAlteredBlock(t.db, block.Hash()) {
+			carried, proot = nil, common.Hash{}
+			t.store(next, &rawdb.CallTraces{Hash: block.Hash()})
+			continue
+		}
+		parent := t.chain.GetBlock(block.ParentHash(), next-1)
+		if parent == nil {
+			log.Error("Missing parent of block to trace", "number", next)
+			return
+		}
+		statedb, err := t.chain.StateAt(parent.Root())
+		if err != nil {
+			if carried == nil {
+				if carried, err = t.tracer.debug.computeStateDB(parent, defaultTraceReexec); err != nil {
+					// If nothing was indexed yet, skip the blocks without state available
+					if next == tail && tail < number {
+						log.Warn("Historical state unavailable, indexing call traces from head", "number", number, "err", err)
+						tail = number
+						rawdb.WriteCallTraceTail(t.db, tail)
+						rawdb.WriteCallTraceHead(t.db, tail-1)
+						next = tail - 1
+						continue
+					}
+					t.fail(block, fmt.Errorf("state unavailable: %v", err))
+					continue
+				}
+			}
+			statedb = carried
+		} else {
+			carried, proot = nil, common.Hash{}
+		}
+		traces, err := t.trace(block, statedb)
+		if err != nil {
+			carried, proot = nil, common.Hash{}
+			t.fail(block, err)
+			continue
+		}
+		// Move the carried state onto the traced block for the next one, falling
+		// back to the local or regenerated state if that fails
+		if statedb == carried {
+			if proot, err = t.advance(block, carried, proot); err != nil {
+				log.Warn("Failed to carry state over traced block", "number", next, "err", err)
+				carried, proot = nil, common.Hash{}
+			}
+		}
+		t.store(next, traces)
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Indexing call traces", "number", next, "head", number, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	t.prune(tail, number)
+}
+
+// fail indexes a block which cannot be traced without any traces, so that one
+// failing block doesn't stall the index, and records the failure.
+func (t *callTraceIndexer) fail(block *types.Block, err error) {
+	log.Error("Failed to trace block, indexing it without traces", "number", block.NumberU64(), "hash", block.Hash(), "err", err)
+	t.store(block.NumberU64(), &rawdb.CallTraces{Hash: block.Hash(), Error: err.Error()})
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.failed++
+	t.lastBlock, t.lastErr = block.NumberU64(), err
+}
+
+// status returns the number of blocks indexed without traces since startup and
+// the last of them along with its failure, if any.
+func (t *callTraceIndexer) status() (uint64, uint64, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.failed, t.lastBlock, t.lastErr
+}
+
+// store writes the call traces of a block, replacing any stale ones, and moves
+// the index head onto it.
+func (t *callTraceIndexer) store(number uint64, traces *rawdb.CallTraces) {
+	batch := t.db.NewBatch()
+	rawdb.DeleteCallTraces(batch, number, rawdb.ReadCallTraces(t.db, number))
+	rawdb.WriteCallTraces(batch, number, traces)
+	rawdb.WriteCallTraceHead(batch, number)
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to write call traces", "err", err)
+	}
+}
+
+// bounds returns the oldest indexed block and the next block to index, starting
+// a new index with the retained blocks below the given head if none exists.
+func (t *callTraceIndexer) bounds(head uint64) (uint64, uint64) {
+	tail, last := rawdb.ReadCallTraceTail(t.db), rawdb.ReadCallTraceHead(t.db)
+	if tail != nil && last != nil {
+		return *tail, *last + 1
+	}
+	// The genesis block has no calls to trace
+	first := uint64(1)
+	if t.limit > 0 && head >= t.limit {
+		first = head - t.limit + 1
+	}
+	rawdb.WriteCallTraceTail(t.db, first)
+	rawdb.WriteCallTraceHead(t.db, first-1)
+	return first, first
+}
+
+// trace replays all the transactions of a block on top of its parent state,
+// returning their flattened call traces.
+func (t *callTraceIndexer) trace(block *types.Block, statedb *state.StateDB) (*rawdb.CallTraces, error) {
+	results, err := t.tracer.traceBlock(context.Background(), block, statedb, traceTypes{trace: true})
+	if err != nil {
+		return nil, err
+	}
+	traces := &rawdb.CallTraces{Hash: block.Hash()}
+	for i, res := range results {
+		traces.Traces = flattenCalls(res.call, uint64(i), nil, traces.Traces)
+	}
+	// Index the block under all the addresses it touched, rewards included
+	seen := make(map[common.Address]bool)
+	touch := func(addr common.Address) {
+		if !seen[addr] {
+			seen[addr] = true
+			traces.Addresses = append(traces.Addresses, addr)
+		}
+	}
+	for _, trace := range traces.Traces {
+		touch(trace.From)
+		touch(trace.To)
+	}
+	if _, ok := t.chain.Engine().(*ethash.Ethash); ok {
+		for _, reward := range ethash.BlockRewards(t.chain.Config(), block.Header(), block.Uncles()) {
+			touch(reward.Beneficiary)
+		}
+	}
+	return traces, nil
+}
+
+// advance finalizes a traced block on top of a carried state and commits it,
+// so that it can be used to trace the next block. The root of the previously
+// carried state is released.
+func (t *callTraceIndexer) advance(block *types.Block, statedb *state.StateDB, proot common.Hash) (common.Hash, error) {
+	t.chain.Engine().Finalize(t.chain, block.Header(), statedb, block.Transactions(), block.Uncles())
+
+	root, err := statedb.Commit(t.chain.Config().IsEIP158(block.Number()))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if root != block.Root() {
+		return common.Hash{}, fmt.Errorf("state root mismatch: have %x, want %x", root, block.Root())
+	}
+	if err := statedb.Reset(root); err != nil {
+		return common.Hash{}, err
+	}
+	database := statedb.Database().TrieDB()
+	database.Reference(root, common.Hash{})
+	if proot != (common.Hash{}) {
+		database.Dereference(proot)
+	}
+	return root, nil
+}
+
+// prune deletes the traces of the blocks beyond the retention limit.
+func (t *callTraceIndexer) prune(tail, head uint64) {
+	if t.limit == 0 || head < t.limit || head-t.limit+1 <= tail {
+		return
+	}
+	batch := t.db.NewBatch()
+	for number := tail; number < head-t.limit+1; number++ {
+		rawdb.DeleteCallTraces(batch, number, rawdb.ReadCallTraces(t.db, number))
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				log.Crit("Failed to prune call traces", "err", err)
+			}
+			batch.Reset()
+		}
+	}
+	rawdb.WriteCallTraceTail(batch, head-t.limit+1)
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to prune call traces", "err", err)
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Tests that trace filters are served from the call trace index.
+func TestTraceFilter(t *testing.T) {
+	api, blocks := newTestTraceAPI(t)
+	if _, err := api.Filter(context.Background(), TraceFilterArgs{}); err != errTraceIndexDisabled {
+		t.Fatalf("filter error mismatch: have %v, want %v", err, errTraceIndexDisabled)
+	}
+	api.eth.callTraceIndexer = newCallTraceIndexer(api.eth, 0)
+	api.eth.callTraceIndexer.index(blocks[1].Header())
+
+	var (
+		one = uint64(1)
+		two = rpc.BlockNumber(2)
+	)
+	tests := []struct {
+		args  TraceFilterArgs
+		types []string // Types of the expected traces
+		want  []common.Address
+	}{
+		// All the calls and rewards
+		{TraceFilterArgs{}, []string{"call", "call", "reward", "call", "reward", "reward"}, nil},
+		// Calls made by a contract
+		{TraceFilterArgs{FromAddress: []common.Address{traceCaller}}, []string{"call"}, []common.Address{traceCallee}},
+		// Calls received by a contract
+		{TraceFilterArgs{ToAddress: []common.Address{traceCallee}}, []string{"call"}, []common.Address{traceCallee}},
+		// Rewards only match recipients
+		{TraceFilterArgs{ToAddress: []common.Address{{0x01}}}, []string{"reward", "reward"}, []common.Address{{0x01}, {0x01}}},
+		{TraceFilterArgs{ToAddress: []common.Address{{0x03}}}, []string{"reward"}, []common.Address{{0x03}}},
+		{TraceFilterArgs{FromAddress: []common.Address{{0x03}}}, nil, nil},
+		// Paginated and ranged results
+		{TraceFilterArgs{After: 2, Count: &one}, []string{"reward"}, []common.Address{{0x01}}},
+		{TraceFilterArgs{FromBlock: &two, ToBlock: &two}, []string{"call", "reward", "reward"}, nil},
+	}
+
+	for i, tt := range tests {
+		traces, err := api.Filter(context.Background(), tt.args)
+		if err != nil {
+			t.Fatalf("test %d: failed to filter traces: %v", i, err)
+		}
+		if len(traces) != len(tt.types) {
+			t.Fatalf("test %d: trace count mismatch: have %d, want %d", i, len(traces), len(tt.types))
+		}
+		for j, trace := range traces {
+			if trace.Type != tt.types[j] {
+				t.Errorf("test %d, trace %d: type mismatch: have %s, want %s", i, j, trace.Type, tt.types[j])
+			}
+			if tt.want == nil {
+				continue
+			}
+			var to common.Address
+			switch action := trace.Action.(type) {
+			case *parityCallAction:
+				to = action.To
+			case *parityRewardAction:
+				to = action.Author
+			}
+			if to != tt.want[j] {
+				t.Errorf("test %d, trace %d: recipient mismatch: have %x, want %x", i, j, to, tt.want[j])
+			}
+		}
+	}
+	// Ranges listing every block must be bounded, unless filtered by address
+	rawdb.WriteCallTraceHead(api.eth.ChainDb(), maxTraceFilterRange+1)
+	if _, err := api.Filter(context.Background(), TraceFilterArgs{}); err == nil {
+		t.Errorf("excessive range filtered")
+	}
+	if _, err := api.Filter(context.Background(), TraceFilterArgs{ToAddress: []common.Address{traceCallee}}); err != nil {
+		t.Errorf("failed to filter excessive range by address: %v", err)
+	}
+}
+
+// Tests that the call traces of old blocks are pruned beyond the limit.
+func TestCallTraceIndexPruning(t *testing.T) {
+	api, blocks := newTestTraceAPI(t)
+	db := api.eth.ChainDb()
+
+	indexer := newCallTraceIndexer(api.eth, 2)
+	indexer.index(blocks[0].Header())
+	if blocks := rawdb.ReadCallTraceBlocks(db, traceCallee, 0, 2); len(blocks) != 1 || blocks[0] != 1 {
+		t.Fatalf("indexed blocks mismatch: have %v, want [1]", blocks)
+	}
+	indexer.limit = 1
+	indexer.index(blocks[1].Header())
+
+	if tail := rawdb.ReadCallTraceTail(db); tail == nil || *tail != 2 {
+		t.Fatalf("index tail mismatch: have %v, want 2", tail)
+	}
+	if head := rawdb.ReadCallTraceHead(db); head == nil || *head != 2 {
+		t.Fatalf("index head mismatch: have %v, want 2", head)
+	}
+	if traces := rawdb.ReadCallTraces(db, 1); traces != nil {
+		t.Errorf("pruned traces still present")
+	}
+	if blocks := rawdb.ReadCallTraceBlocks(db, traceCallee, 0, 2); len(blocks) != 0 {
+		t.Errorf("pruned blocks still indexed: %v", blocks)
+	}
+	api.eth.callTraceIndexer = indexer
+	if _, err := api.Filter(context.Background(), TraceFilterArgs{FromBlock: new(rpc.BlockNumber)}); err == nil {
+		t.Errorf("pruned blocks filtered")
+	}
+}
+
+// Tests that blocks can be traced on top of a state carried over from the
+// previous block, when the node doesn't have their state available.
+func TestCallTraceCarriedState(t *testing.T) {
+	api, blocks := newTestTraceAPI(t)
+	indexer := newCallTraceIndexer(api.eth, 0)
+
+	genesis := api.eth.blockchain.Genesis()
+	statedb, err := state.New(genesis.Root(), state.NewDatabase(api.eth.ChainDb()), nil)
+	if err != nil {
+		t.Fatalf("failed to open genesis state: %v", err)
+	}
+	var root common.Hash
+	for _, block := range blocks {
+		traces, err := indexer.trace(block, statedb)
+		if err != nil {
+			t.Fatalf("failed to trace block #%d: %v", block.NumberU64(), err)
+		}
+		if want := 3 - int(block.NumberU64()); len(traces.Traces) != want {
+			t.Errorf("block #%d: trace count mismatch: have %d, want %d", block.NumberU64(), len(traces.Traces), want)
+		}
+		if root, err = indexer.advance(block, statedb, root); err != nil {
+			t.Fatalf("failed to advance state over block #%d: %v", block.NumberU64(), err)
+		}
+	}
+}
+
+// Tests that blocks failing to be traced are indexed without traces and
+// reported, instead of stalling the index.
+func TestCallTraceIndexFailure(t *testing.T) {
+	api, blocks := newTestTraceAPI(t)
+	indexer := newCallTraceIndexer(api.eth, 0)
+	api.eth.callTraceIndexer = indexer
+
+	// Inject a canonical block with a transaction its sender cannot pay for
+	key, _ := crypto.GenerateKey()
+	tx, _ := types.SignTx(types.NewTransaction(0, common.Address{0x02}, big.NewInt(1000), params.TxGas, big.NewInt(1), nil), types.HomesteadSigner{}, key)
+	parent := blocks[1].Header()
+	bad := types.NewBlock(&types.Header{
+		ParentHash: parent.Hash(),
+		Number:     big.NewInt(3),
+		GasLimit:   parent.GasLimit,
+		Difficulty: parent.Difficulty,
+		Time:       parent.Time + 10,
+	}, []*types.Transaction{tx}, nil, nil, new(trie.Trie))
+
+	db := api.eth.ChainDb()
+	rawdb.WriteBlock(db, bad)
+	rawdb.WriteCanonicalHash(db, bad.Hash(), 3)
+
+	indexer.index(bad.Header())
+	if head := rawdb.ReadCallTraceHead(db); head == nil || *head != 3 {
+		t.Fatalf("index head mismatch: have %v, want 3", head)
+	}
+	if traces := rawdb.ReadCallTraces(db, 3); traces == nil || traces.Hash != bad.Hash() || traces.Error == "" || len(traces.Traces) != 0 {
+		t.Errorf("failed block indexed with %+v", traces)
+	}
+	status, err := api.IndexStatus()
+	if err != nil {
+		t.Fatalf("failed to retrieve index status: %v", err)
+	}
+	if status.Failed != 1 || status.LastBlock == nil || *status.LastBlock != 3 || status.LastError == "" {
+		t.Errorf("index status mismatch: have %+v", status)
+	}
+	// The blocks around the failing one remain filterable
+	if traces, err := api.Filter(context.Background(), TraceFilterArgs{ToAddress: []common.Address{traceCallee}}); err != nil || len(traces) != 1 {
+		t.Errorf("filter mismatch: have %d traces, err %v", len(traces), err)
+	}
+}
@@ -0,0 +1,295 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	// chainStatsThrottling is the time to wait between processing two consecutive
+	// network statistics sections.
+	chainStatsThrottling = 10 * time.Millisecond
+
+	// maxNetworkStatsWindows is the maximum number of windows a single network
+	// statistics query may return.
+	maxNetworkStatsWindows = 4096
+
+	// maxUnindexedStatsBlocks is the maximum number of blocks a single network
+	// statistics query may aggregate from the raw headers, outside the index.
+	maxUnindexedStatsBlocks = 8192
+)
+
+// NetworkStats is the RPC representation of the difficulty and timing data of
+// a window of canonical blocks.
+type NetworkStats struct {
+	From            hexutil.Uint64 `json:"from"`
+	To              hexutil.Uint64 `json:"to"`
+	Blocks          hexutil.Uint64 `json:"blocks"`
+	Difficulty      *hexutil.Big   `json:"difficulty"` // Average block difficulty
+	MinDifficulty   *hexutil.Big   `json:"minDifficulty"`
+	MaxDifficulty   *hexutil.Big   `json:"maxDifficulty"`
+	BlockTime       float64        `json:"blockTime"` // Average block time in seconds
+	MaxBlockTime    hexutil.Uint64 `json:"maxBlockTime"`
+	Hashrate        *hexutil.Big   `json:"hashrate"` // Estimated network hashes per second
+	TotalDifficulty *hexutil.Big   `json:"totalDifficulty"`
+}
+
+// ChainStatsIndexer implements a core.ChainIndexer, aggregating the difficulty
+// and timestamps of canonical headers into fixed size sections so that network
+// statistics can be served over long ranges quickly.
+type ChainStatsIndexer struct {
+	db      ethdb.Database    // database instance to read headers from and write sections into
+	section uint64            // Section is the section number being processed currently
+	head    common.Hash       // Head is the hash of the last header processed
+	stats   *rawdb.ChainStats // Statistics of the section being processed
+	last    uint64            // Timestamp of the last header processed
+}
+
+// NewChainStatsIndexer returns a chain indexer that aggregates network statistics
+// for the canonical chain.
+func NewChainStatsIndexer(db ethdb.Database, size, confirms uint64) *core.ChainIndexer {
+	backend := &ChainStatsIndexer{
+		db: db,
+	}
+	table := rawdb.NewTable(db, string(rawdb.ChainStatsIndexPrefix))
+
+	return core.NewChainIndexer(db, table, backend, size, confirms, chainStatsThrottling, "chainstats")
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new network statistics
+// section.
+func (c *ChainStatsIndexer) Reset(ctx context.Context, section uint64, lastSectionHead common.Hash) error {
+	c.section, c.head, c.stats, c.last = section, common.Hash{}, nil, 0
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, adding a new header's difficulty
+// and timestamp into the section.
+func (c *ChainStatsIndexer) Process(ctx context.Context, header *types.Header) error {
+	parentTime := c.last
+	if c.stats == nil {
+		parentTime = header.Time
+		if number := header.Number.Uint64(); number > 0 {
+			parent := rawdb.ReadHeader(c.db, header.ParentHash, number-1)
+			if parent == nil {
+				return fmt.Errorf("missing parent of header #%d", number)
+			}
+			parentTime = parent.Time
+		}
+		c.stats = new(rawdb.ChainStats)
+	}
+	td := rawdb.ReadTd(c.db, header.Hash(), header.Number.Uint64())
+	if td == nil {
+		return fmt.Errorf("missing total difficulty of header #%d", header.Number)
+	}
+	accumulateChainStats(c.stats, header, parentTime, td)
+	c.head, c.last = header.Hash(), header.Time
+	return nil
+}
+
+// Commit implements core.ChainIndexerBackend, writing the aggregated section
+// out into the database.
+func (c *ChainStatsIndexer) Commit() error {
+	if c.stats == nil {
+		return errors.New("empty network statistics section")
+	}
+	rawdb.WriteChainStats(c.db, c.section, c.head, c.stats)
+	return nil
+}
+
+// Prune returns an empty error since we don't support pruning here.
+func (c *ChainStatsIndexer) Prune(threshold uint64) error {
+	return nil
+}
+
+// accumulateChainStats adds a single header to the aggregated statistics.
+func accumulateChainStats(stats *rawdb.ChainStats, header *types.Header, parentTime uint64, td *big.Int) {
+	if stats.Blocks == 0 {
+		stats.Difficulty = new(big.Int)
+		stats.MinDifficulty = new(big.Int).Set(header.Difficulty)
+		stats.MaxDifficulty = new(big.Int).Set(header.Difficulty)
+		stats.StartTime = parentTime
+	}
+	stats.Blocks++
+	stats.Difficulty.Add(stats.Difficulty, header.Difficulty)
+	if header.Difficulty.Cmp(stats.MinDifficulty) < 0 {
+		stats.MinDifficulty.Set(header.Difficulty)
+	}
+	if header.Difficulty.Cmp(stats.MaxDifficulty) > 0 {
+		stats.MaxDifficulty.Set(header.Difficulty)
+	}
+	if header.Time > parentTime && header.Time-parentTime > stats.MaxBlockTime {
+		stats.MaxBlockTime = header.Time - parentTime
+	}
+	stats.EndTime = header.Time
+	stats.TotalDifficulty = new(big.Int).Set(td)
+}
+
+// mergeChainStats appends the statistics of a subsequent chain segment to the
+// aggregated statistics.
+func mergeChainStats(stats *rawdb.ChainStats, next *rawdb.ChainStats) {
+	if stats.Blocks == 0 {
+		stats.Difficulty = new(big.Int)
+		stats.MinDifficulty = new(big.Int).Set(next.MinDifficulty)
+		stats.MaxDifficulty = new(big.Int).Set(next.MaxDifficulty)
+		stats.StartTime = next.StartTime
+	}
+	stats.Blocks += next.Blocks
+	stats.Difficulty.Add(stats.Difficulty, next.Difficulty)
+	if next.MinDifficulty.Cmp(stats.MinDifficulty) < 0 {
+		stats.MinDifficulty.Set(next.MinDifficulty)
+	}
+	if next.MaxDifficulty.Cmp(stats.MaxDifficulty) > 0 {
+		stats.MaxDifficulty.Set(next.MaxDifficulty)
+	}
+	if next.MaxBlockTime > stats.MaxBlockTime {
+		stats.MaxBlockTime = next.MaxBlockTime
+	}
+	stats.EndTime = next.EndTime
+	stats.TotalDifficulty = new(big.Int).Set(next.TotalDifficulty)
+}
+
+// networkStats aggregates the network statistics of the canonical blocks in the
+// inclusive range [from, to] into consecutive windows of the given size. The
+// range start and the window size are aligned to the indexer sections, so that
+// all but the most recent blocks are served from the index.
+func (s *Ethereum) networkStats(from, to, window uint64) ([]*NetworkStats, error) {
+	size := params.ChainStatsBlocks
+	if head := s.blockchain.CurrentHeader().Number.Uint64(); to > head {
+		to = head
+	}
+	if from > to {
+		return nil, fmt.Errorf("invalid range: from %d > to %d", from, to)
+	}
+	from = from / size * size
+
+	// Clamp the window to the range before aligning it, avoiding overflows
+	if window < size {
+		window = size
+	}
+	if window > to-from+1 {
+		window = to - from + 1
+	}
+	window = (window + size - 1) / size * size
+
+	if n := (to-from)/window + 1; n > maxNetworkStatsWindows {
+		return nil, fmt.Errorf("too many windows requested: %d > %d", n, maxNetworkStatsWindows)
+	}
+	// Blocks not covered by the index are aggregated from the raw headers, don't
+	// walk arbitrarily long chain segments doing so while the index lags behind
+	indexed, _, _ := s.chainStatsIndexer.Sections()
+	if tail := indexed * size; to >= tail {
+		if tail < from {
+			tail = from
+		}
+		if n := to - tail + 1; n > maxUnindexedStatsBlocks {
+			return nil, fmt.Errorf("range not yet indexed: %d unindexed blocks > %d", n, maxUnindexedStatsBlocks)
+		}
+	}
+
+	var results []*NetworkStats
+	for start := from; start <= to; start += window {
+		end := start + window - 1
+		if end > to {
+			end = to
+		}
+		stats := new(rawdb.ChainStats)
+		for section := start / size; section*size <= end; section++ {
+			first, last := section*size, (section+1)*size-1
+			if last <= end && section < indexed {
+				if cached := rawdb.ReadChainStats(s.chainDb, section, s.chainStatsIndexer.SectionHead(section)); cached != nil {
+					mergeChainStats(stats, cached)
+					continue
+				}
+			}
+			if last > end {
+				last = end
+			}
+			if err := s.accumulateHeaders(stats, first, last); err != nil {
+				return nil, err
+			}
+		}
+		results = append(results, newNetworkStats(start, end, stats))
+	}
+	return results, nil
+}
+
+// accumulateHeaders aggregates the canonical headers in the inclusive range
+// [from, to] directly, used for the chain segments not yet indexed.
+func (s *Ethereum) accumulateHeaders(stats *rawdb.ChainStats, from, to uint64) error {
+	var parentTime uint64
+	for number := from; number <= to; number++ {
+		header := s.blockchain.GetHeaderByNumber(number)
+		if header == nil {
+			return fmt.Errorf("header #%d not found", number)
+		}
+		if number == from {
+			parentTime = header.Time
+			if number > 0 {
+				parent := s.blockchain.GetHeader(header.ParentHash, number-1)
+				if parent == nil {
+					return fmt.Errorf("header #%d not found", number-1)
+				}
+				parentTime = parent.Time
+			}
+		}
+		td := s.blockchain.GetTd(header.Hash(), number)
+		if td == nil {
+			return fmt.Errorf("total difficulty of header #%d not found", number)
+		}
+		accumulateChainStats(stats, header, parentTime, td)
+		parentTime = header.Time
+	}
+	return nil
+}
+
+// newNetworkStats converts aggregated chain statistics into their RPC form.
+func newNetworkStats(from, to uint64, stats *rawdb.ChainStats) *NetworkStats {
+	var (
+		blocks = new(big.Int).SetUint64(stats.Blocks)
+		span   = stats.EndTime - stats.StartTime
+	)
+	result := &NetworkStats{
+		From:            hexutil.Uint64(from),
+		To:              hexutil.Uint64(to),
+		Blocks:          hexutil.Uint64(stats.Blocks),
+		Difficulty:      (*hexutil.Big)(new(big.Int).Div(stats.Difficulty, blocks)),
+		MinDifficulty:   (*hexutil.Big)(stats.MinDifficulty),
+		MaxDifficulty:   (*hexutil.Big)(stats.MaxDifficulty),
+		BlockTime:       float64(span) / float64(stats.Blocks),
+		MaxBlockTime:    hexutil.Uint64(stats.MaxBlockTime),
+		Hashrate:        (*hexutil.Big)(new(big.Int)),
+		TotalDifficulty: (*hexutil.Big)(stats.TotalDifficulty),
+	}
+	if span > 0 {
+		result.Hashrate = (*hexutil.Big)(new(big.Int).Div(stats.Difficulty, new(big.Int).SetUint64(span)))
+	}
+	return result
+}
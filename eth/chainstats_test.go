@@ -0,0 +1,99 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that network statistics served from the section index match the ones
+// computed directly from the headers.
+func TestNetworkStats(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		engine  = ethash.NewFaker()
+		genesis = (&core.Genesis{Config: params.TestChainConfig, Difficulty: big.NewInt(131072)}).MustCommit(db)
+		blocks  = 3*params.ChainStatsBlocks + 100
+	)
+	chain, _ := core.NewBlockChain(db, nil, params.TestChainConfig, engine, vm.Config{}, nil, nil)
+	defer chain.Stop()
+
+	gen, _ := core.GenerateChain(params.TestChainConfig, genesis, engine, db, int(blocks), func(i int, b *core.BlockGen) {
+		b.OffsetTime(int64(i % 7)) // Vary the block times and thus the difficulties
+	})
+	if _, err := chain.InsertChain(gen); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	indexer := NewChainStatsIndexer(db, params.ChainStatsBlocks, params.ChainStatsConfirms)
+	defer indexer.Close()
+	indexer.Start(chain)
+
+	for i := 0; i < 500; i++ {
+		if sections, _, _ := indexer.Sections(); sections == 3 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if sections, _, _ := indexer.Sections(); sections != 3 {
+		t.Fatalf("indexed section count mismatch: have %d, want %d", sections, 3)
+	}
+	eth := &Ethereum{chainDb: db, blockchain: chain, chainStatsIndexer: indexer}
+
+	results, err := eth.networkStats(0, blocks, 2*params.ChainStatsBlocks)
+	if err != nil {
+		t.Fatalf("failed to retrieve network stats: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("window count mismatch: have %d, want %d", len(results), 2)
+	}
+	// Windows beyond the range must collapse into a single one, not overflow
+	whole, err := eth.networkStats(0, blocks, math.MaxUint64)
+	if err != nil {
+		t.Fatalf("failed to retrieve network stats over a huge window: %v", err)
+	}
+	if len(whole) != 1 || whole[0].From != 0 || uint64(whole[0].To) != blocks {
+		t.Fatalf("huge window mismatch: have %d windows", len(whole))
+	}
+	for i, result := range results {
+		want := new(rawdb.ChainStats)
+		if err := eth.accumulateHeaders(want, uint64(result.From), uint64(result.To)); err != nil {
+			t.Fatalf("window %d: failed to accumulate headers: %v", i, err)
+		}
+		expect := newNetworkStats(uint64(result.From), uint64(result.To), want)
+		if result.Blocks != expect.Blocks || result.BlockTime != expect.BlockTime || result.MaxBlockTime != expect.MaxBlockTime {
+			t.Errorf("window %d: timing mismatch: have %d/%v/%d, want %d/%v/%d", i, result.Blocks, result.BlockTime, result.MaxBlockTime, expect.Blocks, expect.BlockTime, expect.MaxBlockTime)
+		}
+		if result.Difficulty.ToInt().Cmp(expect.Difficulty.ToInt()) != 0 || result.Hashrate.ToInt().Cmp(expect.Hashrate.ToInt()) != 0 {
+			t.Errorf("window %d: difficulty mismatch: have %v/%v, want %v/%v", i, result.Difficulty, result.Hashrate, expect.Difficulty, expect.Hashrate)
+		}
+		if result.MinDifficulty.ToInt().Cmp(expect.MinDifficulty.ToInt()) != 0 || result.MaxDifficulty.ToInt().Cmp(expect.MaxDifficulty.ToInt()) != 0 {
+			t.Errorf("window %d: difficulty bounds mismatch: have %v-%v, want %v-%v", i, result.MinDifficulty, result.MaxDifficulty, expect.MinDifficulty, expect.MaxDifficulty)
+		}
+		if td := chain.GetTdByHash(chain.GetHeaderByNumber(uint64(result.To)).Hash()); result.TotalDifficulty.ToInt().Cmp(td) != 0 {
+			t.Errorf("window %d: total difficulty mismatch: have %v, want %v", i, result.TotalDifficulty, td)
+		}
+	}
+}
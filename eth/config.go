@@ -79,6 +79,8 @@ var DefaultConfig = Config{
 	RPCGasCap:   25000000,
 	GPO:         DefaultFullGPOConfig,
 	RPCTxFeeCap: 1, // 1 ether
+
+	SideBlocksLimit: 90000,
 }
 
 func init() {
@@ -122,6 +124,12 @@ type Config struct {
 
 	TxLookupLimit uint64 `toml:",omitempty"` // The maximum number of blocks from head whose tx indices are reserved.
 
+	TraceIndex      bool   `toml:",omitempty"` // Whether to index the call traces of the canonical blocks for trace filters
+	TraceIndexLimit uint64 `toml:",omitempty"` // The maximum number of blocks from head whose call traces are retained.
+
+	SideBlocks      bool   `toml:",omitempty"` // Whether to record the side chain blocks seen for uncle analytics
+	SideBlocksLimit uint64 `toml:",omitempty"` // The maximum number of blocks from head whose side blocks are retained.
+
 	// Whitelist of required block number -> hash values to accept
 	Whitelist map[uint64]common.Hash `toml:"-"`
 
@@ -175,6 +183,9 @@ type Config struct {
 	// Type of the EVM interpreter ("" for default)
 	EVMInterpreter string
 
+	// Cross-check the external EVM interpreter against the built-in one
+	EVMDifferential bool
+
 	// RPCGasCap is the global gas cap for eth-call variants.
 	RPCGasCap uint64 `toml:",omitempty"`
 
@@ -182,6 +193,10 @@ type Config struct {
 	// send-transction variants. The unit is ether.
 	RPCTxFeeCap float64 `toml:",omitempty"`
 
+	// RPCErrorSignatures is the path of a 4byte database, in the JSON format of
+	// signer/fourbyte, decoding the custom errors of reverted calls over rpc.
+	RPCErrorSignatures string `toml:",omitempty"`
+
 	// Checkpoint is a hardcoded checkpoint which can be nil.
 	Checkpoint *params.TrustedCheckpoint `toml:",omitempty"`
 
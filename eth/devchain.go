@@ -0,0 +1,436 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	// devTxChanSize is the size of the channel listening to new transactions.
+	devTxChanSize = 256
+
+	// maxDevMineBlocks is the maximum number of blocks a single mining request
+	// may seal.
+	maxDevMineBlocks = 1024
+)
+
+var (
+	errDevUnknownSnapshot = errors.New("unknown snapshot")
+	errDevNotImpersonated = errors.New("sender not impersonated")
+
+	// errDevAlteredBlock is returned when re-executing a development block that
+	// carries unsigned transactions or direct state modifications. Neither is
+	// reproducible from the block contents, so such blocks cannot be traced.
+	errDevAlteredBlock = errors.New("development block with impersonated transactions or state modifications cannot be re-executed")
+)
+
+// devSnapshot is a chain position the development chain can be reverted to.
+type devSnapshot struct {
+	number uint64      // Head block number at the time of the snapshot
+	hash   common.Hash // Head block hash at the time of the snapshot
+	offset int64       // Clock offset at the time of the snapshot
+}
+
+// devTx is a transaction sent on behalf of an impersonated account, waiting
+// to be included into the next block.
+type devTx struct {
+	tx   *types.Transaction
+	from common.Address
+}
+
+// devChain produces blocks on demand for instantly sealed development chains,
+// providing the chain and clock manipulations needed for contract testing.
+type devChain struct {
+	eth    *Ethereum
+	engine consensus.Engine
+
+	automine     bool                        // Whether to seal a block on every new transaction
+	offset       int64                       // Seconds the block clock runs ahead of the wall clock
+	nextTime     uint64                      // Timestamp of the next block (0 = use the block clock)
+	snapshots    []*devSnapshot              // Chain positions to revert to, indexed by id
+	impersonated map[common.Address]struct{} // Accounts allowed to send without signatures
+	pending      []*devTx                    // Impersonated transactions waiting for a block
+
+	txsCh  chan core.NewTxsEvent
+	txsSub event.Subscription
+
+	lock sync.Mutex // Protects the chain manipulation and all of the above fields
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newDevChain creates a block producer for an instantly sealed development
+// chain with automining enabled.
+func newDevChain(eth *Ethereum) *devChain {
+	return &devChain{
+		eth:          eth,
+		engine:       eth.engine,
+		automine:     true,
+		impersonated: make(map[common.Address]struct{}),
+		txsCh:        make(chan core.NewTxsEvent, devTxChanSize),
+		quit:         make(chan struct{}),
+	}
+}
+
+// start subscribes to the transaction pool to mine transactions as they arrive.
+func (d *devChain) start() {
+	d.txsSub = d.eth.txPool.SubscribeNewTxsEvent(d.txsCh)
+
+	d.wg.Add(1)
+	go d.loop()
+}
+
+// stop terminates the automining loop.
+func (d *devChain) stop() {
+	close(d.quit)
+	d.wg.Wait()
+}
+
+// loop seals a new block whenever transactions arrive and automining is on.
+func (d *devChain) loop() {
+	defer d.wg.Done()
+	defer d.txsSub.Unsubscribe()
+
+	for {
+		select {
+		case <-d.txsCh:
+			d.lock.Lock()
+			if d.automine {
+				if _, err := d.commit(nil, true); err != nil {
+					log.Error("Failed to seal development block", "err", err)
+				}
+			}
+			d.lock.Unlock()
+
+		case <-d.txsSub.Err():
+			return
+		case <-d.quit:
+			return
+		}
+	}
+}
+
+// mine seals the given number of blocks, including any pending transactions.
+func (d *devChain) mine(count int) ([]common.Hash, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	hashes := make([]common.Hash, 0, count)
+	for i := 0; i < count; i++ {
+		block, err := d.commit(nil, true)
+		if err != nil {
+			return hashes, err
+		}
+		hashes = append(hashes, block.Hash())
+	}
+	return hashes, nil
+}
+
+// setAutomine toggles whether blocks are sealed as soon as transactions arrive.
+func (d *devChain) setAutomine(enabled bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.automine = enabled
+}
+
+// setNextBlockTimestamp sets the timestamp of the next block to seal, moving
+// the block clock along with it.
+func (d *devChain) setNextBlockTimestamp(timestamp uint64) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if head := d.eth.blockchain.CurrentBlock().Time(); timestamp <= head {
+		return fmt.Errorf("timestamp %d not above head timestamp %d", timestamp, head)
+	}
+	d.nextTime = timestamp
+	return nil
+}
+
+// increaseTime moves the block clock ahead by the given number of seconds,
+// returning the total offset from the wall clock.
+func (d *devChain) increaseTime(seconds uint64) int64 {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.offset += int64(seconds)
+	return d.offset
+}
+
+// snapshot records the current chain position, returning its id.
+func (d *devChain) snapshot() uint64 {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	head := d.eth.blockchain.CurrentBlock()
+	d.snapshots = append(d.snapshots, &devSnapshot{
+		number: head.NumberU64(),
+		hash:   head.Hash(),
+		offset: d.offset,
+	})
+	return uint64(len(d.snapshots) - 1)
+}
+
+// revert rewinds the chain and the block clock to the given snapshot. The
+// snapshot and all the ones taken after it are discarded.
+func (d *devChain) revert(id uint64) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if id >= uint64(len(d.snapshots)) {
+		return errDevUnknownSnapshot
+	}
+	snap := d.snapshots[id]
+	if d.eth.blockchain.GetCanonicalHash(snap.number) != snap.hash {
+		return fmt.Errorf("snapshot block #%d [%x…] no longer canonical", snap.number, snap.hash[:4])
+	}
+	if err := d.eth.blockchain.SetHead(snap.number); err != nil {
+		return err
+	}
+	if head := d.eth.blockchain.CurrentBlock(); head.Hash() != snap.hash {
+		return fmt.Errorf("state of snapshot block #%d unavailable, rewound to #%d", snap.number, head.NumberU64())
+	}
+	d.snapshots = d.snapshots[:id]
+	d.offset, d.nextTime, d.pending = snap.offset, 0, nil
+	return nil
+}
+
+// modify seals a block without transactions, applying the given modification
+// to the state first so that it becomes visible right away.
+func (d *devChain) modify(fn func(statedb *state.StateDB)) (common.Hash, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	block, err := d.commit(fn, false)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return block.Hash(), nil
+}
+
+// impersonate toggles whether transactions may be sent on behalf of the given
+// account without a signature.
+func (d *devChain) impersonate(addr common.Address, enabled bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if enabled {
+		d.impersonated[addr] = struct{}{}
+	} else {
+		delete(d.impersonated, addr)
+	}
+}
+
+// impersonatedNonce returns the next nonce of an impersonated account, taking
+// the transactions waiting for a block into account.
+func (d *devChain) impersonatedNonce(from common.Address) (uint64, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	statedb, err := d.eth.blockchain.State()
+	if err != nil {
+		return 0, err
+	}
+	nonce := statedb.GetNonce(from)
+	for _, ptx := range d.pending {
+		if ptx.from == from {
+			nonce++
+		}
+	}
+	return nonce, nil
+}
+
+// sendImpersonated queues an unsigned transaction from an impersonated account
+// for inclusion, sealing it right away if automining is on.
+func (d *devChain) sendImpersonated(from common.Address, tx *types.Transaction) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if _, ok := d.impersonated[from]; !ok {
+		return errDevNotImpersonated
+	}
+	d.pending = append(d.pending, &devTx{tx: tx, from: from})
+	if d.automine {
+		if _, err := d.commit(nil, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkReproducible returns an error if the given block was altered by the
+// development chain, as re-executing it would not yield its state.
+func checkReproducible(db ethdb.KeyValueReader, block *types.Block) error {
+	if rawdb.HasAlteredBlock(db, block.Hash()) {
+		return fmt.Errorf("block #%d [%x…]: %w", block.NumberU64(), block.Hash().Bytes()[:4], errDevAlteredBlock)
+	}
+	return nil
+}
+
+// timestamp returns the timestamp of the next block to seal on top of the
+// given parent, consuming any explicitly requested one.
+func (d *devChain) timestamp(parent *types.Block) uint64 {
+	now := time.Now().Unix()
+	timestamp := uint64(now + d.offset)
+	if d.nextTime != 0 {
+		timestamp, d.offset = d.nextTime, int64(d.nextTime)-now
+		d.nextTime = 0
+	}
+	if timestamp <= parent.Time() {
+		timestamp = parent.Time() + 1
+	}
+	return timestamp
+}
+
+// commit assembles, seals and imports a new block on top of the current head.
+// The optional modification is applied to the parent state before executing
+// any transactions. It assumes the lock is held.
+func (d *devChain) commit(modify func(statedb *state.StateDB), withTxs bool) (*types.Block, error) {
+	var (
+		chain    = d.eth.blockchain
+		config   = chain.Config()
+		parent   = chain.CurrentBlock()
+		coinbase common.Address
+	)
+	if eb, err := d.eth.Etherbase(); err == nil {
+		coinbase = eb
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   core.CalcGasLimit(parent, d.eth.config.Miner.GasFloor, d.eth.config.Miner.GasCeil),
+		Time:       d.timestamp(parent),
+		Coinbase:   coinbase,
+		Extra:      makeExtraData(d.eth.config.Miner.ExtraData),
+	}
+	if config.IsEIP1559(header.Number) {
+		header.BaseFee = misc.CalcBaseFee(config, parent.Header())
+		if !config.IsEIP1559(parent.Number()) {
+			header.GasLimit = parent.GasLimit() * params.ElasticityMultiplier
+		}
+	}
+	if err := d.engine.Prepare(chain, header); err != nil {
+		return nil, err
+	}
+	statedb, err := chain.StateAt(parent.Root())
+	if err != nil {
+		return nil, err
+	}
+	if modify != nil {
+		modify(statedb)
+	}
+	var (
+		gasPool  = new(core.GasPool).AddGas(header.GasLimit)
+		txs      []*types.Transaction
+		receipts []*types.Receipt
+		altered  = modify != nil
+	)
+	if withTxs {
+		// Impersonated transactions go first, in the order they were sent
+		for _, ptx := range d.pending {
+			statedb.Prepare(ptx.tx.Hash(), common.Hash{}, len(txs))
+
+			snap := statedb.Snapshot()
+			receipt, err := core.ApplyImpersonatedTransaction(config, chain, &coinbase, gasPool, statedb, header, ptx.tx, ptx.from, &header.GasUsed, *chain.GetVMConfig())
+			if err != nil {
+				statedb.RevertToSnapshot(snap)
+				log.Warn("Dropping impersonated transaction", "hash", ptx.tx.Hash(), "from", ptx.from, "err", err)
+				continue
+			}
+			txs, receipts = append(txs, ptx.tx), append(receipts, receipt)
+			altered = true
+		}
+		d.pending = nil
+
+		// Fill the rest of the block from the transaction pool
+		pending, err := d.eth.txPool.Pending()
+		if err != nil {
+			return nil, err
+		}
+		set := types.NewTransactionsByPriceAndNonce(types.MakeSigner(config, header.Number), pending, header.BaseFee)
+		for {
+			if gasPool.Gas() < params.TxGas {
+				break
+			}
+			tx := set.Peek()
+			if tx == nil {
+				break
+			}
+			statedb.Prepare(tx.Hash(), common.Hash{}, len(txs))
+
+			snap := statedb.Snapshot()
+			receipt, err := core.ApplyTransaction(config, chain, &coinbase, gasPool, statedb, header, tx, &header.GasUsed, *chain.GetVMConfig())
+			if err != nil {
+				statedb.RevertToSnapshot(snap)
+				log.Trace("Skipping transaction", "hash", tx.Hash(), "err", err)
+				set.Pop()
+				continue
+			}
+			txs, receipts = append(txs, tx), append(receipts, receipt)
+			set.Shift()
+		}
+	}
+	block, err := d.engine.FinalizeAndAssemble(chain, header, statedb, txs, nil, receipts)
+	if err != nil {
+		return nil, err
+	}
+	results := make(chan *types.Block, 1)
+	if err := d.engine.Seal(chain, block, results, nil); err != nil {
+		return nil, err
+	}
+	block = <-results
+
+	// Fill in the block location fields now that the hash is known
+	var logs []*types.Log
+	for i, receipt := range receipts {
+		receipt.BlockHash = block.Hash()
+		receipt.BlockNumber = block.Number()
+		receipt.TransactionIndex = uint(i)
+		for _, log := range receipt.Logs {
+			log.BlockHash = block.Hash()
+		}
+		logs = append(logs, receipt.Logs...)
+	}
+	// Mark blocks that cannot be re-executed before they become visible
+	if altered {
+		rawdb.WriteAlteredBlock(d.eth.chainDb, block.Hash())
+	}
+	if _, err := chain.WriteBlockWithState(block, receipts, logs, statedb, true); err != nil {
+		return nil, err
+	}
+	d.eth.eventMux.Post(core.NewMinedBlockEvent{Block: block})
+
+	log.Info("Sealed new development block", "number", block.Number(), "hash", block.Hash(), "txs", len(txs), "gas", block.GasUsed(), "time", block.Time())
+	return block, nil
+}
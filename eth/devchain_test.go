@@ -0,0 +1,218 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/instaseal"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// newTestDevChain creates an instantly sealed development chain funding the
+// given account.
+func newTestDevChain(t *testing.T, faucet common.Address) (*devChain, *core.BlockChain, *core.TxPool) {
+	db := rawdb.NewMemoryDatabase()
+	genesis := core.DeveloperInstantGenesisBlock(faucet)
+	genesis.MustCommit(db)
+
+	engine := instaseal.New()
+	chain, err := core.NewBlockChain(db, nil, genesis.Config, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	poolConfig := core.DefaultTxPoolConfig
+	poolConfig.Journal = ""
+	pool := core.NewTxPool(poolConfig, genesis.Config, chain)
+
+	config := DefaultConfig
+	eth := &Ethereum{
+		config:     &config,
+		chainDb:    db,
+		blockchain: chain,
+		txPool:     pool,
+		engine:     engine,
+		eventMux:   new(event.TypeMux),
+		etherbase:  common.HexToAddress("0xc0ffee"),
+	}
+	return newDevChain(eth), chain, pool
+}
+
+// Tests that development blocks are sealed on demand and on new transactions,
+// with the requested timestamps.
+func TestDevChainMining(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		sender = crypto.PubkeyToAddress(key.PublicKey)
+		signer = types.HomesteadSigner{}
+	)
+	dev, chain, pool := newTestDevChain(t, sender)
+	defer chain.Stop()
+	defer pool.Stop()
+
+	dev.start()
+	defer dev.stop()
+
+	// Transactions should be sealed right away with automining
+	tx, _ := types.SignTx(types.NewTransaction(0, common.Address{0x01}, big.NewInt(1), params.TxGas, big.NewInt(1), nil), signer, key)
+	if err := pool.AddLocal(tx); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	for i := 0; i < 100 && chain.CurrentBlock().NumberU64() == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if head := chain.CurrentBlock(); head.NumberU64() != 1 || len(head.Transactions()) != 1 {
+		t.Fatalf("automined block mismatch: have #%d with %d txs, want #1 with 1 tx", head.NumberU64(), len(head.Transactions()))
+	}
+	// Without automining, transactions should wait for an explicit request
+	dev.setAutomine(false)
+	for i := 0; i < 100; i++ {
+		if pending, _ := pool.Stats(); pending == 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	tx, _ = types.SignTx(types.NewTransaction(1, common.Address{0x01}, big.NewInt(1), params.TxGas, big.NewInt(1), nil), signer, key)
+	if err := pool.AddLocal(tx); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if head := chain.CurrentBlock().NumberU64(); head != 1 {
+		t.Fatalf("block sealed without automining: head #%d", head)
+	}
+	future := uint64(time.Now().Unix()) + 3600
+	if err := dev.setNextBlockTimestamp(future); err != nil {
+		t.Fatalf("failed to set next timestamp: %v", err)
+	}
+	hashes, err := dev.mine(2)
+	if err != nil {
+		t.Fatalf("failed to mine blocks: %v", err)
+	}
+	if len(hashes) != 2 || chain.CurrentBlock().Hash() != hashes[1] {
+		t.Fatalf("mined blocks mismatch: have %x, head %x", hashes, chain.CurrentBlock().Hash())
+	}
+	first, second := chain.GetBlockByNumber(2), chain.GetBlockByNumber(3)
+	if len(first.Transactions()) != 1 || first.Transactions()[0].Hash() != tx.Hash() {
+		t.Errorf("pending transaction not included")
+	}
+	if first.Time() != future {
+		t.Errorf("timestamp mismatch: have %d, want %d", first.Time(), future)
+	}
+	if second.Time() <= future || second.Time() > future+2 {
+		t.Errorf("subsequent timestamp mismatch: have %d, want just after %d", second.Time(), future)
+	}
+	// Mining requests must be bounded
+	api := &PrivateDevAPI{e: dev.eth, dev: dev}
+	count := hexutil.Uint64(maxDevMineBlocks + 1)
+	if _, err := api.Mine(&count); err == nil {
+		t.Errorf("excessive mining request accepted")
+	}
+	// Increasing time should move subsequent blocks ahead
+	dev.increaseTime(86400)
+	if _, err := dev.mine(1); err != nil {
+		t.Fatalf("failed to mine block: %v", err)
+	}
+	if have := chain.CurrentBlock().Time(); have < future+86400 {
+		t.Errorf("increased timestamp mismatch: have %d, want at least %d", have, future+86400)
+	}
+}
+
+// Tests that the development chain can be reverted to snapshots, and that state
+// modifications and impersonated transactions take effect.
+func TestDevChainManipulation(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		faucet = crypto.PubkeyToAddress(key.PublicKey)
+		whale  = common.HexToAddress("0x1234")
+		target = common.HexToAddress("0x5678")
+	)
+	dev, chain, pool := newTestDevChain(t, faucet)
+	defer chain.Stop()
+	defer pool.Stop()
+
+	id := dev.snapshot()
+
+	// Fund an account nobody holds the key of and send funds on its behalf
+	if _, err := dev.modify(func(statedb *state.StateDB) {
+		statedb.SetBalance(whale, big.NewInt(params.Ether))
+		statedb.SetState(whale, common.Hash{0x01}, common.Hash{0x02})
+	}); err != nil {
+		t.Fatalf("failed to modify state: %v", err)
+	}
+	tx := types.NewTransaction(0, target, big.NewInt(1000), params.TxGas, big.NewInt(1), nil)
+	if err := dev.sendImpersonated(whale, tx); err != errDevNotImpersonated {
+		t.Fatalf("sending from non impersonated account: have %v, want %v", err, errDevNotImpersonated)
+	}
+	dev.impersonate(whale, true)
+	if err := dev.sendImpersonated(whale, tx); err != nil {
+		t.Fatalf("failed to send impersonated transaction: %v", err)
+	}
+	if head := chain.CurrentBlock(); head.NumberU64() != 2 || len(head.Transactions()) != 1 {
+		t.Fatalf("impersonated block mismatch: have #%d with %d txs, want #2 with 1 tx", head.NumberU64(), len(head.Transactions()))
+	}
+	statedb, _ := chain.State()
+	if balance := statedb.GetBalance(target); balance.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("target balance mismatch: have %v, want %v", balance, 1000)
+	}
+	if slot := statedb.GetState(whale, common.Hash{0x01}); slot != (common.Hash{0x02}) {
+		t.Errorf("storage mismatch: have %x, want %x", slot, common.Hash{0x02})
+	}
+	if nonce, _ := dev.impersonatedNonce(whale); nonce != 1 {
+		t.Errorf("impersonated nonce mismatch: have %d, want %d", nonce, 1)
+	}
+	receipts := chain.GetReceiptsByHash(chain.CurrentBlock().Hash())
+	if len(receipts) != 1 || receipts[0].Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("impersonated transaction failed")
+	}
+	// Neither the modification nor the impersonation can be re-executed
+	debug := NewPrivateDebugAPI(dev.eth)
+	for number := uint64(1); number <= 2; number++ {
+		if _, err := debug.traceBlock(context.Background(), chain.GetBlockByNumber(number), nil); !errors.Is(err, errDevAlteredBlock) {
+			t.Errorf("block #%d: trace error mismatch: have %v, want %v", number, err, errDevAlteredBlock)
+		}
+	}
+	if _, _, _, err := debug.computeTxEnv(chain.CurrentBlock(), 0, 0); !errors.Is(err, errDevAlteredBlock) {
+		t.Errorf("transaction trace error mismatch: have %v, want %v", err, errDevAlteredBlock)
+	}
+	// Revert everything and ensure the modifications are gone
+	if err := dev.revert(id); err != nil {
+		t.Fatalf("failed to revert: %v", err)
+	}
+	if head := chain.CurrentBlock().NumberU64(); head != 0 {
+		t.Fatalf("reverted head mismatch: have #%d, want #0", head)
+	}
+	statedb, _ = chain.State()
+	if balance := statedb.GetBalance(whale); balance.Sign() != 0 {
+		t.Errorf("reverted balance mismatch: have %v, want 0", balance)
+	}
+	if err := dev.revert(id); err != errDevUnknownSnapshot {
+		t.Errorf("reverting twice: have %v, want %v", err, errDevUnknownSnapshot)
+	}
+}
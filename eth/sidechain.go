@@ -0,0 +1,327 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/metrics"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	// sideArrivalLimit is the number of recent canonical block arrival times
+	// retained to measure side block propagation delays against.
+	sideArrivalLimit = 1024
+
+	// sideEventChanSize is the size of the channels listening to chain events.
+	sideEventChanSize = 64
+
+	// maxUncleDepth is the maximum distance at which a side block may still
+	// be referenced as an uncle by a canonical block.
+	maxUncleDepth = 7
+
+	// maxSideChainRange is the maximum number of blocks a side block or uncle
+	// statistics query may span, bounding the headers and bodies it reads.
+	maxSideChainRange = 10000
+)
+
+// errSideBlocksDisabled is returned when querying side blocks without the side
+// chain tracker enabled.
+var errSideBlocksDisabled = errors.New("side block tracking disabled")
+
+var (
+	sideBlockMeter     = metrics.NewRegisteredMeter("chain/side/blocks", nil)
+	sideDelayHistogram = metrics.NewRegisteredHistogram("chain/side/delay", nil, metrics.NewExpDecaySample(1028, 0.015))
+	uncleMeter         = metrics.NewRegisteredMeter("chain/uncles", nil)
+)
+
+// SideBlockInfo is the RPC representation of a block seen by the local node
+// that did not make it into the canonical chain.
+type SideBlockInfo struct {
+	Number     hexutil.Uint64 `json:"number"`
+	Hash       common.Hash    `json:"hash"`
+	ParentHash common.Hash    `json:"parentHash"`
+	Miner      common.Address `json:"miner"`
+	Difficulty *hexutil.Big   `json:"difficulty"`
+	Timestamp  hexutil.Uint64 `json:"timestamp"`
+	Seen       hexutil.Uint64 `json:"seen"`  // Local arrival time in unix milliseconds
+	Delay      *int64         `json:"delay"` // Arrival in milliseconds relative to the canonical sibling
+	Uncle      bool           `json:"uncle"` // Whether the block was included as an uncle
+}
+
+// UncleStats contains the aggregated uncle and side block statistics of a
+// window of canonical blocks ending at the chain head.
+type UncleStats struct {
+	From       hexutil.Uint64         `json:"from"`
+	To         hexutil.Uint64         `json:"to"`
+	Blocks     hexutil.Uint64         `json:"blocks"`
+	Uncles     hexutil.Uint64         `json:"uncles"`     // Uncles referenced by canonical blocks
+	UncleRate  float64                `json:"uncleRate"`  // Uncles per canonical block
+	SideBlocks hexutil.Uint64         `json:"sideBlocks"` // Side blocks seen by the local node
+	SideRate   float64                `json:"sideRate"`   // Side blocks per canonical block
+	Miners     map[common.Address]int `json:"miners"`     // Side blocks per miner
+	AvgDelay   *float64               `json:"avgDelay"`   // Average side block delay in milliseconds
+}
+
+// sideChainTracker persists metadata about side chain blocks as they are
+// reported by the blockchain, which would otherwise only be available for
+// the short time they are eligible as uncles.
+type sideChainTracker struct {
+	db       ethdb.Database
+	chain    *core.BlockChain
+	limit    uint64     // Number of recent blocks to retain the side blocks of, 0 for all
+	arrivals *lru.Cache // Block hash -> local arrival time of recent canonical blocks
+
+	chainCh  chan core.ChainEvent
+	sideCh   chan core.ChainSideEvent
+	chainSub event.Subscription
+	sideSub  event.Subscription
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newSideChainTracker creates a side chain tracker on top of the given chain,
+// retaining the side blocks of the given number of recent blocks.
+func newSideChainTracker(db ethdb.Database, chain *core.BlockChain, limit uint64) *sideChainTracker {
+	arrivals, _ := lru.New(sideArrivalLimit)
+	return &sideChainTracker{
+		db:       db,
+		chain:    chain,
+		limit:    limit,
+		arrivals: arrivals,
+		chainCh:  make(chan core.ChainEvent, sideEventChanSize),
+		sideCh:   make(chan core.ChainSideEvent, sideEventChanSize),
+		quit:     make(chan struct{}),
+	}
+}
+
+// start subscribes to the chain events and starts recording side blocks.
+func (t *sideChainTracker) start() {
+	t.chainSub = t.chain.SubscribeChainEvent(t.chainCh)
+	t.sideSub = t.chain.SubscribeChainSideEvent(t.sideCh)
+
+	t.wg.Add(1)
+	go t.loop()
+}
+
+// stop terminates the tracker's event loop.
+func (t *sideChainTracker) stop() {
+	close(t.quit)
+	t.wg.Wait()
+}
+
+// loop is the event loop recording canonical arrivals and side blocks.
+func (t *sideChainTracker) loop() {
+	defer t.wg.Done()
+	defer t.chainSub.Unsubscribe()
+	defer t.sideSub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-t.chainCh:
+			t.handleCanon(ev.Block, uint64(time.Now().UnixNano()/int64(time.Millisecond)))
+		case ev := <-t.sideCh:
+			t.handleSide(ev.Block, uint64(time.Now().UnixNano()/int64(time.Millisecond)))
+		case <-t.chainSub.Err():
+			return
+		case <-t.sideSub.Err():
+			return
+		case <-t.quit:
+			return
+		}
+	}
+}
+
+// handleCanon records the arrival of a canonical block and completes the
+// propagation data of any side block recorded at the same height. Side blocks
+// beyond the retention limit are pruned.
+func (t *sideChainTracker) handleCanon(block *types.Block, now uint64) {
+	var (
+		number = block.NumberU64()
+		hash   = block.Hash()
+		seen   = now
+	)
+	if v, ok := t.arrivals.Get(hash); ok {
+		seen = v.(uint64)
+	} else {
+		t.arrivals.Add(hash, now)
+	}
+	uncleMeter.Mark(int64(len(block.Uncles())))
+
+	// A previously side block might have been reorged into the canonical chain
+	if rawdb.ReadSideBlock(t.db, number, hash) != nil {
+		rawdb.DeleteSideBlock(t.db, number, hash)
+	}
+	for _, side := range rawdb.ReadSideBlocks(t.db, number, number) {
+		if side.CanonSeen == 0 {
+			side.CanonSeen = seen
+			rawdb.WriteSideBlock(t.db, side)
+			sideDelayHistogram.Update(int64(side.Seen) - int64(side.CanonSeen))
+		}
+	}
+	if t.limit > 0 && number >= t.limit {
+		rawdb.DeleteSideBlocksBelow(t.db, number-t.limit+1)
+	}
+}
+
+// handleSide persists the metadata of a block that is not part of the
+// canonical chain, either because it lost the race or was reorged out.
+func (t *sideChainTracker) handleSide(block *types.Block, now uint64) {
+	var (
+		number = block.NumberU64()
+		hash   = block.Hash()
+	)
+	if rawdb.ReadSideBlock(t.db, number, hash) != nil {
+		return
+	}
+	side := &rawdb.SideBlock{
+		Hash:       hash,
+		ParentHash: block.ParentHash(),
+		Number:     number,
+		Coinbase:   block.Coinbase(),
+		Difficulty: block.Difficulty(),
+		Time:       block.Time(),
+		Seen:       now,
+	}
+	// Reorged out blocks were seen when they became canonical
+	if v, ok := t.arrivals.Get(hash); ok {
+		side.Seen = v.(uint64)
+	}
+	if canon := rawdb.ReadCanonicalHash(t.db, number); canon != (common.Hash{}) && canon != hash {
+		if v, ok := t.arrivals.Get(canon); ok {
+			side.CanonSeen = v.(uint64)
+		}
+	}
+	rawdb.WriteSideBlock(t.db, side)
+
+	sideBlockMeter.Mark(1)
+	if side.CanonSeen != 0 {
+		sideDelayHistogram.Update(int64(side.Seen) - int64(side.CanonSeen))
+	}
+}
+
+// sideBlocks retrieves the side blocks recorded in the inclusive range
+// [from, to], skipping any that since became canonical.
+func (t *sideChainTracker) sideBlocks(from, to uint64) []*rawdb.SideBlock {
+	var blocks []*rawdb.SideBlock
+	for _, side := range rawdb.ReadSideBlocks(t.db, from, to) {
+		if rawdb.ReadCanonicalHash(t.db, side.Number) == side.Hash {
+			continue
+		}
+		blocks = append(blocks, side)
+	}
+	return blocks
+}
+
+// uncleHashes collects the hashes of all uncles referenced by the canonical
+// blocks in the inclusive range [from, to].
+func (t *sideChainTracker) uncleHashes(from, to uint64) map[common.Hash]struct{} {
+	uncles := make(map[common.Hash]struct{})
+	for n := from; n <= to; n++ {
+		header := t.chain.GetHeaderByNumber(n)
+		if header == nil {
+			break
+		}
+		if header.UncleHash == types.EmptyUncleHash {
+			continue
+		}
+		if body := t.chain.GetBody(header.Hash()); body != nil {
+			for _, uncle := range body.Uncles {
+				uncles[uncle.Hash()] = struct{}{}
+			}
+		}
+	}
+	return uncles
+}
+
+// sideBlockInfos retrieves the RPC representation of the side blocks recorded
+// in the inclusive range [from, to].
+func (t *sideChainTracker) sideBlockInfos(from, to uint64) []*SideBlockInfo {
+	var (
+		sides  = t.sideBlocks(from, to)
+		uncles = t.uncleHashes(from+1, to+maxUncleDepth)
+		infos  = make([]*SideBlockInfo, 0, len(sides))
+	)
+	for _, side := range sides {
+		info := &SideBlockInfo{
+			Number:     hexutil.Uint64(side.Number),
+			Hash:       side.Hash,
+			ParentHash: side.ParentHash,
+			Miner:      side.Coinbase,
+			Difficulty: (*hexutil.Big)(side.Difficulty),
+			Timestamp:  hexutil.Uint64(side.Time),
+			Seen:       hexutil.Uint64(side.Seen),
+		}
+		if side.CanonSeen != 0 {
+			delay := int64(side.Seen) - int64(side.CanonSeen)
+			info.Delay = &delay
+		}
+		_, info.Uncle = uncles[side.Hash]
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// uncleStats aggregates the uncle and side block statistics over the given
+// number of canonical blocks ending at the current head. The window is clamped
+// to the length of the chain.
+func (t *sideChainTracker) uncleStats(window uint64) *UncleStats {
+	head := t.chain.CurrentBlock().NumberU64()
+	if window > head+1 {
+		window = head + 1
+	}
+	from := head + 1 - window
+
+	stats := &UncleStats{
+		From:   hexutil.Uint64(from),
+		To:     hexutil.Uint64(head),
+		Blocks: hexutil.Uint64(window),
+		Uncles: hexutil.Uint64(len(t.uncleHashes(from, head))),
+		Miners: make(map[common.Address]int),
+	}
+	var (
+		delays  int64
+		delayed int
+	)
+	sides := t.sideBlocks(from, head)
+	for _, side := range sides {
+		stats.Miners[side.Coinbase]++
+		if side.CanonSeen != 0 {
+			delays += int64(side.Seen) - int64(side.CanonSeen)
+			delayed++
+		}
+	}
+	stats.SideBlocks = hexutil.Uint64(len(sides))
+	stats.UncleRate = float64(stats.Uncles) / float64(window)
+	stats.SideRate = float64(stats.SideBlocks) / float64(window)
+	if delayed > 0 {
+		avg := float64(delays) / float64(delayed)
+		stats.AvgDelay = &avg
+	}
+	return stats
+}
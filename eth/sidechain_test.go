@@ -0,0 +1,136 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that side blocks are recorded with their propagation delay and that
+// uncle statistics account for their inclusion.
+func TestSideChainTracker(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		engine  = ethash.NewFaker()
+		genesis = (&core.Genesis{Config: params.TestChainConfig}).MustCommit(db)
+		miner   = common.HexToAddress("0xdeadbeef")
+	)
+	chain, _ := core.NewBlockChain(db, nil, params.TestChainConfig, engine, vm.Config{}, nil, nil)
+	defer chain.Stop()
+
+	tracker := newSideChainTracker(db, chain, 0)
+	tracker.start()
+	defer tracker.stop()
+
+	canon, _ := core.GenerateChain(params.TestChainConfig, genesis, engine, db, 2, nil)
+	if _, err := chain.InsertChain(canon); err != nil {
+		t.Fatalf("failed to insert canonical chain: %v", err)
+	}
+	// Wait for the canonical arrivals to be recorded before forking
+	for i := 0; i < 100 && tracker.arrivals.Len() < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	fork, _ := core.GenerateChain(params.TestChainConfig, canon[0], engine, db, 1, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(miner)
+	})
+	if _, err := chain.InsertChain(fork); err != nil {
+		t.Fatalf("failed to insert side block: %v", err)
+	}
+	next, _ := core.GenerateChain(params.TestChainConfig, canon[1], engine, db, 1, func(i int, b *core.BlockGen) {
+		b.AddUncle(fork[0].Header())
+	})
+	if _, err := chain.InsertChain(next); err != nil {
+		t.Fatalf("failed to insert uncle including block: %v", err)
+	}
+	var infos []*SideBlockInfo
+	for i := 0; i < 100 && len(infos) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+		infos = tracker.sideBlockInfos(0, 3)
+	}
+	if len(infos) != 1 {
+		t.Fatalf("side block count mismatch: have %d, want %d", len(infos), 1)
+	}
+	if infos[0].Hash != fork[0].Hash() || infos[0].Miner != miner {
+		t.Errorf("side block mismatch: have %x/%x, want %x/%x", infos[0].Hash, infos[0].Miner, fork[0].Hash(), miner)
+	}
+	if infos[0].Delay == nil || *infos[0].Delay < 0 {
+		t.Errorf("side block delay invalid: %v", infos[0].Delay)
+	}
+	if !infos[0].Uncle {
+		t.Errorf("side block not reported as uncle")
+	}
+	stats := tracker.uncleStats(3)
+	if stats.Uncles != 1 || stats.SideBlocks != 1 || stats.Miners[miner] != 1 {
+		t.Errorf("uncle stats mismatch: uncles %d, side blocks %d, miners %v", stats.Uncles, stats.SideBlocks, stats.Miners)
+	}
+}
+
+// Tests that side blocks beyond the retention limit are pruned as the canonical
+// chain progresses.
+func TestSideChainTrackerPruning(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		engine  = ethash.NewFaker()
+		genesis = (&core.Genesis{Config: params.TestChainConfig}).MustCommit(db)
+	)
+	chain, _ := core.NewBlockChain(db, nil, params.TestChainConfig, engine, vm.Config{}, nil, nil)
+	defer chain.Stop()
+
+	tracker := newSideChainTracker(db, chain, 2)
+	canon, _ := core.GenerateChain(params.TestChainConfig, genesis, engine, db, 4, nil)
+	for i := uint64(1); i <= 4; i++ {
+		rawdb.WriteSideBlock(db, &rawdb.SideBlock{Hash: common.Hash{byte(i)}, Number: i, Difficulty: common.Big1})
+	}
+	tracker.handleCanon(canon[3], 0)
+
+	if have := rawdb.ReadSideBlocks(db, 0, 4); len(have) != 2 || have[0].Number != 3 || have[1].Number != 4 {
+		t.Fatalf("retained side blocks mismatch: have %v", have)
+	}
+}
+
+// Tests that uncle statistics over windows spanning too many blocks, or the
+// whole chain, are rejected.
+func TestUncleStatsWindowLimit(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	(&core.Genesis{Config: params.TestChainConfig}).MustCommit(db)
+
+	chain, _ := core.NewBlockChain(db, nil, params.TestChainConfig, ethash.NewFaker(), vm.Config{}, nil, nil)
+	defer chain.Stop()
+
+	api := NewPublicDebugAPI(&Ethereum{blockchain: chain})
+	if _, err := api.UncleStats([]hexutil.Uint64{1}); err != errSideBlocksDisabled {
+		t.Fatalf("disabled tracker error mismatch: have %v, want %v", err, errSideBlocksDisabled)
+	}
+	api.eth.sideTracker = newSideChainTracker(db, chain, 0)
+	for _, window := range []hexutil.Uint64{0, maxSideChainRange + 1} {
+		if _, err := api.UncleStats([]hexutil.Uint64{1, window}); err == nil {
+			t.Errorf("window %d accepted", window)
+		}
+	}
+	if stats, err := api.UncleStats([]hexutil.Uint64{maxSideChainRange}); err != nil || len(stats) != 1 || stats[0].Blocks != 1 {
+		t.Errorf("clamped window mismatch: stats %v, err %v", stats, err)
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/holiman/uint256"
+)
+
+// ResultTracer is a vm.Tracer which assembles a JSON result from the traced
+// execution. It is implemented by both the JavaScript and the native tracers.
+type ResultTracer interface {
+	vm.Tracer
+
+	// GetResult returns the JSON result of the trace, or any error which
+	// occurred while tracing.
+	GetResult() (json.RawMessage, error)
+
+	// Stop terminates the tracing at the first opportunity, failing it with
+	// the given error.
+	Stop(err error)
+}
+
+// NativeConstructor creates a native Go tracer, configured by the optional
+// tracer specific JSON config.
+type NativeConstructor func(config json.RawMessage) (ResultTracer, error)
+
+// natives contains the constructors of all the native Go tracers by name.
+var natives = make(map[string]NativeConstructor)
+
+// RegisterNative makes a native Go tracer available by name. Native tracers
+// take precedence over the JavaScript tracers of the same name, which remain
+// reachable with a "Js" suffix (e.g. callTracerJs).
+func RegisterNative(name string, ctor NativeConstructor) {
+	natives[name] = ctor
+}
+
+// init registers the native tracers included in go-ethereum.
+func init() {
+	RegisterNative("callTracer", newCallTracer)
+	RegisterNative("prestateTracer", newPrestateTracer)
+	RegisterNative("4byteTracer", newFourByteTracer)
+	RegisterNative("vmTracer", newVMTracer)
+}
+
+// NewResultTracer instantiates the native tracer registered under the given
+// name, falling back to a JavaScript tracer if no such native tracer exists.
+// In that case code may be the name of a built in JavaScript tracer or the
+// source of a custom one. The config is only interpreted by native tracers.
+func NewResultTracer(code string, config json.RawMessage) (ResultTracer, error) {
+	if ctor, ok := natives[code]; ok {
+		return ctor(config)
+	}
+	if name := strings.TrimSuffix(code, "Js"); name != code {
+		if _, ok := natives[name]; ok {
+			code = name
+		}
+	}
+	return New(code)
+}
+
+// MuxTracer forwards all the tracing events to multiple tracers, running them
+// side by side on the same execution.
+type MuxTracer []vm.Tracer
+
+// CaptureStart implements the Tracer interface to initialize the tracing operation.
+func (t MuxTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	for _, tracer := range t {
+		if err := tracer.CaptureStart(from, to, create, input, gas, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CaptureState implements the Tracer interface to trace a single step of VM execution.
+func (t MuxTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, rdata []byte, contract *vm.Contract, depth int, err error) error {
+	for _, tracer := range t {
+		if err := tracer.CaptureState(env, pc, op, gas, cost, memory, stack, rStack, rdata, contract, depth, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CaptureFault implements the Tracer interface to trace an execution fault
+// while running an opcode.
+func (t MuxTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, contract *vm.Contract, depth int, err error) error {
+	for _, tracer := range t {
+		if err := tracer.CaptureFault(env, pc, op, gas, cost, memory, stack, rStack, contract, depth, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t MuxTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	for _, tracer := range t {
+		if err := tracer.CaptureEnd(output, gasUsed, d, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stackPeek returns the n-th item from the top of the stack, or zero if the
+// stack is not deep enough, just like the stack accessor of the JavaScript
+// tracers.
+func stackPeek(stack *vm.Stack, n int) *uint256.Int {
+	if len(stack.Data()) <= n || n < 0 {
+		return new(uint256.Int)
+	}
+	return stack.Back(n)
+}
+
+// memorySlice returns a copy of size bytes of memory starting at offset. If
+// the requested range is out of bounds, nil is returned, just like the memory
+// accessor of the JavaScript tracers.
+func memorySlice(memory *vm.Memory, offset, size *uint256.Int) []byte {
+	if size.IsZero() {
+		return []byte{}
+	}
+	if !offset.IsUint64() || !size.IsUint64() {
+		return nil
+	}
+	off, end := offset.Uint64(), offset.Uint64()+size.Uint64()
+	if end < off || end > uint64(memory.Len()) {
+		return nil
+	}
+	return memory.GetCopy(int64(off), int64(size.Uint64()))
+}
+
+// isPrecompiled checks whether addr is a precompiled contract. It matches the
+// JavaScript tracers in always using the Istanbul set of precompiles.
+func isPrecompiled(addr common.Address) bool {
+	_, ok := vm.PrecompiledContractsIstanbul[addr]
+	return ok
+}
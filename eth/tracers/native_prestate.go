@@ -0,0 +1,380 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// prestateAccount is the state of a single account prior to a transaction.
+type prestateAccount struct {
+	Balance *hexutil.Big                `json:"balance"`
+	Nonce   uint64                      `json:"nonce"`
+	Code    hexutil.Bytes               `json:"code"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+// exists reports whether the account existed prior to the transaction.
+func (a *prestateAccount) exists() bool {
+	return a.Nonce > 0 || len(a.Code) > 0 || a.Balance.ToInt().Sign() != 0
+}
+
+// diffAccount is the state of a single account in the diff mode output of the
+// prestate tracer, omitting the fields the transaction did not change.
+type diffAccount struct {
+	Balance *hexutil.Big                `json:"balance,omitempty"`
+	Nonce   uint64                      `json:"nonce,omitempty"`
+	Code    hexutil.Bytes               `json:"code,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// prestateDiff is the diff mode output of the prestate tracer.
+type prestateDiff struct {
+	Pre  map[common.Address]*diffAccount `json:"pre"`
+	Post map[common.Address]*diffAccount `json:"post"`
+}
+
+// prestateTracerConfig is the configuration of the prestate tracer.
+type prestateTracerConfig struct {
+	// DiffMode returns both the pre and post state of the accounts modified
+	// by the transaction, instead of the prestate of all accessed accounts.
+	DiffMode bool `json:"diffMode"`
+}
+
+// prestateTracer is the native version of the JavaScript prestateTracer, which
+// collects the accounts and storage slots a transaction accessed, along with
+// their values prior to the transaction.
+//
+// In diff mode, the tracer instead reports the modified accounts, with their
+// changed fields and storage slots both before and after the transaction. The
+// sender's prestate is reconstructed exactly, accounting for the gas purchase
+// preceding execution. Accounts created by the transaction are omitted from
+// the pre state, and those self-destructed from the post state.
+type prestateTracer struct {
+	config   prestateTracerConfig
+	prestate map[common.Address]*prestateAccount
+	db       vm.StateDB
+
+	created map[common.Address]bool // Accounts created by the transaction
+	deleted map[common.Address]bool // Accounts self-destructed by the transaction
+
+	create bool
+	from   common.Address
+	to     common.Address
+	gas    uint64
+	input  []byte
+	value  *big.Int
+	err    error // Error, if one occurred during tracing
+
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+// newPrestateTracer creates a native prestate tracer.
+func newPrestateTracer(config json.RawMessage) (ResultTracer, error) {
+	t := &prestateTracer{
+		prestate: make(map[common.Address]*prestateAccount),
+		created:  make(map[common.Address]bool),
+		deleted:  make(map[common.Address]bool),
+	}
+	if len(config) > 0 {
+		if err := json.Unmarshal(config, &t.config); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// lookupAccount injects the specified account into the prestate.
+func (t *prestateTracer) lookupAccount(addr common.Address) {
+	if _, ok := t.prestate[addr]; ok {
+		return
+	}
+	t.prestate[addr] = &prestateAccount{
+		Balance: (*hexutil.Big)(new(big.Int).Set(t.db.GetBalance(addr))),
+		Nonce:   t.db.GetNonce(addr),
+		Code:    common.CopyBytes(t.db.GetCode(addr)),
+		Storage: make(map[common.Hash]common.Hash),
+	}
+}
+
+// lookupStorage injects the specified storage entry of the given account into
+// the prestate.
+func (t *prestateTracer) lookupStorage(addr common.Address, key common.Hash) {
+	t.lookupAccount(addr)
+	if _, ok := t.prestate[addr].Storage[key]; ok {
+		return
+	}
+	t.prestate[addr].Storage[key] = t.db.GetState(addr, key)
+}
+
+// CaptureStart implements the Tracer interface to initialize the tracing operation.
+func (t *prestateTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.create, t.from, t.to, t.value = create, from, to, value
+	t.gas, t.input = gas, common.CopyBytes(input)
+	if t.value == nil {
+		t.value = new(big.Int)
+	}
+	return nil
+}
+
+// CaptureState implements the Tracer interface to trace a single step of VM execution.
+func (t *prestateTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, rdata []byte, contract *vm.Contract, depth int, err error) error {
+	if t.err != nil {
+		return nil
+	}
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		t.err = t.reason
+		return nil
+	}
+	// Add the current account if we just started tracing. Its balance will be
+	// wrong, including the value sent along with the message, which is fixed
+	// in GetResult.
+	if t.db == nil {
+		t.db = env.StateDB
+		t.lookupAccount(contract.Address())
+		if t.config.DiffMode {
+			if err := t.lookupOrigin(env); err != nil {
+				t.err = err
+				return nil
+			}
+		}
+	}
+	// Whenever new state is accessed, add it to the prestate
+	switch op {
+	case vm.EXTCODECOPY, vm.EXTCODESIZE, vm.BALANCE:
+		t.lookupAccount(common.Address(stackPeek(stack, 0).Bytes20()))
+	case vm.CREATE:
+		from := contract.Address()
+		addr := crypto.CreateAddress(from, t.db.GetNonce(from))
+		t.lookupAccount(addr)
+		t.created[addr] = true
+	case vm.CREATE2:
+		// stack: salt, size, offset, endowment
+		code := memorySlice(memory, stackPeek(stack, 1), stackPeek(stack, 2))
+		salt := common.Hash(stackPeek(stack, 3).Bytes32())
+		addr := crypto.CreateAddress2(contract.Address(), salt, crypto.Keccak256(code))
+		t.lookupAccount(addr)
+		t.created[addr] = true
+	case vm.SELFDESTRUCT:
+		// The JavaScript tracer doesn't track beneficiaries, keep the outputs equal
+		if t.config.DiffMode {
+			t.lookupAccount(common.Address(stackPeek(stack, 0).Bytes20()))
+			t.deleted[contract.Address()] = true
+		}
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		t.lookupAccount(common.Address(stackPeek(stack, 1).Bytes20()))
+	case vm.SSTORE, vm.SLOAD:
+		t.lookupStorage(contract.Address(), common.Hash(stackPeek(stack, 0).Bytes32()))
+	}
+	return nil
+}
+
+// lookupOrigin injects the sender, recipient and coinbase of the transaction
+// into the prestate, reverting the value transfer, nonce increment and gas
+// purchase which already happened prior to execution.
+func (t *prestateTracer) lookupOrigin(env *vm.EVM) error {
+	var (
+		rules     = env.ChainConfig()
+		number    = env.Context.BlockNumber
+		homestead = rules.IsHomestead(number)
+		istanbul  = rules.IsIstanbul(number)
+	)
+	// The access list of the transaction is not visible to the tracer, so its
+	// intrinsic cost is not refunded to the sender for EIP-2930 transactions.
+	intrinsic, err := core.IntrinsicGas(t.input, nil, t.create, homestead, istanbul)
+	if err != nil {
+		return err
+	}
+	t.lookupAccount(t.from)
+	t.lookupAccount(t.to)
+	t.lookupAccount(env.Context.Coinbase)
+
+	from, to := t.prestate[t.from], t.prestate[t.to]
+	if t.from != t.to {
+		from.Balance = (*hexutil.Big)(new(big.Int).Add(from.Balance.ToInt(), t.value))
+		to.Balance = (*hexutil.Big)(new(big.Int).Sub(to.Balance.ToInt(), t.value))
+	}
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(t.gas+intrinsic), env.TxContext.GasPrice)
+	from.Balance = (*hexutil.Big)(new(big.Int).Add(from.Balance.ToInt(), fee))
+	from.Nonce--
+
+	// Contract creation already bumped the nonce of the new account, which
+	// could have only been funded beforehand
+	if t.create {
+		to.Nonce = 0
+		t.created[t.to] = true
+	}
+	return nil
+}
+
+// CaptureFault implements the Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *prestateTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *prestateTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	return nil
+}
+
+// GetResult returns the assembled prestate of the traced transaction, or any
+// error which occurred during tracing.
+func (t *prestateTracer) GetResult() (json.RawMessage, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	if t.config.DiffMode {
+		return json.Marshal(t.diff())
+	}
+	// Plain value transfers execute no code and thus access no state
+	if t.db == nil {
+		return json.Marshal(t.prestate)
+	}
+	// At this point, we need to deduct the value from the outer transaction,
+	// and move it back to the origin
+	value := t.value
+	if value == nil {
+		value = new(big.Int)
+	}
+	t.lookupAccount(t.from)
+	t.lookupAccount(t.to)
+
+	from, to := t.prestate[t.from], t.prestate[t.to]
+	fromBal, toBal := from.Balance.ToInt(), to.Balance.ToInt()
+
+	to.Balance = (*hexutil.Big)(new(big.Int).Sub(toBal, value))
+	from.Balance = (*hexutil.Big)(new(big.Int).Add(fromBal, value))
+
+	// Decrement the caller's nonce, and remove empty create targets. Any state
+	// existing at the contract address would have caused the transaction to be
+	// rejected as invalid in the first place.
+	from.Nonce--
+	if t.create {
+		delete(t.prestate, t.to)
+	}
+	return json.Marshal(t.prestate)
+}
+
+// diff compares the prestate with the current state, assembling the changes
+// made to each account by the transaction.
+func (t *prestateTracer) diff() *prestateDiff {
+	res := &prestateDiff{
+		Pre:  make(map[common.Address]*diffAccount),
+		Post: make(map[common.Address]*diffAccount),
+	}
+	// Plain value transfers execute no code and thus access no state
+	if t.db == nil {
+		return res
+	}
+	for addr, acc := range t.prestate {
+		// Accounts which didn't exist before the transaction have no prestate
+		if t.created[addr] && !acc.exists() {
+			if t.deleted[addr] {
+				continue
+			}
+			post := t.postAccount(addr, nil)
+			for key := range acc.Storage {
+				if val := t.db.GetState(addr, key); val != (common.Hash{}) {
+					post.Storage[key] = val
+				}
+			}
+			// Failed creations leave nothing behind
+			if post.Balance != nil || post.Nonce != 0 || post.Code != nil || len(post.Storage) > 0 {
+				res.Post[addr] = post
+			}
+			continue
+		}
+		pre := &diffAccount{
+			Balance: acc.Balance,
+			Nonce:   acc.Nonce,
+			Code:    acc.Code,
+			Storage: make(map[common.Hash]common.Hash),
+		}
+		// Self-destructed accounts have no post state
+		if t.deleted[addr] {
+			for key, val := range acc.Storage {
+				if val != (common.Hash{}) {
+					pre.Storage[key] = val
+				}
+			}
+			res.Pre[addr] = pre
+			continue
+		}
+		post := t.postAccount(addr, acc)
+
+		modified := post.Balance != nil || post.Nonce != 0 || post.Code != nil
+		for key, val := range acc.Storage {
+			newVal := t.db.GetState(addr, key)
+			if newVal == val {
+				continue
+			}
+			modified = true
+			if val != (common.Hash{}) {
+				pre.Storage[key] = val
+			}
+			if newVal != (common.Hash{}) {
+				post.Storage[key] = newVal
+			}
+		}
+		if modified {
+			res.Pre[addr], res.Post[addr] = pre, post
+		}
+	}
+	return res
+}
+
+// postAccount returns the fields of an account which differ from its prestate,
+// or all of them if it didn't exist beforehand.
+func (t *prestateTracer) postAccount(addr common.Address, pre *prestateAccount) *diffAccount {
+	var (
+		post    = &diffAccount{Storage: make(map[common.Hash]common.Hash)}
+		balance = t.db.GetBalance(addr)
+		nonce   = t.db.GetNonce(addr)
+		code    = t.db.GetCode(addr)
+	)
+	if pre == nil {
+		pre = &prestateAccount{Balance: new(hexutil.Big), Storage: make(map[common.Hash]common.Hash)}
+	}
+	if balance.Cmp(pre.Balance.ToInt()) != 0 {
+		post.Balance = (*hexutil.Big)(new(big.Int).Set(balance))
+	}
+	if nonce != pre.Nonce {
+		post.Nonce = nonce
+	}
+	if !bytes.Equal(code, pre.Code) {
+		post.Code = common.CopyBytes(code)
+	}
+	return post
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *prestateTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
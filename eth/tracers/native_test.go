@@ -0,0 +1,231 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// runCallTracerTest executes the transaction of a call tracer test case with
+// the given tracer attached, returning the resulting state.
+func runCallTracerTest(t *testing.T, test *callTracerTest, tracer vm.Tracer) *state.StateDB {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(common.FromHex(test.Input), tx); err != nil {
+		t.Fatalf("failed to parse testcase input: %v", err)
+	}
+	signer := types.MakeSigner(test.Genesis.Config, new(big.Int).SetUint64(uint64(test.Context.Number)))
+	origin, _ := signer.Sender(tx)
+	txContext := vm.TxContext{
+		Origin:   origin,
+		GasPrice: tx.GasPrice(),
+	}
+	context := vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		Coinbase:    test.Context.Miner,
+		BlockNumber: new(big.Int).SetUint64(uint64(test.Context.Number)),
+		Time:        new(big.Int).SetUint64(uint64(test.Context.Time)),
+		Difficulty:  (*big.Int)(test.Context.Difficulty),
+		GasLimit:    uint64(test.Context.GasLimit),
+	}
+	_, statedb := tests.MakePreState(rawdb.NewMemoryDatabase(), test.Genesis.Alloc, false)
+	evm := vm.NewEVM(context, txContext, statedb, test.Genesis.Config, vm.Config{Debug: true, Tracer: tracer})
+
+	msg, err := tx.AsMessage(signer, nil)
+	if err != nil {
+		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+	}
+	st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
+	if _, err = st.TransitionDb(); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	return statedb
+}
+
+// Iterates over all the input-output datasets in the tracer test harness and
+// runs the native tracers against them, side by side with their JavaScript
+// counterparts, checking that the results are identical.
+func TestNativeTracers(t *testing.T) {
+	files, err := ioutil.ReadDir("testdata")
+	if err != nil {
+		t.Fatalf("failed to retrieve tracer test suite: %v", err)
+	}
+	for _, file := range files {
+		if !strings.HasPrefix(file.Name(), "call_tracer_") {
+			continue
+		}
+		file := file // capture range variable
+		t.Run(camel(strings.TrimSuffix(strings.TrimPrefix(file.Name(), "call_tracer_"), ".json")), func(t *testing.T) {
+			t.Parallel()
+
+			blob, err := ioutil.ReadFile(filepath.Join("testdata", file.Name()))
+			if err != nil {
+				t.Fatalf("failed to read testcase: %v", err)
+			}
+			test := new(callTracerTest)
+			if err := json.Unmarshal(blob, test); err != nil {
+				t.Fatalf("failed to parse testcase: %v", err)
+			}
+			for _, name := range []string{"callTracer", "prestateTracer", "4byteTracer"} {
+				native, err := NewResultTracer(name, nil)
+				if err != nil {
+					t.Fatalf("failed to create native %s: %v", name, err)
+				}
+				if _, ok := native.(*Tracer); ok {
+					t.Fatalf("%s: native tracer not registered", name)
+				}
+				js, err := NewResultTracer(name+"Js", nil)
+				if err != nil {
+					t.Fatalf("failed to create JavaScript %s: %v", name, err)
+				}
+				runCallTracerTest(t, test, MuxTracer{native, js})
+
+				have, err := native.GetResult()
+				if err != nil {
+					t.Fatalf("failed to retrieve native %s result: %v", name, err)
+				}
+				want, err := js.GetResult()
+				if err != nil {
+					t.Fatalf("failed to retrieve JavaScript %s result: %v", name, err)
+				}
+				// The call tracer orders its fields explicitly, so its output must be
+				// identical, while the other tracers return unordered objects.
+				if name == "callTracer" {
+					if !bytes.Equal(have, want) {
+						t.Errorf("%s: result mismatch:\nhave %s\nwant %s", name, have, want)
+					}
+					ret := new(callTrace)
+					if err := json.Unmarshal(have, ret); err != nil {
+						t.Fatalf("failed to unmarshal trace result: %v", err)
+					}
+					if !jsonEqual(ret, test.Result) {
+						t.Errorf("%s: trace mismatch:\nhave %+v\nwant %+v", name, ret, test.Result)
+					}
+					continue
+				}
+				var haveObj, wantObj interface{}
+				if err := json.Unmarshal(have, &haveObj); err != nil {
+					t.Fatalf("failed to unmarshal native %s result: %v", name, err)
+				}
+				if err := json.Unmarshal(want, &wantObj); err != nil {
+					t.Fatalf("failed to unmarshal JavaScript %s result: %v", name, err)
+				}
+				if !reflect.DeepEqual(haveObj, wantObj) {
+					t.Errorf("%s: result mismatch:\nhave %s\nwant %s", name, have, want)
+				}
+			}
+		})
+	}
+}
+
+// Tests that the diff mode of the prestate tracer reports the exact state of the
+// modified accounts both before and after the transaction.
+func TestPrestateTracerDiffMode(t *testing.T) {
+	files, err := ioutil.ReadDir("testdata")
+	if err != nil {
+		t.Fatalf("failed to retrieve tracer test suite: %v", err)
+	}
+	for _, file := range files {
+		if !strings.HasPrefix(file.Name(), "call_tracer_") {
+			continue
+		}
+		file := file // capture range variable
+		t.Run(camel(strings.TrimSuffix(strings.TrimPrefix(file.Name(), "call_tracer_"), ".json")), func(t *testing.T) {
+			t.Parallel()
+
+			blob, err := ioutil.ReadFile(filepath.Join("testdata", file.Name()))
+			if err != nil {
+				t.Fatalf("failed to read testcase: %v", err)
+			}
+			test := new(callTracerTest)
+			if err := json.Unmarshal(blob, test); err != nil {
+				t.Fatalf("failed to parse testcase: %v", err)
+			}
+			tracer, err := NewResultTracer("prestateTracer", json.RawMessage(`{"diffMode": true}`))
+			if err != nil {
+				t.Fatalf("failed to create tracer: %v", err)
+			}
+			statedb := runCallTracerTest(t, test, tracer)
+
+			res, err := tracer.GetResult()
+			if err != nil {
+				t.Fatalf("failed to retrieve trace result: %v", err)
+			}
+			diff := new(prestateDiff)
+			if err := json.Unmarshal(res, diff); err != nil {
+				t.Fatalf("failed to unmarshal trace result: %v", err)
+			}
+			if len(diff.Post) == 0 {
+				t.Fatalf("no modified accounts reported")
+			}
+			// The genesis allocation is the exact prestate of the transaction
+			for addr, pre := range diff.Pre {
+				alloc := test.Genesis.Alloc[addr]
+				if alloc.Balance == nil {
+					alloc.Balance = new(big.Int)
+				}
+				if pre.Balance.ToInt().Cmp(alloc.Balance) != 0 {
+					t.Errorf("%x: pre balance mismatch: have %v, want %v", addr, pre.Balance, alloc.Balance)
+				}
+				if pre.Nonce != alloc.Nonce {
+					t.Errorf("%x: pre nonce mismatch: have %d, want %d", addr, pre.Nonce, alloc.Nonce)
+				}
+				if !bytes.Equal(pre.Code, alloc.Code) {
+					t.Errorf("%x: pre code mismatch", addr)
+				}
+				for key, val := range pre.Storage {
+					if alloc.Storage[key] != val {
+						t.Errorf("%x: pre slot %x mismatch: have %x, want %x", addr, key, val, alloc.Storage[key])
+					}
+				}
+			}
+			// Only modified fields should be reported in the post state
+			for addr, post := range diff.Post {
+				if post.Balance != nil && post.Balance.ToInt().Cmp(statedb.GetBalance(addr)) != 0 {
+					t.Errorf("%x: post balance mismatch: have %v, want %v", addr, post.Balance, statedb.GetBalance(addr))
+				}
+				if post.Nonce != 0 && post.Nonce != statedb.GetNonce(addr) {
+					t.Errorf("%x: post nonce mismatch: have %d, want %d", addr, post.Nonce, statedb.GetNonce(addr))
+				}
+				if pre := diff.Pre[addr]; pre != nil && post.Nonce != 0 && post.Nonce == pre.Nonce {
+					t.Errorf("%x: unchanged nonce reported", addr)
+				}
+				for key, val := range post.Storage {
+					if have := statedb.GetState(addr, key); have != val {
+						t.Errorf("%x: post slot %x mismatch: have %x, want %x", addr, key, val, have)
+					}
+				}
+			}
+		})
+	}
+}
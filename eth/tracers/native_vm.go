@@ -0,0 +1,244 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/holiman/uint256"
+)
+
+// vmTrace is the trace of the instructions executed by a single call frame, in
+// the VM trace format of OpenEthereum.
+type vmTrace struct {
+	Code hexutil.Bytes `json:"code"`
+	Ops  []*vmOp       `json:"ops"`
+}
+
+// vmOp is a single executed instruction of a VM trace.
+type vmOp struct {
+	Cost uint64   `json:"cost"`
+	Ex   *vmExec  `json:"ex"`
+	PC   uint64   `json:"pc"`
+	Sub  *vmTrace `json:"sub"`
+
+	op      vm.OpCode
+	used    uint64   // Gas left after the instruction, if not known better
+	memOff  uint64   // Offset of the memory written by the instruction
+	memSize uint64   // Size of the memory written by the instruction
+	store   *vmStore // Storage slot written by the instruction
+}
+
+// vmExec contains the effects of an executed instruction. Failed instructions
+// have no effects.
+type vmExec struct {
+	Mem   *vmMem   `json:"mem"`
+	Push  []string `json:"push"`
+	Store *vmStore `json:"store"`
+	Used  uint64   `json:"used"`
+}
+
+// vmMem is a memory region written by an instruction.
+type vmMem struct {
+	Data hexutil.Bytes `json:"data"`
+	Off  uint64        `json:"off"`
+}
+
+// vmStore is a storage slot written by an instruction.
+type vmStore struct {
+	Key string `json:"key"`
+	Val string `json:"val"`
+}
+
+// vmFrame is a call frame being traced by the VM tracer.
+type vmFrame struct {
+	trace   *vmTrace
+	pending *vmOp // Last instruction, whose effects are only known on the next step
+}
+
+// vmTracer is a native tracer producing OpenEthereum style VM traces, listing
+// the instructions of every call frame along with the stack items pushed, the
+// memory and storage written and the gas left after each of them.
+type vmTracer struct {
+	root   *vmTrace
+	frames []*vmFrame
+	err    error // Error, if one occurred during tracing
+
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+// newVMTracer creates a native VM tracer.
+func newVMTracer(config json.RawMessage) (ResultTracer, error) {
+	return new(vmTracer), nil
+}
+
+// CaptureStart implements the Tracer interface to initialize the tracing operation.
+func (t *vmTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	return nil
+}
+
+// CaptureState implements the Tracer interface to trace a single step of VM execution.
+func (t *vmTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, rdata []byte, contract *vm.Contract, depth int, err error) error {
+	if t.err != nil {
+		return nil
+	}
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		t.err = t.reason
+		return nil
+	}
+	// Close any frames which returned and open a new one if we've just descended
+	for len(t.frames) > depth {
+		t.closeFrame()
+	}
+	if len(t.frames) < depth {
+		trace := &vmTrace{Code: common.CopyBytes(contract.Code), Ops: []*vmOp{}}
+		if len(t.frames) == 0 {
+			t.root = trace
+		} else if parent := t.frames[len(t.frames)-1].pending; parent != nil {
+			parent.Sub = trace
+		}
+		t.frames = append(t.frames, &vmFrame{trace: trace})
+	}
+	frame := t.frames[len(t.frames)-1]
+	if frame.pending != nil {
+		t.complete(frame.pending, gas, memory, stack)
+		frame.pending = nil
+	}
+	step := &vmOp{Cost: cost, PC: pc, op: op}
+	frame.trace.Ops = append(frame.trace.Ops, step)
+	if err != nil {
+		return nil
+	}
+	if gas > cost {
+		step.used = gas - cost
+	}
+	// Record the memory and storage the instruction is about to modify
+	var off, size *uint256.Int
+	switch op {
+	case vm.MSTORE:
+		off, size = stackPeek(stack, 0), uint256.NewInt().SetUint64(32)
+	case vm.MSTORE8:
+		off, size = stackPeek(stack, 0), uint256.NewInt().SetUint64(1)
+	case vm.CALLDATACOPY, vm.CODECOPY, vm.RETURNDATACOPY:
+		off, size = stackPeek(stack, 0), stackPeek(stack, 2)
+	case vm.EXTCODECOPY:
+		off, size = stackPeek(stack, 1), stackPeek(stack, 3)
+	case vm.CALL, vm.CALLCODE:
+		off, size = stackPeek(stack, 5), stackPeek(stack, 6)
+	case vm.DELEGATECALL, vm.STATICCALL:
+		off, size = stackPeek(stack, 4), stackPeek(stack, 5)
+	case vm.SSTORE:
+		step.store = &vmStore{
+			Key: hexutil.EncodeBig(stackPeek(stack, 0).ToBig()),
+			Val: hexutil.EncodeBig(stackPeek(stack, 1).ToBig()),
+		}
+	}
+	if off != nil && off.IsUint64() && size.IsUint64() && !size.IsZero() {
+		step.memOff, step.memSize = off.Uint64(), size.Uint64()
+	}
+	frame.pending = step
+	return nil
+}
+
+// complete fills in the effects of an instruction from the state of the VM
+// after its execution.
+func (t *vmTracer) complete(op *vmOp, gas uint64, memory *vm.Memory, stack *vm.Stack) {
+	ex := &vmExec{Push: []string{}, Store: op.store, Used: gas}
+	for i := pushCount(op.op) - 1; i >= 0; i-- {
+		ex.Push = append(ex.Push, hexutil.EncodeBig(stackPeek(stack, i).ToBig()))
+	}
+	if op.memSize > 0 {
+		off, size := uint256.NewInt().SetUint64(op.memOff), uint256.NewInt().SetUint64(op.memSize)
+		ex.Mem = &vmMem{Data: memorySlice(memory, off, size), Off: op.memOff}
+	}
+	op.Ex = ex
+}
+
+// closeFrame finishes tracing the innermost call frame. Its last instruction
+// ended the frame, so it has no observable effects beyond its gas usage.
+func (t *vmTracer) closeFrame() {
+	frame := t.frames[len(t.frames)-1]
+	if op := frame.pending; op != nil {
+		op.Ex = &vmExec{Push: []string{}, Store: op.store, Used: op.used}
+	}
+	t.frames = t.frames[:len(t.frames)-1]
+}
+
+// CaptureFault implements the Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *vmTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, contract *vm.Contract, depth int, err error) error {
+	// The failed instruction is the last one of its frame and has no effects
+	if t.err == nil && len(t.frames) > 0 {
+		t.frames[len(t.frames)-1].pending = nil
+	}
+	return nil
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *vmTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	for len(t.frames) > 0 {
+		t.closeFrame()
+	}
+	return nil
+}
+
+// GetResult returns the VM trace of the traced transaction, or any error which
+// occurred during tracing.
+func (t *vmTracer) GetResult() (json.RawMessage, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	if t.root == nil {
+		return json.Marshal(&vmTrace{Code: []byte{}, Ops: []*vmOp{}})
+	}
+	return json.Marshal(t.root)
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *vmTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
+
+// pushCount returns the number of stack items an instruction pushes. The DUP
+// and SWAP instructions are considered to push all the items they touch. Any
+// undefined instructions fail, so they never get here.
+func pushCount(op vm.OpCode) int {
+	switch {
+	case op.IsPush():
+		return 1
+	case op >= vm.DUP1 && op <= vm.DUP16:
+		return int(op-vm.DUP1) + 2
+	case op >= vm.SWAP1 && op <= vm.SWAP16:
+		return int(op-vm.SWAP1) + 2
+	}
+	switch op {
+	case vm.STOP, vm.POP, vm.MSTORE, vm.MSTORE8, vm.SSTORE, vm.JUMP, vm.JUMPI, vm.JUMPDEST,
+		vm.BEGINSUB, vm.JUMPSUB, vm.RETURNSUB, vm.LOG0, vm.LOG1, vm.LOG2, vm.LOG3, vm.LOG4,
+		vm.CALLDATACOPY, vm.CODECOPY, vm.EXTCODECOPY, vm.RETURNDATACOPY,
+		vm.RETURN, vm.REVERT, vm.SELFDESTRUCT:
+		return 0
+	}
+	return 1
+}
@@ -69,6 +69,14 @@ const schema string = `
         transaction: Transaction!
     }
 
+    # AccessTuple is an entry of the access list of an EIP-2930 transaction.
+    type AccessTuple {
+        # Address is the account pre-warmed by the transaction.
+        address: Address!
+        # StorageKeys is the list of pre-warmed storage slots of the account.
+        storageKeys: [Bytes32!]!
+    }
+
     # Transaction is an Ethereum transaction.
     type Transaction {
         # Hash is the hash of this transaction.
@@ -88,6 +96,15 @@ const schema string = `
         value: BigInt!
         # GasPrice is the price offered to miners for gas, in wei per unit.
         gasPrice: BigInt!
+        # MaxFeePerGas is the maximum fee per gas offered to include an EIP-1559
+        # transaction, null for other transaction types.
+        maxFeePerGas: BigInt
+        # MaxPriorityFeePerGas is the maximum miner tip per gas offered to include
+        # an EIP-1559 transaction, null for other transaction types.
+        maxPriorityFeePerGas: BigInt
+        # EffectiveGasPrice is the price actually paid per unit of gas. This will
+        # be null if the transaction has not yet been mined.
+        effectiveGasPrice: BigInt
         # Gas is the maximum amount of gas this transaction can consume.
         gas: Long!
         # InputData is the data supplied to the target of the transaction.
@@ -118,6 +135,11 @@ const schema string = `
         r: BigInt!
         s: BigInt!
         v: BigInt!
+        # Type is the EIP-2718 type of the transaction, 0 for legacy ones.
+        type: Int
+        # AccessList is the list of accounts and storage slots pre-warmed by an
+        # EIP-2930 transaction, null for other transaction types.
+        accessList: [AccessTuple!]
     }
 
     # BlockFilterCriteria encapsulates log filter criteria for a filter applied
@@ -167,6 +189,9 @@ const schema string = `
         gasLimit: Long!
         # GasUsed is the amount of gas that was used executing transactions in this block.
         gasUsed: Long!
+        # BaseFeePerGas is the EIP-1559 base fee per unit of gas of this block,
+        # null for blocks before the fork.
+        baseFeePerGas: BigInt
         # Timestamp is the unix timestamp at which this block was mined.
         timestamp: Long!
         # LogsBloom is a bloom filter that can be used to check if a block may
@@ -222,6 +247,10 @@ const schema string = `
         gas: Long
         # GasPrice is the price, in wei, offered for each unit of gas.
         gasPrice: BigInt
+        # MaxFeePerGas is the maximum fee, in wei, offered for each unit of gas.
+        maxFeePerGas: BigInt
+        # MaxPriorityFeePerGas is the maximum tip, in wei, offered for each unit of gas.
+        maxPriorityFeePerGas: BigInt
         # Value is the value, in wei, sent along with the call.
         value: BigInt
         # Data is the data sent to the callee.
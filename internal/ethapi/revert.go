@@ -0,0 +1,245 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// SelectorDB looks up the signatures of 4 byte selectors, as the 4byte
+// database of signer/fourbyte does.
+type SelectorDB interface {
+	Selector(id []byte) (string, error)
+}
+
+// selectorFile is a 4byte database loaded from a JSON file mapping hex encoded
+// selectors to signatures, the format of signer/fourbyte.
+type selectorFile map[string]string
+
+// LoadSelectorDB loads a 4byte database from a JSON file in the format of
+// signer/fourbyte.
+func LoadSelectorDB(path string) (SelectorDB, error) {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	db := make(selectorFile)
+	if err := json.Unmarshal(blob, &db); err != nil {
+		return nil, fmt.Errorf("invalid 4byte database %s: %v", path, err)
+	}
+	return db, nil
+}
+
+// Selector implements SelectorDB.
+func (db selectorFile) Selector(id []byte) (string, error) {
+	if len(id) < 4 {
+		return "", fmt.Errorf("expected 4-byte id, got %d", len(id))
+	}
+	sig := hex.EncodeToString(id[:4])
+	if selector, ok := db[sig]; ok {
+		return selector, nil
+	}
+	return "", fmt.Errorf("signature %v not found", sig)
+}
+
+// RevertArgument is a decoded argument of the error a call reverted with.
+type RevertArgument struct {
+	Name  string      `json:"name,omitempty"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// RevertData is the data a call reverted with, along with the error it decodes
+// to if known: Error(string) for failed requires and reverts with a reason,
+// Panic(uint256) for failed assertions and checks, or a custom error.
+type RevertData struct {
+	Data      hexutil.Bytes    `json:"data"`                // Raw revert data
+	Selector  hexutil.Bytes    `json:"selector,omitempty"`  // Selector of the error, if any
+	Name      string           `json:"name,omitempty"`      // Name of the error, empty if unknown
+	Signature string           `json:"signature,omitempty"` // Canonical signature of the error
+	Args      []RevertArgument `json:"args,omitempty"`      // Arguments of the error
+	Reason    string           `json:"reason,omitempty"`    // Human readable error, empty if unknown
+}
+
+// standardErrors are the errors solidity reverts with on its own.
+var standardErrors = []abi.Error{
+	mustParseError("Error(string)"),
+	mustParseError("Panic(uint256)"),
+}
+
+// DecodeRevert decodes the data a call reverted with. Besides the standard
+// errors, custom errors are decoded with the ABI of the called contract if
+// given, or with the signatures found in the 4byte database if any.
+func DecodeRevert(data []byte, contract *abi.ABI, db SelectorDB) *RevertData {
+	revert := &RevertData{Data: data}
+	if len(data) < 4 {
+		return revert
+	}
+	revert.Selector = data[:4]
+
+	var (
+		selector [4]byte
+		errABI   *abi.Error
+	)
+	copy(selector[:], data)
+	for i := range standardErrors {
+		if selector == errorSelector(&standardErrors[i]) {
+			errABI = &standardErrors[i]
+		}
+	}
+	if errABI == nil && contract != nil {
+		errABI, _ = contract.ErrorByID(selector)
+	}
+	if errABI == nil && db != nil {
+		if sig, err := db.Selector(selector[:]); err == nil {
+			if parsed, err := parseError(sig); err == nil && errorSelector(&parsed) == selector {
+				errABI = &parsed
+			}
+		}
+	}
+	if errABI == nil {
+		return revert
+	}
+	values, err := errABI.Unpack(data)
+	if err != nil {
+		return revert
+	}
+	revert.Name, revert.Signature = errABI.Name, errABI.Sig
+	formatted := make([]string, len(values))
+	for i, value := range values {
+		revert.Args = append(revert.Args, RevertArgument{
+			Name:  errABI.Inputs[i].Name,
+			Type:  errABI.Inputs[i].Type.String(),
+			Value: formatRevertValue(value),
+		})
+		formatted[i] = fmt.Sprint(revert.Args[i].Value)
+	}
+	if reason, err := abi.UnpackRevert(data); err == nil {
+		revert.Reason = reason
+	} else {
+		revert.Reason = fmt.Sprintf("%s(%s)", errABI.Name, strings.Join(formatted, ", "))
+	}
+	return revert
+}
+
+// errorSelector returns the selector of an error.
+func errorSelector(e *abi.Error) [4]byte {
+	var id [4]byte
+	copy(id[:], e.ID[:4])
+	return id
+}
+
+// parseError parses an error signature like InsufficientBalance(uint256,uint256),
+// the arguments being unnamed. Tuple arguments are not supported.
+func parseError(sig string) (abi.Error, error) {
+	open := strings.IndexByte(sig, '(')
+	if open <= 0 || !strings.HasSuffix(sig, ")") {
+		return abi.Error{}, fmt.Errorf("invalid error signature %q", sig)
+	}
+	var args abi.Arguments
+	if params := sig[open+1 : len(sig)-1]; params != "" {
+		for _, param := range strings.Split(params, ",") {
+			typ, err := abi.NewType(param, "", nil)
+			if err != nil {
+				return abi.Error{}, fmt.Errorf("invalid error signature %q: %v", sig, err)
+			}
+			args = append(args, abi.Argument{Type: typ})
+		}
+	}
+	return abi.NewError(sig[:open], args), nil
+}
+
+func mustParseError(sig string) abi.Error {
+	e, err := parseError(sig)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// formatRevertValue converts an unpacked argument into its JSON representation,
+// encoding numbers and bytes in hex like the rest of the API.
+func formatRevertValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case *big.Int:
+		return (*hexutil.Big)(value)
+	case []byte:
+		return hexutil.Bytes(value)
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Array, reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			blob := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(blob), v)
+			return hexutil.Bytes(blob)
+		}
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = formatRevertValue(v.Index(i).Interface())
+		}
+		return values
+	}
+	return value
+}
+
+// revertError is an API error that encompasses an EVM revert with JSON error
+// code and the decoded revert data.
+type revertError struct {
+	error
+	revert *RevertData
+}
+
+func newRevertError(data []byte, contract *abi.ABI, db SelectorDB) *revertError {
+	revert := DecodeRevert(data, contract, db)
+	err := errors.New("execution reverted")
+	if revert.Reason != "" {
+		err = fmt.Errorf("execution reverted: %v", revert.Reason)
+	}
+	return &revertError{
+		error:  err,
+		revert: revert,
+	}
+}
+
+// ErrorCode returns the JSON error code for a revertal.
+// See: https://github.com/ethereum/wiki/wiki/JSON-RPC-Error-Codes-Improvement-Proposal
+func (e *revertError) ErrorCode() int {
+	return 3
+}
+
+// ErrorData returns the hex encoded revert data, as clients expect it. The
+// decoded error is available from RevertData, or from the revert field of
+// eth_callMany results and call traces.
+func (e *revertError) ErrorData() interface{} {
+	return hexutil.Encode(e.revert.Data)
+}
+
+// RevertData returns the revert data along with the error it decodes to.
+func (e *revertError) RevertData() *RevertData {
+	return e.revert
+}
@@ -155,7 +155,12 @@ func New(stack *node.Node, config *eth.Config) (*LightEthereum, error) {
 		rawdb.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
 
-	leth.ApiBackend = &LesApiBackend{stack.Config().ExtRPCEnabled(), leth, nil}
+	leth.ApiBackend = &LesApiBackend{stack.Config().ExtRPCEnabled(), leth, nil, nil}
+	if config.RPCErrorSignatures != "" {
+		if leth.ApiBackend.selectors, err = ethapi.LoadSelectorDB(config.RPCErrorSignatures); err != nil {
+			return nil, err
+		}
+	}
 	gpoParams := config.GPO
 	if gpoParams.Default == nil {
 		gpoParams.Default = config.Miner.GasPrice
@@ -174,6 +174,30 @@ func (miner *Miner) SetExtra(extra []byte) error {
 	return nil
 }
 
+// SetGasFloor sets the gas target the miner moves the block gas limit towards.
+// The target must not exceed the gas ceiling.
+func (miner *Miner) SetGasFloor(floor uint64) error {
+	if floor < params.MinGasLimit {
+		return fmt.Errorf("gas target below minimum gas limit. %d < %d", floor, params.MinGasLimit)
+	}
+	return miner.worker.setGasFloor(floor)
+}
+
+// SetGasCeil sets the gas ceiling the miner never raises the block gas limit above.
+// The ceiling must not be below the gas target.
+func (miner *Miner) SetGasCeil(ceil uint64) error {
+	if ceil < params.MinGasLimit {
+		return fmt.Errorf("gas limit below minimum gas limit. %d < %d", ceil, params.MinGasLimit)
+	}
+	return miner.worker.setGasCeil(ceil)
+}
+
+// BuildStats returns the statistics of the last n blocks assembled by the
+// miner, newest first. If n is not positive, all retained entries are returned.
+func (miner *Miner) BuildStats(n int) []*BuildStats {
+	return miner.worker.buildStats.last(n)
+}
+
 // SetRecommitInterval sets the interval for sealing work resubmitting.
 func (miner *Miner) SetRecommitInterval(interval time.Duration) {
 	miner.worker.setRecommitInterval(interval)
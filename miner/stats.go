@@ -0,0 +1,106 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// buildStatsLimit is the maximum number of block building statistics retained
+// by the worker.
+const buildStatsLimit = 128
+
+// Reasons for which a transaction considered for inclusion may be skipped.
+const (
+	skipGasLimit    = "gaslimit"    // Not enough gas left in the block
+	skipNonceLow    = "noncelow"    // Nonce already used on the parent state
+	skipNonceHigh   = "noncehigh"   // Nonce gap on the parent state
+	skipUnprotected = "unprotected" // Replay protected transaction before EIP155
+	skipFailed      = "failed"      // Any other execution failure
+)
+
+var (
+	buildTimer        = metrics.NewRegisteredTimer("miner/build/time", nil)
+	buildTxsMeter     = metrics.NewRegisteredMeter("miner/build/txs", nil)
+	buildSkippedMeter = metrics.NewRegisteredMeter("miner/build/skipped", nil)
+	buildUnclesMeter  = metrics.NewRegisteredMeter("miner/build/uncles", nil)
+	buildGasGauge     = metrics.NewRegisteredGauge("miner/build/gas", nil)
+	buildFeesGauge    = metrics.NewRegisteredGauge("miner/build/fees", nil) // Fees earned in gwei
+)
+
+// BuildStats contains statistics about the assembly of a single block by the
+// local miner, meant to help operators tune their mining configuration.
+type BuildStats struct {
+	Number     hexutil.Uint64 `json:"number"`
+	ParentHash common.Hash    `json:"parentHash"`
+	SealHash   common.Hash    `json:"sealHash"`
+	Timestamp  hexutil.Uint64 `json:"timestamp"`
+	Elapsed    time.Duration  `json:"elapsed"`    // Time spent filling the block
+	Considered int            `json:"considered"` // Transactions attempted
+	Included   int            `json:"included"`   // Transactions included
+	Skipped    map[string]int `json:"skipped"`    // Transactions skipped, grouped by reason
+	GasLimit   hexutil.Uint64 `json:"gasLimit"`
+	GasUsed    hexutil.Uint64 `json:"gasUsed"`
+	Fees       *hexutil.Big   `json:"fees"` // Transaction fees earned in wei
+	Uncles     []common.Hash  `json:"uncles"`
+}
+
+// buildStatsSet is a bounded, thread safe history of block building statistics.
+type buildStatsSet struct {
+	stats []*BuildStats
+	lock  sync.RWMutex
+}
+
+// add inserts a new entry into the history, evicting the oldest one if the
+// limit is reached, and updates the relevant metrics.
+func (s *buildStatsSet) add(stats *BuildStats) {
+	buildTimer.Update(stats.Elapsed)
+	buildTxsMeter.Mark(int64(stats.Included))
+	buildSkippedMeter.Mark(int64(stats.Considered - stats.Included))
+	buildUnclesMeter.Mark(int64(len(stats.Uncles)))
+	buildGasGauge.Update(int64(stats.GasUsed))
+	buildFeesGauge.Update(new(big.Int).Div(stats.Fees.ToInt(), big.NewInt(1e9)).Int64())
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.stats = append(s.stats, stats)
+	if len(s.stats) > buildStatsLimit {
+		s.stats = s.stats[len(s.stats)-buildStatsLimit:]
+	}
+}
+
+// last returns the most recent n entries of the history, newest first.
+func (s *buildStatsSet) last(n int) []*BuildStats {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if n <= 0 || n > len(s.stats) {
+		n = len(s.stats)
+	}
+	res := make([]*BuildStats, 0, n)
+	for i := len(s.stats) - 1; i >= len(s.stats)-n; i-- {
+		res = append(res, s.stats[i])
+	}
+	return res
+}
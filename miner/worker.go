@@ -19,6 +19,7 @@ package miner
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"math/big"
 	"sync"
 	"sync/atomic"
@@ -26,6 +27,7 @@ import (
 
 	mapset "github.com/deckarep/golang-set"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/core"
@@ -91,6 +93,9 @@ type environment struct {
 	header   *types.Header
 	txs      []*types.Transaction
 	receipts []*types.Receipt
+
+	considered int            // Number of transactions attempted in cycle
+	skipped    map[string]int // Number of transactions skipped in cycle, by reason
 }
 
 // task contains all information for consensus engine sealing and result submitting.
@@ -155,9 +160,11 @@ type worker struct {
 	remoteUncles map[common.Hash]*types.Block // A set of side blocks as the possible uncle blocks.
 	unconfirmed  *unconfirmedBlocks           // A set of locally mined blocks pending canonicalness confirmations.
 
-	mu       sync.RWMutex // The lock used to protect the coinbase and extra fields
+	mu       sync.RWMutex // The lock used to protect the coinbase, extra and gas target fields
 	coinbase common.Address
 	extra    []byte
+	gasFloor uint64
+	gasCeil  uint64
 
 	pendingMu    sync.RWMutex
 	pendingTasks map[common.Hash]*task
@@ -166,6 +173,8 @@ type worker struct {
 	snapshotBlock *types.Block
 	snapshotState *state.StateDB
 
+	buildStats buildStatsSet // Statistics of the recently assembled mining blocks
+
 	// atomic status counters
 	running int32 // The indicator whether the consensus engine is running or not.
 	newTxs  int32 // New arrival transaction count since last sealing work submitting.
@@ -196,6 +205,8 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 		mux:                mux,
 		chain:              eth.BlockChain(),
 		isLocalBlock:       isLocalBlock,
+		gasFloor:           config.GasFloor,
+		gasCeil:            config.GasCeil,
 		localUncles:        make(map[common.Hash]*types.Block),
 		remoteUncles:       make(map[common.Hash]*types.Block),
 		unconfirmed:        newUnconfirmedBlocks(eth.BlockChain(), miningLogAtDepth),
@@ -250,6 +261,32 @@ func (w *worker) setExtra(extra []byte) {
 	w.extra = extra
 }
 
+// setGasFloor sets the gas target the block gas limit is moved towards. The
+// target must not exceed the gas ceiling.
+func (w *worker) setGasFloor(floor uint64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if floor > w.gasCeil {
+		return fmt.Errorf("gas target above gas limit. %d > %d", floor, w.gasCeil)
+	}
+	w.gasFloor = floor
+	return nil
+}
+
+// setGasCeil sets the gas ceiling the block gas limit must not exceed. The
+// ceiling must not be below the gas target.
+func (w *worker) setGasCeil(ceil uint64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if ceil < w.gasFloor {
+		return fmt.Errorf("gas limit below gas target. %d < %d", ceil, w.gasFloor)
+	}
+	w.gasCeil = ceil
+	return nil
+}
+
 // setRecommitInterval updates the interval for miner sealing work recommitting.
 func (w *worker) setRecommitInterval(interval time.Duration) {
 	w.resubmitIntervalCh <- interval
@@ -492,7 +529,7 @@ func (w *worker) mainLoop() {
 					acc, _ := types.Sender(w.current.signer, tx)
 					txs[acc] = append(txs[acc], tx)
 				}
-				txset := types.NewTransactionsByPriceAndNonce(w.current.signer, txs)
+				txset := types.NewTransactionsByPriceAndNonce(w.current.signer, txs, w.current.header.BaseFee)
 				tcount := w.current.tcount
 				w.commitTransactions(txset, coinbase, nil)
 				// Only update the snapshot if any new transactons were added
@@ -643,12 +680,13 @@ func (w *worker) makeCurrent(parent *types.Block, header *types.Header) error {
 		return err
 	}
 	env := &environment{
-		signer:    types.NewEIP155Signer(w.chainConfig.ChainID),
+		signer:    types.MakeSigner(w.chainConfig, header.Number),
 		state:     state,
 		ancestors: mapset.NewSet(),
 		family:    mapset.NewSet(),
 		uncles:    mapset.NewSet(),
 		header:    header,
+		skipped:   make(map[string]int),
 	}
 
 	// when 08 is processed ancestors contain 07 (quick block)
@@ -776,6 +814,8 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 		if tx == nil {
 			break
 		}
+		w.current.considered++
+
 		// Error may be ignored here. The error has already been checked
 		// during transaction acceptance is the transaction pool.
 		//
@@ -785,6 +825,7 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 		// phase, start ignoring the sender until we do.
 		if tx.Protected() && !w.chainConfig.IsEIP155(w.current.header.Number) {
 			log.Trace("Ignoring reply protected transaction", "hash", tx.Hash(), "eip155", w.chainConfig.EIP155Block)
+			w.current.skipped[skipUnprotected]++
 
 			txs.Pop()
 			continue
@@ -797,16 +838,19 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 		case errors.Is(err, core.ErrGasLimitReached):
 			// Pop the current out-of-gas transaction without shifting in the next from the account
 			log.Trace("Gas limit exceeded for current block", "sender", from)
+			w.current.skipped[skipGasLimit]++
 			txs.Pop()
 
 		case errors.Is(err, core.ErrNonceTooLow):
 			// New head notification data race between the transaction pool and miner, shift
 			log.Trace("Skipping transaction with low nonce", "sender", from, "nonce", tx.Nonce())
+			w.current.skipped[skipNonceLow]++
 			txs.Shift()
 
 		case errors.Is(err, core.ErrNonceTooHigh):
 			// Reorg notification data race between the transaction pool and miner, skip account =
 			log.Trace("Skipping account with hight nonce", "sender", from, "nonce", tx.Nonce())
+			w.current.skipped[skipNonceHigh]++
 			txs.Pop()
 
 		case errors.Is(err, nil):
@@ -819,6 +863,7 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 			// Strange error, discard the transaction and get the next in line (note, the
 			// nonce-too-high clause will prevent us from executing in vain).
 			log.Debug("Transaction failed, account skipped", "hash", tx.Hash(), "err", err)
+			w.current.skipped[skipFailed]++
 			txs.Shift()
 		}
 	}
@@ -857,8 +902,9 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 	if parent.Time() >= uint64(timestamp) {
 		timestamp = int64(parent.Time() + 1)
 	}
-	// this will ensure we're not going off too far in the future
-	if now := time.Now().Unix(); timestamp > now+1 {
+	// this will ensure we're not going off too far in the future, unless the
+	// chain is an instantly sealed development chain which may do so on purpose
+	if now := time.Now().Unix(); timestamp > now+1 && w.chainConfig.InstaSeal == nil {
 		wait := time.Duration(timestamp-now) * time.Second
 		log.Info("Mining too far in the future", "wait", common.PrettyDuration(wait))
 		time.Sleep(wait)
@@ -868,10 +914,18 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 	header := &types.Header{
 		ParentHash: parent.Hash(),
 		Number:     num.Add(num, common.Big1),
-		GasLimit:   core.CalcGasLimit(parent, w.config.GasFloor, w.config.GasCeil),
+		GasLimit:   core.CalcGasLimit(parent, w.gasFloor, w.gasCeil),
 		Extra:      w.extra,
 		Time:       uint64(timestamp),
 	}
+	// Set baseFee and GasLimit if we are on an EIP-1559 chain
+	if w.chainConfig.IsEIP1559(header.Number) {
+		header.BaseFee = misc.CalcBaseFee(w.chainConfig, parent.Header())
+		if !w.chainConfig.IsEIP1559(parent.Number()) {
+			// The gas limit doubles at the fork to keep the gas target unchanged
+			header.GasLimit = parent.GasLimit() * params.ElasticityMultiplier
+		}
+	}
 	// Only set the coinbase if our consensus engine is running (avoid spurious block rewards)
 	if w.isRunning() {
 		if w.coinbase == (common.Address{}) {
@@ -961,13 +1015,13 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 		}
 	}
 	if len(localTxs) > 0 {
-		txs := types.NewTransactionsByPriceAndNonce(w.current.signer, localTxs)
+		txs := types.NewTransactionsByPriceAndNonce(w.current.signer, localTxs, header.BaseFee)
 		if w.commitTransactions(txs, w.coinbase, interrupt) {
 			return
 		}
 	}
 	if len(remoteTxs) > 0 {
-		txs := types.NewTransactionsByPriceAndNonce(w.current.signer, remoteTxs)
+		txs := types.NewTransactionsByPriceAndNonce(w.current.signer, remoteTxs, header.BaseFee)
 		if w.commitTransactions(txs, w.coinbase, interrupt) {
 			return
 		}
@@ -997,6 +1051,11 @@ func (w *worker) commit(uncles []*types.Header, interval func(), update bool, st
 				"gas", block.GasUsed(), "fees", totalFees(block, receipts),
 				"elapsed", common.PrettyDuration(time.Since(start)))
 
+			// Only account fully assembled blocks in the build statistics
+			if update {
+				w.recordBuildStats(block, receipts, start)
+			}
+
 		case <-w.exitCh:
 			log.Info("Worker has exited")
 		}
@@ -1007,6 +1066,32 @@ func (w *worker) commit(uncles []*types.Header, interval func(), update bool, st
 	return nil
 }
 
+// recordBuildStats stores the statistics of assembling the given block.
+// Note this function assumes the current variable is thread safe.
+func (w *worker) recordBuildStats(block *types.Block, receipts []*types.Receipt, start time.Time) {
+	stats := &BuildStats{
+		Number:     hexutil.Uint64(block.NumberU64()),
+		ParentHash: block.ParentHash(),
+		SealHash:   w.engine.SealHash(block.Header()),
+		Timestamp:  hexutil.Uint64(block.Time()),
+		Elapsed:    time.Since(start),
+		Considered: w.current.considered,
+		Included:   w.current.tcount,
+		Skipped:    make(map[string]int, len(w.current.skipped)),
+		GasLimit:   hexutil.Uint64(block.GasLimit()),
+		GasUsed:    hexutil.Uint64(block.GasUsed()),
+		Fees:       (*hexutil.Big)(totalFeesWei(block, receipts)),
+		Uncles:     make([]common.Hash, 0, len(block.Uncles())),
+	}
+	for reason, count := range w.current.skipped {
+		stats.Skipped[reason] = count
+	}
+	for _, uncle := range block.Uncles() {
+		stats.Uncles = append(stats.Uncles, uncle.Hash())
+	}
+	w.buildStats.add(stats)
+}
+
 // copyReceipts makes a deep copy of the given receipts.
 func copyReceipts(receipts []*types.Receipt) []*types.Receipt {
 	result := make([]*types.Receipt, len(receipts))
@@ -1027,9 +1112,17 @@ func (w *worker) postSideBlock(event core.ChainSideEvent) {
 
 // totalFees computes total consumed fees in ETH. Block transactions and receipts have to have the same order.
 func totalFees(block *types.Block, receipts []*types.Receipt) *big.Float {
+	feesWei := totalFeesWei(block, receipts)
+	return new(big.Float).Quo(new(big.Float).SetInt(feesWei), new(big.Float).SetInt(big.NewInt(params.Ether)))
+}
+
+// totalFeesWei computes total fees paid to the miner in wei, excluding the burnt base fee.
+// Block transactions and receipts have to have the same order.
+func totalFeesWei(block *types.Block, receipts []*types.Receipt) *big.Int {
 	feesWei := new(big.Int)
 	for i, tx := range block.Transactions() {
-		feesWei.Add(feesWei, new(big.Int).Mul(new(big.Int).SetUint64(receipts[i].GasUsed), tx.GasPrice()))
+		tip := tx.EffectiveGasTipValue(block.BaseFee())
+		feesWei.Add(feesWei, new(big.Int).Mul(new(big.Int).SetUint64(receipts[i].GasUsed), tip))
 	}
-	return new(big.Float).Quo(new(big.Float).SetInt(feesWei), new(big.Float).SetInt(big.NewInt(params.Ether)))
+	return feesWei
 }
@@ -36,6 +36,7 @@ import (
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
 )
 
 const (
@@ -501,3 +502,85 @@ func testAdjustInterval(t *testing.T, chainConfig *params.ChainConfig, engine co
 		t.Error("interval reset timeout")
 	}
 }
+
+func TestBuildStats(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, b := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	// Inverted gas targets and ceilings must be rejected
+	if err := w.setGasFloor(2 * params.GenesisGasLimit); err == nil {
+		t.Fatal("gas target above gas ceiling accepted")
+	}
+	if err := w.setGasCeil(params.GenesisGasLimit - 1); err == nil {
+		t.Fatal("gas ceiling below gas target accepted")
+	}
+	// Raise the gas target at runtime and ensure new work picks it up
+	if err := w.setGasCeil(2 * params.GenesisGasLimit); err != nil {
+		t.Fatalf("failed to raise gas ceiling: %v", err)
+	}
+	if err := w.setGasFloor(2 * params.GenesisGasLimit); err != nil {
+		t.Fatalf("failed to raise gas target: %v", err)
+	}
+
+	taskCh := make(chan *task, 4)
+	w.newTaskHook = func(task *task) {
+		if task.block.NumberU64() == 1 && len(task.receipts) > 0 {
+			taskCh <- task
+		}
+	}
+	w.skipSealHook = func(task *task) bool { return true }
+	w.fullTaskHook = func() {
+		time.Sleep(100 * time.Millisecond)
+	}
+	w.start()
+
+	var full *task
+	select {
+	case full = <-taskCh:
+	case <-time.NewTimer(3 * time.Second).C:
+		t.Fatal("new task timeout")
+	}
+	if limit, genesis := full.block.GasLimit(), b.genesis.GasLimit; limit <= genesis {
+		t.Errorf("gas limit not raised towards target: have %d, parent %d", limit, genesis)
+	}
+	// Statistics are recorded right after the task is submitted, wait for them
+	var stats []*BuildStats
+	for i := 0; i < 100 && len(stats) == 0; i++ {
+		stats = w.buildStats.last(1)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(stats) != 1 {
+		t.Fatalf("build stats count mismatch: have %d, want %d", len(stats), 1)
+	}
+	if stats[0].Number != 1 {
+		t.Errorf("build stats number mismatch: have %d, want %d", stats[0].Number, 1)
+	}
+	if stats[0].Included != 1 || stats[0].Considered != 1 {
+		t.Errorf("build stats tx count mismatch: have %d/%d, want %d/%d", stats[0].Included, stats[0].Considered, 1, 1)
+	}
+	if uint64(stats[0].GasUsed) != params.TxGas {
+		t.Errorf("build stats gas mismatch: have %d, want %d", stats[0].GasUsed, params.TxGas)
+	}
+}
+
+// Tests that the block fees only include the tips paid to the miner, not the
+// base fee burnt by EIP-1559 transactions.
+func TestTotalFeesWei(t *testing.T) {
+	var (
+		to       = common.Address{0x01}
+		legacy   = types.NewTransaction(0, to, big.NewInt(0), params.TxGas, big.NewInt(150), nil)
+		dynamic  = types.NewTx(&types.DynamicFeeTx{To: &to, Gas: params.TxGas, GasFeeCap: big.NewInt(200), GasTipCap: big.NewInt(30)})
+		capped   = types.NewTx(&types.DynamicFeeTx{To: &to, Gas: params.TxGas, GasFeeCap: big.NewInt(110), GasTipCap: big.NewInt(30)})
+		receipts = []*types.Receipt{{GasUsed: params.TxGas}, {GasUsed: params.TxGas}, {GasUsed: params.TxGas}}
+		header   = &types.Header{Number: big.NewInt(1), BaseFee: big.NewInt(100)}
+		block    = types.NewBlock(header, []*types.Transaction{legacy, dynamic, capped}, nil, receipts, new(trie.Trie))
+	)
+	// Tips of 50, 30 and 10 wei per gas on top of the base fee of 100
+	want := new(big.Int).SetUint64((50 + 30 + 10) * params.TxGas)
+	if fees := totalFeesWei(block, receipts); fees.Cmp(want) != 0 {
+		t.Errorf("fees mismatch: have %v, want %v", fees, want)
+	}
+}
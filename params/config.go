@@ -273,8 +273,18 @@ var (
 		nil, // MCIP3Block
 		nil, // MCIP8Block
 
+		big.NewInt(0), // EIP2718Block
+		big.NewInt(0), // EIP2930Block
+		nil,           // EIP1559Block
+		nil,           // EIP1559FeeRecipient
+		nil,           // EIP3529Block
+		nil,           // EIP3541Block
+		nil,           // EIP3855Block
+		nil,           // Precompiles
+
 		new(EthashConfig), // Ethash
 		nil,               // Clique
+		nil,               // InstaSeal
 	}
 
 	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
@@ -315,11 +325,21 @@ var (
 		nil, // MCIP3Block
 		nil, // MCIP8Block
 
+		big.NewInt(0), // EIP2718Block
+		big.NewInt(0), // EIP2930Block
+		nil,           // EIP1559Block
+		nil,           // EIP1559FeeRecipient
+		nil,           // EIP3529Block
+		nil,           // EIP3541Block
+		nil,           // EIP3855Block
+		nil,           // Precompiles
+
 		nil, // Ethash
 		&CliqueConfig{
 			Period: 0,
 			Epoch:  30000,
 		},
+		nil, // InstaSeal
 	}
 
 	TestChainConfig = &ChainConfig{
@@ -355,8 +375,18 @@ var (
 		nil, // MCIP3Block
 		nil, // MCIP8Block
 
+		big.NewInt(0), // EIP2718Block
+		big.NewInt(0), // EIP2930Block
+		nil,           // EIP1559Block
+		nil,           // EIP1559FeeRecipient
+		nil,           // EIP3529Block
+		nil,           // EIP3541Block
+		nil,           // EIP3855Block
+		nil,           // Precompiles
+
 		new(EthashConfig), // Ethash
 		nil,               // Clique
+		nil,               // InstaSeal
 	}
 
 	TestRules       = TestChainConfig.Rules(new(big.Int))
@@ -452,9 +482,49 @@ type ChainConfig struct {
 	MCIP3Block *big.Int `json:"mcip3Block,omitempty"` // Musicoin 'UBI Fork' block
 	MCIP8Block *big.Int `json:"mcip8Block,omitempty"` // Musicoin 'QT For' block
 
+	// EIP2718Block enables the typed transaction envelope and EIP2930Block the
+	// optional access list transactions built on top of it (nil = no fork).
+	// https://github.com/ethereum/EIPs/blob/master/EIPS/eip-2718.md
+	// https://github.com/ethereum/EIPs/blob/master/EIPS/eip-2930.md
+	EIP2718Block *big.Int `json:"eip2718Block,omitempty"`
+	EIP2930Block *big.Int `json:"eip2930Block,omitempty"`
+
+	// EIP1559Block enables the EIP-1559 fee market: blocks carry a base fee
+	// and dynamic fee transactions become valid (nil = no fork). The base fee
+	// is burned unless EIP1559FeeRecipient names an account (e.g. a treasury)
+	// to be credited with it instead.
+	// https://github.com/ethereum/EIPs/blob/master/EIPS/eip-1559.md
+	EIP1559Block        *big.Int        `json:"eip1559Block,omitempty"`
+	EIP1559FeeRecipient *common.Address `json:"eip1559FeeRecipient,omitempty"`
+
+	// Individually schedulable EVM changes adopted after Berlin, e.g. by ETC's
+	// Mystique upgrade (nil = no fork). EIP-3529 builds on the EIP-2929 gas
+	// costs, so it requires yoloV2Block.
+	// https://github.com/ethereum/EIPs/blob/master/EIPS/eip-3529.md
+	// https://github.com/ethereum/EIPs/blob/master/EIPS/eip-3541.md
+	// https://github.com/ethereum/EIPs/blob/master/EIPS/eip-3855.md
+	EIP3529Block *big.Int `json:"eip3529Block,omitempty"` // Reduction in refunds
+	EIP3541Block *big.Int `json:"eip3541Block,omitempty"` // Reject new contract code starting with the 0xEF byte
+	EIP3855Block *big.Int `json:"eip3855Block,omitempty"` // PUSH0 instruction
+
+	// Precompiles schedules precompiled contracts independently of the forks,
+	// e.g. the BLS12-381 suite or repriced bn256 operations. An activation
+	// overrides the fork's default contract at the same address.
+	Precompiles []PrecompileActivation `json:"precompiles,omitempty"`
+
 	// Various consensus engines
-	Ethash *EthashConfig `json:"ethash,omitempty"`
-	Clique *CliqueConfig `json:"clique,omitempty"`
+	Ethash    *EthashConfig    `json:"ethash,omitempty"`
+	Clique    *CliqueConfig    `json:"clique,omitempty"`
+	InstaSeal *InstaSealConfig `json:"instaseal,omitempty"`
+}
+
+// PrecompileActivation schedules a precompiled contract at an address from the
+// given block onwards. Contract names one of the implementations known to the
+// EVM, e.g. "bls12381G1Add" or "bn256AddIstanbul".
+type PrecompileActivation struct {
+	Address  common.Address `json:"address"`
+	Contract string         `json:"contract"`
+	Block    *big.Int       `json:"block"`
 }
 
 // EthashConfig is the consensus engine configs for proof-of-work based sealing.
@@ -476,6 +546,15 @@ func (c *CliqueConfig) String() string {
 	return "clique"
 }
 
+// InstaSealConfig is the consensus engine configs for instantly sealed
+// development chains.
+type InstaSealConfig struct{}
+
+// String implements the stringer interface, returning the consensus engine details.
+func (c *InstaSealConfig) String() string {
+	return "instaseal"
+}
+
 // String implements the fmt.Stringer interface.
 func (c *ChainConfig) String() string {
 	var engine interface{}
@@ -484,6 +563,8 @@ func (c *ChainConfig) String() string {
 		engine = c.Ethash
 	case c.Clique != nil:
 		engine = c.Clique
+	case c.InstaSeal != nil:
+		engine = c.InstaSeal
 	default:
 		engine = "unknown"
 	}
@@ -610,6 +691,42 @@ func (c *ChainConfig) IsECIP1010(num *big.Int) bool {
 	return isForked(c.ECIP1010PauseBlock, num)
 }
 
+// IsEIP2718 returns whether num is either equal to the EIP2718 (typed transaction
+// envelope) fork block or greater.
+func (c *ChainConfig) IsEIP2718(num *big.Int) bool {
+	return isForked(c.EIP2718Block, num)
+}
+
+// IsEIP2930 returns whether num is either equal to the EIP2930 (access list
+// transactions) fork block or greater.
+func (c *ChainConfig) IsEIP2930(num *big.Int) bool {
+	return isForked(c.EIP2930Block, num)
+}
+
+// IsEIP1559 returns whether num is either equal to the EIP1559 (fee market)
+// fork block or greater.
+func (c *ChainConfig) IsEIP1559(num *big.Int) bool {
+	return isForked(c.EIP1559Block, num)
+}
+
+// IsEIP3529 returns whether num is either equal to the EIP3529 (refund
+// reduction) fork block or greater.
+func (c *ChainConfig) IsEIP3529(num *big.Int) bool {
+	return isForked(c.EIP3529Block, num)
+}
+
+// IsEIP3541 returns whether num is either equal to the EIP3541 (reject 0xEF
+// code) fork block or greater.
+func (c *ChainConfig) IsEIP3541(num *big.Int) bool {
+	return isForked(c.EIP3541Block, num)
+}
+
+// IsEIP3855 returns whether num is either equal to the EIP3855 (PUSH0) fork
+// block or greater.
+func (c *ChainConfig) IsEIP3855(num *big.Int) bool {
+	return isForked(c.EIP3855Block, num)
+}
+
 // CheckCompatible checks whether scheduled fork transitions have been imported
 // with a mismatching chain configuration.
 func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64) *ConfigCompatError {
@@ -668,6 +785,23 @@ func (c *ChainConfig) CheckConfigForkOrder() error {
 			lastFork = cur
 		}
 	}
+	// Access list transactions are carried in the typed transaction envelope
+	if c.EIP2930Block != nil && (c.EIP2718Block == nil || c.EIP2718Block.Cmp(c.EIP2930Block) > 0) {
+		return fmt.Errorf("unsupported fork ordering: eip2930Block enabled at %v, but eip2718Block enabled at %v", c.EIP2930Block, c.EIP2718Block)
+	}
+	// Dynamic fee transactions are carried in the typed transaction envelope
+	if c.EIP1559Block != nil && (c.EIP2718Block == nil || c.EIP2718Block.Cmp(c.EIP1559Block) > 0) {
+		return fmt.Errorf("unsupported fork ordering: eip1559Block enabled at %v, but eip2718Block enabled at %v", c.EIP1559Block, c.EIP2718Block)
+	}
+	// The refund reduction is defined on top of the EIP-2929 gas costs
+	if c.EIP3529Block != nil && (c.YoloV2Block == nil || c.YoloV2Block.Cmp(c.EIP3529Block) > 0) {
+		return fmt.Errorf("unsupported fork ordering: eip3529Block enabled at %v, but yoloV2Block enabled at %v", c.EIP3529Block, c.YoloV2Block)
+	}
+	for _, p := range c.Precompiles {
+		if p.Contract == "" || p.Block == nil {
+			return fmt.Errorf("invalid precompile activation at %x: contract and block required", p.Address)
+		}
+	}
 	return nil
 }
 
@@ -718,9 +852,64 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.EWASMBlock, newcfg.EWASMBlock, head) {
 		return newCompatError("ewasm fork block", c.EWASMBlock, newcfg.EWASMBlock)
 	}
+	if isForkIncompatible(c.EIP2718Block, newcfg.EIP2718Block, head) {
+		return newCompatError("EIP2718 fork block", c.EIP2718Block, newcfg.EIP2718Block)
+	}
+	if isForkIncompatible(c.EIP2930Block, newcfg.EIP2930Block, head) {
+		return newCompatError("EIP2930 fork block", c.EIP2930Block, newcfg.EIP2930Block)
+	}
+	if isForkIncompatible(c.EIP1559Block, newcfg.EIP1559Block, head) {
+		return newCompatError("EIP1559 fork block", c.EIP1559Block, newcfg.EIP1559Block)
+	}
+	if c.IsEIP1559(head) && !configAddressEqual(c.EIP1559FeeRecipient, newcfg.EIP1559FeeRecipient) {
+		return newCompatError("EIP1559 fee recipient", c.EIP1559Block, newcfg.EIP1559Block)
+	}
+	if isForkIncompatible(c.EIP3529Block, newcfg.EIP3529Block, head) {
+		return newCompatError("EIP3529 fork block", c.EIP3529Block, newcfg.EIP3529Block)
+	}
+	if isForkIncompatible(c.EIP3541Block, newcfg.EIP3541Block, head) {
+		return newCompatError("EIP3541 fork block", c.EIP3541Block, newcfg.EIP3541Block)
+	}
+	if isForkIncompatible(c.EIP3855Block, newcfg.EIP3855Block, head) {
+		return newCompatError("EIP3855 fork block", c.EIP3855Block, newcfg.EIP3855Block)
+	}
+	if err := checkPrecompilesCompatible(c.Precompiles, newcfg.Precompiles, head); err != nil {
+		return err
+	}
 	return nil
 }
 
+// checkPrecompilesCompatible checks whether the precompile activations can be
+// rescheduled without affecting blocks up to head, returning the conflict with
+// the lowest rewind block if there are several.
+func checkPrecompilesCompatible(stored, updated []PrecompileActivation, head *big.Int) *ConfigCompatError {
+	type activation struct {
+		address  common.Address
+		contract string
+	}
+	oldBlocks, newBlocks := make(map[activation]*big.Int), make(map[activation]*big.Int)
+	for _, p := range stored {
+		oldBlocks[activation{p.Address, p.Contract}] = p.Block
+	}
+	for _, p := range updated {
+		newBlocks[activation{p.Address, p.Contract}] = p.Block
+	}
+	var lowest *ConfigCompatError
+	for _, list := range [][]PrecompileActivation{stored, updated} {
+		for _, p := range list {
+			a := activation{p.Address, p.Contract}
+			if !isForkIncompatible(oldBlocks[a], newBlocks[a], head) {
+				continue
+			}
+			err := newCompatError(fmt.Sprintf("precompile %s at %x", a.contract, a.address), oldBlocks[a], newBlocks[a])
+			if lowest == nil || err.RewindTo < lowest.RewindTo {
+				lowest = err
+			}
+		}
+	}
+	return lowest
+}
+
 // isForkIncompatible returns true if a fork scheduled at s1 cannot be rescheduled to
 // block s2 because head is already past the fork.
 func isForkIncompatible(s1, s2, head *big.Int) bool {
@@ -745,6 +934,13 @@ func configNumEqual(x, y *big.Int) bool {
 	return x.Cmp(y) == 0
 }
 
+func configAddressEqual(x, y *common.Address) bool {
+	if x == nil || y == nil {
+		return x == y
+	}
+	return *x == *y
+}
+
 // ConfigCompatError is raised if the locally-stored blockchain is initialised with a
 // ChainConfig that would alter the past.
 type ConfigCompatError struct {
@@ -789,6 +985,12 @@ type Rules struct {
 	HasECIP1017, IsEIP160, IsEIP161, IsBombDisposal         bool
 	IsECIP1010                                              bool
 	IsMCIP0, IsMCIP3, IsMCIP8                               bool
+	IsEIP2718, IsEIP2930, IsEIP1559                         bool
+	IsEIP3529, IsEIP3541, IsEIP3855                         bool
+
+	// Precompiles maps the addresses of the precompiles scheduled by the chain
+	// configuration to their contract names, or is nil if there are none.
+	Precompiles map[common.Address]string
 }
 
 // Rules ensures c's ChainID is not nil.
@@ -815,5 +1017,35 @@ func (c *ChainConfig) Rules(num *big.Int) Rules {
 		IsMCIP0:          c.IsMCIP0(num),
 		IsMCIP3:          c.IsMCIP3(num),
 		IsMCIP8:          c.IsMCIP8(num),
+		IsEIP2718:        c.IsEIP2718(num),
+		IsEIP2930:        c.IsEIP2930(num),
+		IsEIP1559:        c.IsEIP1559(num),
+		IsEIP3529:        c.IsEIP3529(num),
+		IsEIP3541:        c.IsEIP3541(num),
+		IsEIP3855:        c.IsEIP3855(num),
+		Precompiles:      c.activePrecompiles(num),
+	}
+}
+
+// activePrecompiles returns the precompiles scheduled by the configuration at
+// the given block. If several activations share an address, the one with the
+// highest block wins, with ties resolved in favour of the later activation.
+func (c *ChainConfig) activePrecompiles(num *big.Int) map[common.Address]string {
+	var (
+		active map[common.Address]string
+		blocks map[common.Address]*big.Int
+	)
+	for _, p := range c.Precompiles {
+		if !isForked(p.Block, num) {
+			continue
+		}
+		if active == nil {
+			active, blocks = make(map[common.Address]string), make(map[common.Address]*big.Int)
+		}
+		if prev, ok := blocks[p.Address]; ok && prev.Cmp(p.Block) > 0 {
+			continue
+		}
+		active[p.Address], blocks[p.Address] = p.Contract, p.Block
 	}
+	return active
 }
@@ -20,6 +20,8 @@ import (
 	"math/big"
 	"reflect"
 	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
 )
 
 func TestCheckCompatible(t *testing.T) {
@@ -87,6 +89,45 @@ func TestCheckCompatible(t *testing.T) {
 				RewindTo:     30,
 			},
 		},
+		{
+			stored:  &ChainConfig{Precompiles: []PrecompileActivation{{Address: common.Address{10}, Contract: "bls12381G1Add", Block: big.NewInt(30)}}},
+			new:     &ChainConfig{Precompiles: []PrecompileActivation{{Address: common.Address{10}, Contract: "bls12381G1Add", Block: big.NewInt(50)}}},
+			head:    20,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{Precompiles: []PrecompileActivation{{Address: common.Address{10}, Contract: "bls12381G1Add", Block: big.NewInt(30)}}},
+			new:    &ChainConfig{Precompiles: []PrecompileActivation{{Address: common.Address{10}, Contract: "bls12381G1Add", Block: big.NewInt(50)}}},
+			head:   40,
+			wantErr: &ConfigCompatError{
+				What:         "precompile bls12381G1Add at 0a00000000000000000000000000000000000000",
+				StoredConfig: big.NewInt(30),
+				NewConfig:    big.NewInt(50),
+				RewindTo:     29,
+			},
+		},
+		{
+			stored: &ChainConfig{},
+			new:    &ChainConfig{Precompiles: []PrecompileActivation{{Address: common.Address{10}, Contract: "bls12381G1Add", Block: big.NewInt(30)}}},
+			head:   40,
+			wantErr: &ConfigCompatError{
+				What:         "precompile bls12381G1Add at 0a00000000000000000000000000000000000000",
+				StoredConfig: nil,
+				NewConfig:    big.NewInt(30),
+				RewindTo:     29,
+			},
+		},
+		{
+			stored: &ChainConfig{Precompiles: multiPrecompiles},
+			new:    &ChainConfig{Precompiles: []PrecompileActivation{{Address: common.Address{10}, Contract: "bls12381G1Add", Block: big.NewInt(45)}}},
+			head:   40,
+			wantErr: &ConfigCompatError{
+				What:         "precompile bls12381G1Mul at 0b00000000000000000000000000000000000000",
+				StoredConfig: big.NewInt(25),
+				NewConfig:    nil,
+				RewindTo:     24,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -96,3 +137,45 @@ func TestCheckCompatible(t *testing.T) {
 		}
 	}
 }
+
+// multiPrecompiles schedules two precompiles, the later listed one first.
+var multiPrecompiles = []PrecompileActivation{
+	{Address: common.Address{10}, Contract: "bls12381G1Add", Block: big.NewInt(35)},
+	{Address: common.Address{11}, Contract: "bls12381G1Mul", Block: big.NewInt(25)},
+}
+
+// Tests that the precompile compatibility check reports the conflict with the
+// lowest rewind block, irrespective of the order of the activations.
+func TestCheckPrecompilesCompatible(t *testing.T) {
+	updated := []PrecompileActivation{{Address: common.Address{10}, Contract: "bls12381G1Add", Block: big.NewInt(45)}}
+	for i := 0; i < 10; i++ {
+		err := checkPrecompilesCompatible(multiPrecompiles, updated, big.NewInt(40))
+		if err == nil || err.RewindTo != 24 {
+			t.Fatalf("run %d: conflict mismatch: have %v, want rewind to 24", i, err)
+		}
+	}
+}
+
+func TestRulesPrecompiles(t *testing.T) {
+	config := &ChainConfig{
+		Precompiles: []PrecompileActivation{
+			{Address: common.Address{6}, Contract: "bn256AddIstanbul", Block: big.NewInt(20)},
+			{Address: common.Address{6}, Contract: "bn256AddByzantium", Block: big.NewInt(10)},
+			{Address: common.Address{10}, Contract: "bls12381G1Add", Block: big.NewInt(10)},
+		},
+	}
+	tests := []struct {
+		head uint64
+		want map[common.Address]string
+	}{
+		{5, nil},
+		{10, map[common.Address]string{{6}: "bn256AddByzantium", {10}: "bls12381G1Add"}},
+		{25, map[common.Address]string{{6}: "bn256AddIstanbul", {10}: "bls12381G1Add"}},
+	}
+	for _, test := range tests {
+		have := config.Rules(new(big.Int).SetUint64(test.head)).Precompiles
+		if !reflect.DeepEqual(have, test.want) {
+			t.Errorf("head %d: precompiles mismatch: have %v, want %v", test.head, have, test.want)
+		}
+	}
+}
@@ -32,6 +32,14 @@ const (
 	// considered probably final and its rotated bits are calculated.
 	BloomConfirms = 256
 
+	// ChainStatsBlocks is the number of blocks aggregated into a single section
+	// of the network statistics index.
+	ChainStatsBlocks uint64 = 256
+
+	// ChainStatsConfirms is the number of confirmation blocks before a network
+	// statistics section is considered probably final and gets aggregated.
+	ChainStatsConfirms = 64
+
 	// CHTFrequency is the block frequency for creating CHTs
 	CHTFrequency = 32768
 
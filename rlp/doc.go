@@ -102,7 +102,8 @@ Signed integers, floating point numbers, maps, channels and functions cannot be
 
 Struct Tags
 
-Package rlp honours certain struct tags: "-", "tail", "nil", "nilList" and "nilString".
+Package rlp honours certain struct tags: "-", "tail", "nil", "nilList", "nilString" and
+"optional".
 
 The "-" tag ignores fields.
 
@@ -126,5 +127,11 @@ an empty RLP list.
 The choice of null value can be made explicit with the "nilList" and "nilString" struct
 tags. Using these tags encodes/decodes a Go nil pointer value as the kind of empty
 RLP value defined by the tag.
+
+The "optional" tag allows a field to be missing from the end of the input list, in which
+case it is decoded as its zero value. When encoding, trailing optional fields holding zero
+values are omitted. All fields following an optional field must be optional too. This
+tag is useful for extending consensus objects with new fields in a backwards compatible
+way.
 */
 package rlp
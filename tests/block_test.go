@@ -56,3 +56,21 @@ func TestBlockchain(t *testing.T) {
 	// prior to Istanbul. However, they are all derived from GeneralStateTests,
 	// which run natively, so there's no reason to run them here.
 }
+
+func TestETCBlockchain(t *testing.T) {
+	if *regenerateETC {
+		generateETCBlockTests(t)
+	}
+	checkETCBlockFixtures(t)
+	t.Parallel()
+
+	bt := new(testMatcher)
+	bt.walk(t, etcBlockTestDir, func(t *testing.T, name string, test *BlockTest) {
+		if err := bt.checkFailure(t, name+"/trie", test.Run(false)); err != nil {
+			t.Errorf("test without snapshotter failed: %v", err)
+		}
+		if err := bt.checkFailure(t, name+"/snap", test.Run(true)); err != nil {
+			t.Errorf("test with snapshotter failed: %v", err)
+		}
+	})
+}
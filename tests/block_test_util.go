@@ -49,6 +49,11 @@ func (t *BlockTest) UnmarshalJSON(in []byte) error {
 	return json.Unmarshal(in, &t.json)
 }
 
+// MarshalJSON implements json.Marshaler interface.
+func (t *BlockTest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&t.json)
+}
+
 type btJSON struct {
 	Blocks     []btBlock             `json:"blocks"`
 	Genesis    btHeader              `json:"genesisBlockHeader"`
@@ -60,30 +65,30 @@ type btJSON struct {
 }
 
 type btBlock struct {
-	BlockHeader  *btHeader
-	Rlp          string
-	UncleHeaders []*btHeader
+	BlockHeader  *btHeader   `json:"blockHeader"`
+	Rlp          string      `json:"rlp"`
+	UncleHeaders []*btHeader `json:"uncleHeaders"`
 }
 
 //go:generate gencodec -type btHeader -field-override btHeaderMarshaling -out gen_btheader.go
 
 type btHeader struct {
-	Bloom            types.Bloom
-	Coinbase         common.Address
-	MixHash          common.Hash
-	Nonce            types.BlockNonce
-	Number           *big.Int
-	Hash             common.Hash
-	ParentHash       common.Hash
-	ReceiptTrie      common.Hash
-	StateRoot        common.Hash
-	TransactionsTrie common.Hash
-	UncleHash        common.Hash
-	ExtraData        []byte
-	Difficulty       *big.Int
-	GasLimit         uint64
-	GasUsed          uint64
-	Timestamp        uint64
+	Bloom            types.Bloom      `json:"bloom"`
+	Coinbase         common.Address   `json:"coinbase"`
+	MixHash          common.Hash      `json:"mixHash"`
+	Nonce            types.BlockNonce `json:"nonce"`
+	Number           *big.Int         `json:"number"`
+	Hash             common.Hash      `json:"hash"`
+	ParentHash       common.Hash      `json:"parentHash"`
+	ReceiptTrie      common.Hash      `json:"receiptTrie"`
+	StateRoot        common.Hash      `json:"stateRoot"`
+	TransactionsTrie common.Hash      `json:"transactionsTrie"`
+	UncleHash        common.Hash      `json:"uncleHash"`
+	ExtraData        []byte           `json:"extraData"`
+	Difficulty       *big.Int         `json:"difficulty"`
+	GasLimit         uint64           `json:"gasLimit"`
+	GasUsed          uint64           `json:"gasUsed"`
+	Timestamp        uint64           `json:"timestamp"`
 }
 
 type btHeaderMarshaling struct {
@@ -96,9 +101,9 @@ type btHeaderMarshaling struct {
 }
 
 func (t *BlockTest) Run(snapshotter bool) error {
-	config, ok := Forks[t.json.Network]
-	if !ok {
-		return UnsupportedForkError{t.json.Network}
+	config, err := chainConfig(t.json.Network)
+	if err != nil {
+		return err
 	}
 
 	// import pre accounts & construct test genesis block & state root
@@ -170,6 +175,95 @@ func (t *BlockTest) genesis(config *params.ChainConfig) *core.Genesis {
 	}
 }
 
+// MakeBlockTest generates a block test on the named network or fork, with n
+// blocks on top of the genesis generated by gen as by core.GenerateChain. The
+// blocks carry no proof of work, and the accounts of the state after the last
+// block make up the expected post state.
+func MakeBlockTest(network string, genesis *core.Genesis, n int, gen func(int, *core.BlockGen)) (*BlockTest, error) {
+	config, err := chainConfig(network)
+	if err != nil {
+		return nil, err
+	}
+	spec := *genesis
+	spec.Config = config
+
+	db := rawdb.NewMemoryDatabase()
+	gblock, err := spec.Commit(db)
+	if err != nil {
+		return nil, err
+	}
+	blocks, _ := core.GenerateChain(config, gblock, ethash.NewFaker(), db, n, gen)
+
+	t := &BlockTest{json: btJSON{
+		Genesis:    *newBtHeader(gblock.Header()),
+		Pre:        genesis.Alloc,
+		Post:       make(core.GenesisAlloc),
+		BestBlock:  common.UnprefixedHash(gblock.Hash()),
+		Network:    network,
+		SealEngine: "NoProof",
+	}}
+	head := gblock
+	for _, block := range blocks {
+		enc, err := rlp.EncodeToBytes(block)
+		if err != nil {
+			return nil, err
+		}
+		b := btBlock{BlockHeader: newBtHeader(block.Header()), Rlp: hexutil.Encode(enc)}
+		for _, uncle := range block.Uncles() {
+			b.UncleHeaders = append(b.UncleHeaders, newBtHeader(uncle))
+		}
+		t.json.Blocks = append(t.json.Blocks, b)
+		head = block
+	}
+	t.json.BestBlock = common.UnprefixedHash(head.Hash())
+
+	statedb, err := state.New(head.Root(), state.NewDatabase(db), nil)
+	if err != nil {
+		return nil, err
+	}
+	for addr, account := range statedb.RawDump(false, false, true).Accounts {
+		balance, ok := new(big.Int).SetString(account.Balance, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid balance of %x: %s", addr, account.Balance)
+		}
+		var storage map[common.Hash]common.Hash
+		for key, value := range account.Storage {
+			if storage == nil {
+				storage = make(map[common.Hash]common.Hash)
+			}
+			storage[key] = common.HexToHash(value)
+		}
+		t.json.Post[addr] = core.GenesisAccount{
+			Code:    common.FromHex(account.Code),
+			Storage: storage,
+			Balance: balance,
+			Nonce:   account.Nonce,
+		}
+	}
+	return t, nil
+}
+
+func newBtHeader(h *types.Header) *btHeader {
+	return &btHeader{
+		Bloom:            h.Bloom,
+		Coinbase:         h.Coinbase,
+		MixHash:          h.MixDigest,
+		Nonce:            h.Nonce,
+		Number:           h.Number,
+		Hash:             h.Hash(),
+		ParentHash:       h.ParentHash,
+		ReceiptTrie:      h.ReceiptHash,
+		StateRoot:        h.Root,
+		TransactionsTrie: h.TxHash,
+		UncleHash:        h.UncleHash,
+		ExtraData:        h.Extra,
+		Difficulty:       h.Difficulty,
+		GasLimit:         h.GasLimit,
+		GasUsed:          h.GasUsed,
+		Timestamp:        h.Time,
+	}
+}
+
 /* See https://github.com/ethereum/tests/wiki/Blockchain-Tests-II
 
    Whether a block is valid or not is a bit subtle, it's defined by presence of
@@ -89,3 +89,22 @@ func TestDifficulty(t *testing.T) {
 		}
 	})
 }
+
+func TestETCDifficulty(t *testing.T) {
+	if *regenerateETC {
+		generateETCDifficultyTests(t)
+	}
+	checkETCDifficultyFixtures(t)
+	t.Parallel()
+
+	dt := new(testMatcher)
+	dt.walk(t, etcDifficultyTestDir, func(t *testing.T, name string, test *DifficultyTest) {
+		config, err := chainConfig(test.Chain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := dt.checkFailure(t, name, test.Run(config)); err != nil {
+			t.Error(err)
+		}
+	})
+}
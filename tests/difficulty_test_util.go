@@ -36,6 +36,7 @@ type DifficultyTest struct {
 	CurrentTimestamp   uint64      `json:"currentTimestamp"`
 	CurrentBlockNumber uint64      `json:"currentBlockNumber"`
 	CurrentDifficulty  *big.Int    `json:"currentDifficulty"`
+	Chain              string      `json:"chainConfig,omitempty"`
 }
 
 type difficultyTestMarshaling struct {
@@ -67,3 +68,21 @@ func (test *DifficultyTest) Run(config *params.ChainConfig) error {
 	return nil
 
 }
+
+// MakeDifficultyTest generates a difficulty test for the child of the given
+// parent header, with the given timestamp, on the named network or fork.
+func MakeDifficultyTest(chain string, parent *types.Header, time uint64) (*DifficultyTest, error) {
+	config, err := chainConfig(chain)
+	if err != nil {
+		return nil, err
+	}
+	return &DifficultyTest{
+		ParentTimestamp:    parent.Time,
+		ParentDifficulty:   parent.Difficulty,
+		UncleHash:          parent.UncleHash,
+		CurrentTimestamp:   time,
+		CurrentBlockNumber: parent.Number.Uint64() + 1,
+		CurrentDifficulty:  ethash.CalcDifficulty(config, time, parent),
+		Chain:              chain,
+	}, nil
+}
@@ -0,0 +1,370 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// The specifications below are the source of the fixtures in etcTestDir.
+// After changing them, or the rules they cover, regenerate the fixtures with
+//
+//	go test ./tests -run 'TestETC' -etc.regenerate
+//
+// and review the differences in the generated files. The fixtures are verified
+// against the hand computed etcDifficultyChecks and etcBlockChecks on every run.
+
+// etcDifficultyFixtures lists the block numbers of each chain around which
+// the difficulty calculation changes, per fixture file.
+var etcDifficultyFixtures = []struct {
+	file    string
+	chain   string
+	numbers []uint64
+}{
+	// ECIP-1010 bomb pause and continuation, ECIP-1041 bomb disposal and the
+	// uncle aware adjustment of Atlantis
+	{"difficultyClassic.json", "Classic", []uint64{1150000, 2999999, 3000000, 3000001, 4999999, 5000000, 5000001, 5899999, 5900000, 8772000}},
+	{"difficultyMordor.json", "Mordor", []uint64{1, 301243, 2000001}},
+	{"difficultyEllaism.json", "Ellaism", []uint64{1, 1999999, 2000000}},
+	{"difficultyMusicoin.json", "Musicoin", []uint64{1149999, 1150000, 2222222, 5200001}},
+}
+
+var (
+	// etcParentDifficulty is large enough for the bomb to remain noticeable
+	etcParentDifficulty = big.NewInt(100e12)
+	etcParentTime       = uint64(1500000000)
+	etcTimeDeltas       = []uint64{1, 9, 20, 1000}
+	etcParentUncles     = types.CalcUncleHash([]*types.Header{new(types.Header)})
+)
+
+// etcDifficultyChecks are difficulties worked out by hand from the ECIPs, which
+// the generated fixtures have to match. With the parent difficulty of 100e12
+// the adjustment step is 100e12/2048 = 48828125000, and the bomb adds
+// 2^(period-2) until its disposal.
+var etcDifficultyChecks = map[string]*big.Int{
+	// Homestead at maximum downward adjustment, bomb period 11
+	"Classic_1150000_1000s": big.NewInt(100e12 - 99*48828125000 + 1<<9),
+	// Last block before the ECIP-1010 pause, bomb period 29
+	"Classic_2999999_1s": big.NewInt(100e12 + 48828125000 + 1<<27),
+	// Bomb paused at period 30 between blocks 3000000 and 5000000
+	"Classic_3000000_1s": big.NewInt(100e12 + 48828125000 + 1<<28),
+	"Classic_4999999_1s": big.NewInt(100e12 + 48828125000 + 1<<28),
+	// Bomb continued at block 5000000, delayed by 2000000 blocks
+	"Classic_5000000_1s":  big.NewInt(100e12 + 48828125000 + 1<<28),
+	"Classic_5899999_20s": big.NewInt(100e12 - 48828125000 + 1<<36),
+	// Bomb disposed of by ECIP-1041
+	"Classic_5900000_1s": big.NewInt(100e12 + 48828125000),
+	// Uncle aware adjustment of Atlantis
+	"Classic_8772000_1s_uncles":  big.NewInt(100e12 + 2*48828125000),
+	"Classic_8772000_20s":        big.NewInt(100e12 - 48828125000),
+	"Classic_8772000_20s_uncles": big.NewInt(100e12),
+}
+
+// etcBlockFixtures lists the block tests of each fixture file.
+var etcBlockFixtures = []struct {
+	file  string
+	tests []etcBlockFixture
+}{
+	{"bcECIP1017Rewards.json", []etcBlockFixture{
+		{"eraBoundaries", "ECIP1017Era5", 16, eraBoundaryUncles},
+		{"uncleDistances", "ECIP1017Era5", 8, uncleDistances},
+	}},
+	{"bcEIP160.json", []etcBlockFixture{
+		{"expRepricing", "EIP150ToEIP160At5", 8, callExp},
+	}},
+	{"bcEIP161.json", []etcBlockFixture{
+		{"touchDisabled", "ByzantiumToEIP161DisableAt5", 8, touchEmpty},
+		{"touchReenabled", "EIP161DisableToReenableAt5", 8, touchEmpty},
+	}},
+	{"bcMCIPRewards.json", []etcBlockFixture{
+		{"ubiFork", "MCIP0ToMCIP3At5", 8, eraBoundaryUncles},
+		{"qtFork", "MCIP3ToMCIP8At5", 8, eraBoundaryUncles},
+	}},
+}
+
+// etcBlockChecks are post state balances worked out by hand from the specs of
+// the block fixtures, which the generated fixtures have to match. A nil balance
+// requires the account to be absent.
+//
+// With ECIP-1017 eras of 5 blocks the block reward is 5, 4, 3.2 and 2.56 ETC
+// in blocks 1-5, 6-10, 11-15 and 16. The miner earns 1/32 of it per included
+// uncle. Uncle miners earn (8-distance)/8 of it in the first era and 1/32 of
+// it afterwards.
+var etcBlockChecks = map[string][]etcAccountCheck{
+	"eraBoundaries_ECIP1017Era5": {
+		{etcMiner, etcMicroEther(25e6 + 156250 + 20e6 + 2*125000 + 16e6 + 2*100000 + 2560000 + 80000)},
+		{common.Address{0x20, 5}, etcMicroEther(4375000)},
+		{common.Address{0x20, 6}, etcMicroEther(125000)},
+		{common.Address{0x20, 11}, etcMicroEther(100000)},
+		{common.Address{0x20, 16}, etcMicroEther(80000)},
+	},
+	"uncleDistances_ECIP1017Era5": {
+		{etcMiner, etcMicroEther(25e6 + 6*156250 + 12e6 + 6*125000)},
+		{common.Address{0x30, 3}, etcMicroEther(4375000)},
+		{common.Address{0x30, 4}, etcMicroEther(3750000)},
+		{common.Address{0x30, 5}, etcMicroEther(3125000)},
+		{common.Address{0x30, 8}, etcMicroEther(125000)},
+	},
+	// EXP of a 32 byte exponent costs 10+10*32 gas before EIP-160 and 10+50*32
+	// after it, on top of 21008 gas for the transaction and other opcodes
+	"expRepricing_EIP150ToEIP160At5": {
+		{etcSender, big.NewInt(1e18 - 4*(21008+330) - 4*(21008+1610))},
+	},
+	// Accounts touched in blocks 1-4 are removed only while EIP-161 is active
+	"touchDisabled_ByzantiumToEIP161DisableAt5": {
+		{common.Address{0x40, 3}, nil},
+		{common.Address{0x40, 4}, new(big.Int)},
+	},
+	"touchReenabled_EIP161DisableToReenableAt5": {
+		{common.Address{0x40, 3}, new(big.Int)},
+		{common.Address{0x40, 4}, nil},
+	},
+}
+
+// etcAccountCheck is an expected post state balance of a block fixture.
+type etcAccountCheck struct {
+	account common.Address
+	balance *big.Int
+}
+
+// etcMicroEther converts an amount of millionths of an ether into wei.
+func etcMicroEther(amount int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(amount), big.NewInt(params.Ether/1e6))
+}
+
+// etcBlockFixture is a block test generated from the genesis etcGenesis.
+type etcBlockFixture struct {
+	name    string
+	network string
+	blocks  int
+	gen     func(config *params.ChainConfig) func(int, *core.BlockGen)
+}
+
+var (
+	etcKey, _    = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	etcSender    = crypto.PubkeyToAddress(etcKey.PublicKey)
+	etcMiner     = common.HexToAddress("0x1000000000000000000000000000000000000000")
+	etcExpCaller = common.HexToAddress("0x0000000000000000000000000000000000000e60")
+
+	// etcExpCode raises 2 to the power of a 32 byte exponent
+	etcExpCode = common.FromHex("0x7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff60020a5000")
+)
+
+func etcGenesis() *core.Genesis {
+	return &core.Genesis{
+		GasLimit:   8000000,
+		Difficulty: big.NewInt(131072),
+		Coinbase:   etcMiner,
+		Alloc: core.GenesisAlloc{
+			etcSender:    {Balance: big.NewInt(1e18)},
+			etcExpCaller: {Balance: new(big.Int), Code: etcExpCode},
+		},
+	}
+}
+
+// eraBoundaryUncles includes uncles in the blocks around every fifth block.
+func eraBoundaryUncles(config *params.ChainConfig) func(int, *core.BlockGen) {
+	return func(i int, b *core.BlockGen) {
+		if n := b.Number().Uint64(); n > 2 && (n%5 == 0 || n%5 == 1) {
+			addUncle(config, b, 1, common.Address{0x20, byte(n)})
+		}
+	}
+}
+
+// uncleDistances includes uncles of increasing generations, two at a time.
+func uncleDistances(config *params.ChainConfig) func(int, *core.BlockGen) {
+	return func(i int, b *core.BlockGen) {
+		if n := int(b.Number().Uint64()); n > 2 {
+			addUncle(config, b, n-2, common.Address{0x30, byte(n)})
+			addUncle(config, b, 1, common.Address{0x31, byte(n)})
+		}
+	}
+}
+
+// callExp calls the contract exponentiating, repriced by EIP-160, in each block.
+func callExp(config *params.ChainConfig) func(int, *core.BlockGen) {
+	return func(i int, b *core.BlockGen) {
+		addTx(config, b, etcExpCaller)
+	}
+}
+
+// touchEmpty sends a transaction without value to a new account in each block,
+// which EIP-161 removes again.
+func touchEmpty(config *params.ChainConfig) func(int, *core.BlockGen) {
+	return func(i int, b *core.BlockGen) {
+		addTx(config, b, common.Address{0x40, byte(i)})
+	}
+}
+
+// addUncle includes a block of the given generation mined by coinbase, beside
+// the ancestor of the generated block.
+func addUncle(config *params.ChainConfig, b *core.BlockGen, generation int, coinbase common.Address) {
+	parent := b.PrevBlock(int(b.Number().Int64()) - generation - 2)
+	uncle := &types.Header{
+		ParentHash:  parent.Hash(),
+		UncleHash:   types.EmptyUncleHash,
+		Coinbase:    coinbase,
+		Root:        parent.Root(),
+		TxHash:      types.EmptyRootHash,
+		ReceiptHash: types.EmptyRootHash,
+		Number:      new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:    core.CalcGasLimit(parent, parent.GasLimit(), parent.GasLimit()),
+		Time:        parent.Time() + 5,
+	}
+	uncle.Difficulty = ethash.CalcDifficulty(config, uncle.Time, parent.Header())
+	b.AddUncle(uncle)
+}
+
+// addTx includes a transaction without value from etcSender to the recipient,
+// mined by etcMiner.
+func addTx(config *params.ChainConfig, b *core.BlockGen, to common.Address) {
+	b.SetCoinbase(etcMiner)
+	tx := types.NewTransaction(b.TxNonce(etcSender), to, new(big.Int), 100000, big.NewInt(1), nil)
+	tx, err := types.SignTx(tx, types.MakeSigner(config, b.Number()), etcKey)
+	if err != nil {
+		panic(err)
+	}
+	b.AddTx(tx)
+}
+
+// generateETCDifficultyTests writes the difficulty fixtures.
+func generateETCDifficultyTests(t *testing.T) {
+	for _, fixture := range etcDifficultyFixtures {
+		tests := make(map[string]*DifficultyTest)
+		for _, number := range fixture.numbers {
+			for _, uncles := range []common.Hash{types.EmptyUncleHash, etcParentUncles} {
+				for _, delta := range etcTimeDeltas {
+					parent := &types.Header{
+						Number:     new(big.Int).SetUint64(number - 1),
+						Time:       etcParentTime,
+						Difficulty: etcParentDifficulty,
+						UncleHash:  uncles,
+					}
+					test, err := MakeDifficultyTest(fixture.chain, parent, etcParentTime+delta)
+					if err != nil {
+						t.Fatalf("%s: %v", fixture.file, err)
+					}
+					name := fmt.Sprintf("%s_%d_%ds", fixture.chain, number, delta)
+					if uncles != types.EmptyUncleHash {
+						name += "_uncles"
+					}
+					tests[name] = test
+				}
+			}
+		}
+		writeETCFixture(t, etcDifficultyTestDir, fixture.file, tests)
+	}
+}
+
+// generateETCBlockTests writes the block fixtures.
+func generateETCBlockTests(t *testing.T) {
+	for _, fixture := range etcBlockFixtures {
+		tests := make(map[string]*BlockTest)
+		for _, spec := range fixture.tests {
+			test, err := MakeBlockTest(spec.network, etcGenesis(), spec.blocks, spec.gen(Forks[spec.network]))
+			if err != nil {
+				t.Fatalf("%s/%s: %v", fixture.file, spec.name, err)
+			}
+			tests[spec.name+"_"+spec.network] = test
+		}
+		writeETCFixture(t, etcBlockTestDir, fixture.file, tests)
+	}
+}
+
+// checkETCDifficultyFixtures verifies the difficulty fixtures on disk against
+// the hand computed etcDifficultyChecks.
+func checkETCDifficultyFixtures(t *testing.T) {
+	checked := 0
+	for _, fixture := range etcDifficultyFixtures {
+		var tests map[string]*DifficultyTest
+		if err := readJSONFile(filepath.Join(etcDifficultyTestDir, fixture.file), &tests); err != nil {
+			t.Fatalf("%s: %v", fixture.file, err)
+		}
+		for name, test := range tests {
+			want, ok := etcDifficultyChecks[name]
+			if !ok {
+				continue
+			}
+			if test.CurrentDifficulty.Cmp(want) != 0 {
+				t.Errorf("%s/%s: difficulty mismatch: have %v, want %v", fixture.file, name, test.CurrentDifficulty, want)
+			}
+			checked++
+		}
+	}
+	if checked != len(etcDifficultyChecks) {
+		t.Errorf("difficulty checks of unknown tests: %d of %d checked", checked, len(etcDifficultyChecks))
+	}
+}
+
+// checkETCBlockFixtures verifies the post states of the block fixtures on disk
+// against the hand computed etcBlockChecks.
+func checkETCBlockFixtures(t *testing.T) {
+	checked := 0
+	for _, fixture := range etcBlockFixtures {
+		var tests map[string]*BlockTest
+		if err := readJSONFile(filepath.Join(etcBlockTestDir, fixture.file), &tests); err != nil {
+			t.Fatalf("%s: %v", fixture.file, err)
+		}
+		for name, test := range tests {
+			checks, ok := etcBlockChecks[name]
+			if !ok {
+				continue
+			}
+			for _, check := range checks {
+				account, ok := test.json.Post[check.account]
+				switch {
+				case check.balance == nil && ok:
+					t.Errorf("%s/%s: account %x not removed", fixture.file, name, check.account)
+				case check.balance != nil && !ok:
+					t.Errorf("%s/%s: account %x missing", fixture.file, name, check.account)
+				case check.balance != nil && account.Balance.Cmp(check.balance) != 0:
+					t.Errorf("%s/%s: account %x balance mismatch: have %v, want %v", fixture.file, name, check.account, account.Balance, check.balance)
+				}
+			}
+			checked++
+		}
+	}
+	if checked != len(etcBlockChecks) {
+		t.Errorf("balance checks of unknown tests: %d of %d checked", checked, len(etcBlockChecks))
+	}
+}
+
+func writeETCFixture(t *testing.T, dir, file string, tests interface{}) {
+	data, err := json.MarshalIndent(tests, "", "    ")
+	if err != nil {
+		t.Fatalf("%s: %v", file, err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, file), append(data, '\n'), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -21,6 +21,7 @@ func (d DifficultyTest) MarshalJSON() ([]byte, error) {
 		CurrentTimestamp   math.HexOrDecimal64   `json:"currentTimestamp"`
 		CurrentBlockNumber math.HexOrDecimal64   `json:"currentBlockNumber"`
 		CurrentDifficulty  *math.HexOrDecimal256 `json:"currentDifficulty"`
+		Chain              string                `json:"chainConfig,omitempty"`
 	}
 	var enc DifficultyTest
 	enc.ParentTimestamp = math.HexOrDecimal64(d.ParentTimestamp)
@@ -29,6 +30,7 @@ func (d DifficultyTest) MarshalJSON() ([]byte, error) {
 	enc.CurrentTimestamp = math.HexOrDecimal64(d.CurrentTimestamp)
 	enc.CurrentBlockNumber = math.HexOrDecimal64(d.CurrentBlockNumber)
 	enc.CurrentDifficulty = (*math.HexOrDecimal256)(d.CurrentDifficulty)
+	enc.Chain = d.Chain
 	return json.Marshal(&enc)
 }
 
@@ -41,6 +43,7 @@ func (d *DifficultyTest) UnmarshalJSON(input []byte) error {
 		CurrentTimestamp   *math.HexOrDecimal64  `json:"currentTimestamp"`
 		CurrentBlockNumber *math.HexOrDecimal64  `json:"currentBlockNumber"`
 		CurrentDifficulty  *math.HexOrDecimal256 `json:"currentDifficulty"`
+		Chain              *string               `json:"chainConfig,omitempty"`
 	}
 	var dec DifficultyTest
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -64,5 +67,8 @@ func (d *DifficultyTest) UnmarshalJSON(input []byte) error {
 	if dec.CurrentDifficulty != nil {
 		d.CurrentDifficulty = (*big.Int)(dec.CurrentDifficulty)
 	}
+	if dec.Chain != nil {
+		d.Chain = *dec.Chain
+	}
 	return nil
 }
@@ -167,6 +167,95 @@ var Forks = map[string]*params.ChainConfig{
 		IstanbulBlock:       big.NewInt(0),
 		YoloV2Block:         big.NewInt(0),
 	},
+	// ETC's Mystique upgrade: Berlin plus the EIP-3529 refund reduction and
+	// EIP-3541 contract code validation
+	"Mystique": {
+		ChainID:             big.NewInt(1),
+		HomesteadBlock:      big.NewInt(0),
+		EIP150Block:         big.NewInt(0),
+		EIP155Block:         big.NewInt(0),
+		EIP158Block:         big.NewInt(0),
+		ByzantiumBlock:      big.NewInt(0),
+		ConstantinopleBlock: big.NewInt(0),
+		PetersburgBlock:     big.NewInt(0),
+		IstanbulBlock:       big.NewInt(0),
+		YoloV2Block:         big.NewInt(0),
+		EIP3529Block:        big.NewInt(0),
+		EIP3541Block:        big.NewInt(0),
+	},
+	// ETC's ECIP-1017 monetary policy, with eras shortened to 5 blocks
+	"ECIP1017Era5": {
+		ChainID:          big.NewInt(61),
+		HomesteadBlock:   big.NewInt(0),
+		EIP150Block:      big.NewInt(0),
+		EIP155Block:      big.NewInt(0),
+		EIP160Block:      big.NewInt(0),
+		DisposalBlock:    big.NewInt(0),
+		ECIP1017EraBlock: big.NewInt(5),
+	},
+	// ETC's EIP-160 EXP repricing, without the rest of EIP-158
+	"EIP150ToEIP160At5": {
+		ChainID:        big.NewInt(61),
+		HomesteadBlock: big.NewInt(0),
+		EIP150Block:    big.NewInt(0),
+		EIP155Block:    big.NewInt(0),
+		EIP160Block:    big.NewInt(5),
+	},
+	// Byzantium without EIP-161 state clearing, as on Ellaism
+	"ByzantiumToEIP161DisableAt5": {
+		ChainID:            big.NewInt(61),
+		HomesteadBlock:     big.NewInt(0),
+		EIP150Block:        big.NewInt(0),
+		EIP155Block:        big.NewInt(0),
+		EIP160Block:        big.NewInt(0),
+		ByzantiumBlock:     big.NewInt(0),
+		EIP161DisableBlock: big.NewInt(5),
+	},
+	"EIP161DisableToReenableAt5": {
+		ChainID:             big.NewInt(61),
+		HomesteadBlock:      big.NewInt(0),
+		EIP150Block:         big.NewInt(0),
+		EIP155Block:         big.NewInt(0),
+		EIP160Block:         big.NewInt(0),
+		ByzantiumBlock:      big.NewInt(0),
+		EIP161DisableBlock:  big.NewInt(0),
+		EIP161ReenableBlock: big.NewInt(5),
+	},
+	// Musicoin's reward schedule, with its MCIP-3 UBI and MCIP-8 forks
+	"MCIP0ToMCIP3At5": {
+		ChainID:        big.NewInt(7762959),
+		HomesteadBlock: big.NewInt(0),
+		MCIP0Block:     big.NewInt(0),
+		MCIP3Block:     big.NewInt(5),
+	},
+	"MCIP3ToMCIP8At5": {
+		ChainID:        big.NewInt(7762959),
+		HomesteadBlock: big.NewInt(0),
+		MCIP0Block:     big.NewInt(0),
+		MCIP3Block:     big.NewInt(0),
+		MCIP8Block:     big.NewInt(5),
+	},
+}
+
+// ChainConfigs table defines the chain configurations of the networks tests
+// can reference by name, for rules not captured by a fork.
+var ChainConfigs = map[string]*params.ChainConfig{
+	"Mainnet":  params.MainnetChainConfig,
+	"Classic":  params.ClassicChainConfig,
+	"Mordor":   params.MordorChainConfig,
+	"Ellaism":  params.EllaismChainConfig,
+	"Musicoin": params.MusicoinChainConfig,
+}
+
+// chainConfig returns the chain configuration of the named network or fork.
+func chainConfig(name string) (*params.ChainConfig, error) {
+	if config, ok := ChainConfigs[name]; ok {
+		return config, nil
+	}
+	if config, ok := Forks[name]; ok {
+		return config, nil
+	}
+	return nil, UnsupportedForkError{name}
 }
 
 // Returns the set of defined fork names
@@ -38,6 +38,8 @@ import (
 var (
 	testEVM   = flag.String("vm.evm", "", "EVM configuration")
 	testEWASM = flag.String("vm.ewasm", "", "EWASM configuration")
+
+	regenerateETC = flag.Bool("etc.regenerate", false, "regenerate the ETC fixtures before running them")
 )
 
 func TestMain(m *testing.M) {
@@ -54,6 +56,11 @@ var (
 	vmTestDir          = filepath.Join(baseDir, "VMTests")
 	rlpTestDir         = filepath.Join(baseDir, "RLPTests")
 	difficultyTestDir  = filepath.Join(baseDir, "BasicTests")
+
+	// The fixtures of the ETC specific rules are generated in this repository
+	etcTestDir           = filepath.Join(".", "etc-fixtures")
+	etcBlockTestDir      = filepath.Join(etcTestDir, "BlockchainTests")
+	etcDifficultyTestDir = filepath.Join(etcTestDir, "DifficultyTests")
 )
 
 func readJSON(reader io.Reader, value interface{}) error {
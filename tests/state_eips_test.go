@@ -0,0 +1,161 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	eipTestSender   = common.HexToAddress("0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b")
+	eipTestContract = common.HexToAddress("0x0000000000000000000000000000000000001000")
+	eipTestFunds    = big.NewInt(1000000000000000000)
+)
+
+// eipStateTest assembles a general state test sending a single transaction with
+// the given target and data. The target account is deployed with code and a
+// single storage slot set.
+func eipStateTest(t *testing.T, to, code, data string) *StateTest {
+	src := fmt.Sprintf(`{
+		"env": {
+			"currentCoinbase": "2adc25665018aa1fe0e6bc666dac8fc2697ff9ba",
+			"currentDifficulty": "0x020000",
+			"currentGasLimit": "0xff112233445566",
+			"currentNumber": "1",
+			"currentTimestamp": "1000"
+		},
+		"pre": {
+			"a94f5374fce5edbc8e2a8697c15331677e6ebf0b": {
+				"balance": "%d", "code": "0x", "nonce": "0", "storage": {}
+			},
+			"0000000000000000000000000000000000001000": {
+				"balance": "0", "code": "%s", "nonce": "0",
+				"storage": {"0x00": "0x01"}
+			}
+		},
+		"transaction": {
+			"data": ["%s"],
+			"gasLimit": ["0x0186a0"],
+			"gasPrice": "0x01",
+			"nonce": "0x00",
+			"secretKey": "0x45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8",
+			"to": "%s",
+			"value": ["0x00"]
+		}
+	}`, eipTestFunds, code, data, to)
+
+	test := new(StateTest)
+	if err := json.Unmarshal([]byte(src), test); err != nil {
+		t.Fatalf("failed to parse state test: %v", err)
+	}
+	return test
+}
+
+// runEipStateTest executes the state test with the given fork, returning the
+// resulting state and the gas paid for by the sender.
+func runEipStateTest(t *testing.T, test *StateTest, fork string) (*state.StateDB, uint64) {
+	test.json.Post = map[string][]stPostState{fork: {{}}}
+
+	_, statedb, _, err := test.RunNoVerify(StateSubtest{Fork: fork}, vm.Config{}, false)
+	if err != nil {
+		t.Fatalf("fork %s: failed to run state test: %v", fork, err)
+	}
+	paid := new(big.Int).Sub(eipTestFunds, statedb.GetBalance(eipTestSender))
+	return statedb, paid.Uint64()
+}
+
+// Tests that EIP-3529 reduces the SSTORE clearing refund and caps refunds to a
+// fifth of the gas used.
+func TestStateEIP3529(t *testing.T) {
+	// Clear the preset storage slot: sstore(0, 0)
+	test := eipStateTest(t, eipTestContract.Hex(), "0x6000600055", "0x")
+
+	// Intrinsic gas, two pushes, a cold slot access and a reset
+	used := uint64(21000 + 3 + 3 + 2100 + 2900)
+	for _, tt := range []struct {
+		fork string
+		paid uint64
+	}{
+		{"Berlin", used - used/2},           // 15000 refund capped to half
+		{"Berlin+3529", used - 4800},        // 4800 refund under the fifth cap
+		{"Mystique", used - 4800},           // Activated by the chain config
+		{"Berlin+2929+3529", used - 4800},   // Redundant eips are harmless
+		{"Mystique+3529+3541", used - 4800}, // Idem
+		{"YOLOv2", used - used/2},           // Not part of the fork
+	} {
+		if _, paid := runEipStateTest(t, test, tt.fork); paid != tt.paid {
+			t.Errorf("fork %s: gas paid mismatch: have %d, want %d", tt.fork, paid, tt.paid)
+		}
+	}
+}
+
+// Tests that EIP-3541 rejects new contract code starting with the 0xEF byte.
+func TestStateEIP3541(t *testing.T) {
+	// Deploy the code 0xEF: mstore8(0, 0xef) return(0, 1)
+	test := eipStateTest(t, "", "0x", "0x60ef60005360016000f3")
+	created := crypto.CreateAddress(eipTestSender, 0)
+
+	for _, tt := range []struct {
+		fork     string
+		rejected bool
+	}{
+		{"Berlin", false},
+		{"Berlin+3541", true},
+		{"Mystique", true},
+	} {
+		statedb, paid := runEipStateTest(t, test, tt.fork)
+		code := statedb.GetCode(created)
+		if tt.rejected {
+			if len(code) != 0 {
+				t.Errorf("fork %s: code deployed: %x", tt.fork, code)
+			}
+			if paid != 100000 {
+				t.Errorf("fork %s: gas paid mismatch: have %d, want all gas", tt.fork, paid)
+			}
+		} else if len(code) != 1 || code[0] != 0xEF {
+			t.Errorf("fork %s: code mismatch: have %x, want ef", tt.fork, code)
+		}
+	}
+}
+
+// Tests that EIP-3855 introduces the PUSH0 instruction.
+func TestStateEIP3855(t *testing.T) {
+	// Store using a zero key pushed by PUSH0: sstore(push0, 2)
+	test := eipStateTest(t, eipTestContract.Hex(), "0x60025f55", "0x")
+
+	for _, tt := range []struct {
+		fork  string
+		value common.Hash
+	}{
+		{"Berlin", common.BigToHash(big.NewInt(1))}, // invalid opcode, storage untouched
+		{"Mystique", common.BigToHash(big.NewInt(1))},
+		{"Berlin+3855", common.BigToHash(big.NewInt(2))},
+	} {
+		statedb, _ := runEipStateTest(t, test, tt.fork)
+		if have := statedb.GetState(eipTestContract, common.Hash{}); have != tt.value {
+			t.Errorf("fork %s: storage mismatch: have %x, want %x", tt.fork, have, tt.value)
+		}
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
 )
 
@@ -52,10 +53,16 @@ type SecureTrie struct {
 // A new cache generation is created by each call to Commit.
 // cachelimit sets the number of past cache generations to keep.
 func NewSecure(root common.Hash, db *Database) (*SecureTrie, error) {
+	return NewSecureWithWitness(root, db, nil)
+}
+
+// NewSecureWithWitness creates a secure trie like NewSecure, recording the
+// nodes loaded from db into witness as NewWithWitness does.
+func NewSecureWithWitness(root common.Hash, db *Database, witness ethdb.KeyValueWriter) (*SecureTrie, error) {
 	if db == nil {
 		panic("trie.NewSecure called without a database")
 	}
-	trie, err := New(root, db)
+	trie, err := NewWithWitness(root, db, witness)
 	if err != nil {
 		return nil, err
 	}
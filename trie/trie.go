@@ -24,6 +24,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
 )
@@ -53,6 +54,9 @@ type Trie struct {
 	// hashing operation. This number will not directly map to the number of
 	// actually unhashed nodes
 	unhashed int
+
+	// witness, if set, receives every node loaded from db
+	witness ethdb.KeyValueWriter
 }
 
 // newFlag returns the cache flag value for a newly created node.
@@ -67,11 +71,20 @@ func (t *Trie) newFlag() nodeFlag {
 // New will panic if db is nil and returns a MissingNodeError if root does
 // not exist in the database. Accessing the trie loads nodes from db on demand.
 func New(root common.Hash, db *Database) (*Trie, error) {
+	return NewWithWitness(root, db, nil)
+}
+
+// NewWithWitness creates a trie like New, additionally writing the RLP
+// encoding of every node loaded from db into witness, keyed by its hash.
+// The collected nodes suffice to repeat the same trie accesses and updates
+// without db.
+func NewWithWitness(root common.Hash, db *Database, witness ethdb.KeyValueWriter) (*Trie, error) {
 	if db == nil {
 		panic("trie.New called without a database")
 	}
 	trie := &Trie{
-		db: db,
+		db:      db,
+		witness: witness,
 	}
 	if root != (common.Hash{}) && root != emptyRoot {
 		rootnode, err := trie.resolveHash(root[:], nil)
@@ -483,6 +496,11 @@ func (t *Trie) resolve(n node, prefix []byte) (node, error) {
 func (t *Trie) resolveHash(n hashNode, prefix []byte) (node, error) {
 	hash := common.BytesToHash(n)
 	if node := t.db.node(hash); node != nil {
+		if t.witness != nil {
+			if enc, err := t.db.Node(hash); err == nil {
+				t.witness.Put(hash[:], enc)
+			}
+		}
 		return node, nil
 	}
 	return nil, &MissingNodeError{NodeHash: hash, Path: prefix}